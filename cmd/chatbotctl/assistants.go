@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/config"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/services"
+	"github.com/sotatek-dev/hyper-automation-chatbot/pkg/logger"
+)
+
+func syncAssistants(args []string) {
+	log := logger.NewLogger()
+	flags := flag.NewFlagSet("assistants sync", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "only print the changes")
+	configPath := flags.String("config", ".", "directory of the .env configuration")
+	timeout := flags.Duration("timeout", 2*time.Minute, "time allowed for the whole sync")
+	flags.Parse(args)
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Cannot load config")
+	}
+	if cfg.LLM.Backend != config.LLMBackendAzureAssistants {
+		log.Fatal().Str("backend", cfg.LLM.Backend).Msg("Assistants are only stored by the Azure Assistants backend")
+	}
+	router, err := services.NewAssistantRouter(cfg.Assistants)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Cannot load assistants")
+	}
+	syncService := services.NewAssistantSyncService(
+		services.NewAzureAssistantsBackend(http.DefaultClient, cfg.AzureOpenAI),
+		router,
+		cfg.Assistants,
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	changes, err := syncService.Plan(ctx)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Cannot compare the assistants with Azure")
+	}
+	for i := range changes {
+		printChange(os.Stdout, changes[i])
+		if *dryRun || changes[i].Action == services.AssistantSyncUnchanged {
+			continue
+		}
+		err = syncService.Apply(ctx, &changes[i])
+		if err != nil {
+			log.Fatal().Err(err).Msg("Cannot sync assistant")
+		}
+		if changes[i].Action == services.AssistantSyncCreate {
+			fmt.Printf("  created %s, set it as the assistant_id of %s\n", changes[i].Desired.ID, changes[i].Name)
+		}
+	}
+}
+
+func printChange(w io.Writer, change services.AssistantChange) {
+	version := change.Desired.Metadata[services.AssistantVersionMetadata]
+	switch change.Action {
+	case services.AssistantSyncCreate:
+		fmt.Fprintf(w, "%s: create version %s\n", change.Name, version)
+	case services.AssistantSyncUnchanged:
+		fmt.Fprintf(w, "%s: unchanged, version %s\n", change.Name, version)
+	case services.AssistantSyncUpdate:
+		fmt.Fprintf(w, "%s: update %s to version %s\n", change.Name, strings.Join(change.Fields, ", "), version)
+		for _, line := range diffLines(change.Current.Instructions, change.Desired.Instructions) {
+			fmt.Fprintf(w, "  %s\n", line)
+		}
+	}
+}
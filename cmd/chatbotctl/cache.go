@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/config"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/database"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/repository"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/services"
+	"github.com/sotatek-dev/hyper-automation-chatbot/pkg/logger"
+)
+
+func clearAnswerCache(args []string) {
+	log := logger.NewLogger()
+	flags := flag.NewFlagSet("cache clear", flag.ExitOnError)
+	assistant := flags.String("assistant", "", "assistant whose answers are dropped, all of them when empty")
+	configPath := flags.String("config", ".", "directory of the .env configuration")
+	flags.Parse(args)
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Cannot load config")
+	}
+	db, err := database.Initialize(&cfg.DB)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Cannot initialize database")
+	}
+	err = database.Migrate(db)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Cannot migrate database")
+	}
+
+	answerCache := services.NewAnswerCacheService(repository.NewCachedAnswerRepository(db), cfg.AnswerCache)
+	deleted, err := answerCache.Invalidate(*assistant)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Cannot clear the answer cache")
+	}
+	fmt.Printf("%d cached answers dropped\n", deleted)
+}
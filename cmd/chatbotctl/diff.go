@@ -0,0 +1,74 @@
+package main
+
+import "strings"
+
+// diffContext is the number of unchanged lines shown around the changed ones.
+const diffContext = 2
+
+// diffLines compares the lines of from and to. It returns the removed lines
+// prefixed by "- ", the added ones by "+ " and the unchanged lines around
+// them by "  ", longer runs of unchanged lines are cut to "...". Nothing is
+// returned when both are equal.
+func diffLines(from string, to string) []string {
+	if from == to {
+		return nil
+	}
+	a := strings.Split(from, "\n")
+	b := strings.Split(to, "\n")
+	// common[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:].
+	common := make([][]int, len(a)+1)
+	for i := range common {
+		common[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	lines := []string{}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, "  "+a[i])
+			i++
+			j++
+		case i < len(a) && (j == len(b) || common[i+1][j] >= common[i][j+1]):
+			lines = append(lines, "- "+a[i])
+			i++
+		default:
+			lines = append(lines, "+ "+b[j])
+			j++
+		}
+	}
+	return trimUnchanged(lines)
+}
+
+// trimUnchanged keeps the unchanged lines at most diffContext lines away from
+// a changed one.
+func trimUnchanged(lines []string) []string {
+	keep := make([]bool, len(lines))
+	for i, line := range lines {
+		if strings.HasPrefix(line, "  ") {
+			continue
+		}
+		for k := max(0, i-diffContext); k <= min(len(lines)-1, i+diffContext); k++ {
+			keep[k] = true
+		}
+	}
+	trimmed := []string{}
+	for i, line := range lines {
+		if keep[i] {
+			trimmed = append(trimmed, line)
+		} else if i == 0 || keep[i-1] {
+			trimmed = append(trimmed, "...")
+		}
+	}
+	return trimmed
+}
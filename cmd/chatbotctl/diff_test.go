@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		expected []string
+	}{
+		{name: "equal", from: "a\nb", to: "a\nb", expected: nil},
+		{name: "changed line", from: "a\nb\nc", to: "a\nB\nc", expected: []string{"  a", "- b", "+ B", "  c"}},
+		{name: "added line", from: "a\nc", to: "a\nb\nc", expected: []string{"  a", "+ b", "  c"}},
+		{name: "from nothing", from: "", to: "a", expected: []string{"- ", "+ a"}},
+		{
+			name:     "long unchanged runs are cut",
+			from:     "1\n2\n3\n4\n5\n6\n7\n8",
+			to:       "1\n2\n3\n4\nfive\n6\n7\n8",
+			expected: []string{"...", "  3", "  4", "- 5", "+ five", "  6", "  7", "..."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, diffLines(tt.from, tt.to))
+		})
+	}
+}
@@ -0,0 +1,48 @@
+// Command chatbotctl administers the chatbot.
+//
+//	go run ./cmd/chatbotctl assistants sync -dry-run
+//	go run ./cmd/chatbotctl assistants sync
+//	go run ./cmd/chatbotctl cache clear -assistant hr_policy_qa
+//
+// assistants sync creates and updates the assistants on Azure OpenAI from the
+// definitions of the assistants file (ASSISTANTS_FILE): name, model,
+// instructions, tools, file_search stores and version. It prints what differs
+// from Azure, the instructions line by line, then applies it unless -dry-run
+// is given. Run it when deploying a change of the assistants file, and set
+// the assistant_id of the assistants it creates.
+//
+// cache clear drops the cached answers of an assistant, of all of them without
+// -assistant, e.g. after a policy changed without a new assistant version.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+type command struct {
+	group string
+	name  string
+	run   func(args []string)
+}
+
+var commands = []command{
+	{group: "assistants", name: "sync", run: syncAssistants},
+	{group: "cache", name: "clear", run: clearAnswerCache},
+}
+
+func main() {
+	if len(os.Args) >= 3 {
+		for _, command := range commands {
+			if os.Args[1] == command.group && os.Args[2] == command.name {
+				command.run(os.Args[3:])
+				return
+			}
+		}
+	}
+	fmt.Fprintln(os.Stderr, "Usage:")
+	for _, command := range commands {
+		fmt.Fprintf(os.Stderr, "  %s %s %s [flags]\n", os.Args[0], command.group, command.name)
+	}
+	os.Exit(2)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// cassette holds the HTTP exchanges with the LLM API of every corpus case, in
+// the order they happened, so an evaluation can be replayed without network
+// access.
+type cassette struct {
+	Backend string                `json:"backend"`
+	Cases   map[string][]exchange `json:"cases"`
+}
+
+type exchange struct {
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        string `json:"body"`
+}
+
+func loadCassette(path string) (*cassette, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var recorded cassette
+	err = json.Unmarshal(content, &recorded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recording %s: %w", path, err)
+	}
+	return &recorded, nil
+}
+
+func (c *cassette) save(path string) error {
+	content, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0o644)
+}
+
+// cassetteTransport records the exchanges of the current case when it has a
+// base transport, and replays the recorded ones otherwise. Replayed requests
+// must come in the recorded order; the IDs in their paths come from the
+// replayed responses, so they match as long as the detection path does not
+// change.
+type cassetteTransport struct {
+	base     http.RoundTripper
+	cassette *cassette
+	current  string
+	position int
+}
+
+func newRecordingTransport(base http.RoundTripper, backend string) *cassetteTransport {
+	return &cassetteTransport{base: base, cassette: &cassette{Backend: backend, Cases: map[string][]exchange{}}}
+}
+
+func newReplayingTransport(recorded *cassette) *cassetteTransport {
+	return &cassetteTransport{cassette: recorded}
+}
+
+// begin starts the exchanges of the case of utterance.
+func (t *cassetteTransport) begin(utterance string) {
+	t.current = utterance
+	t.position = 0
+	if t.base != nil {
+		t.cassette.Cases[utterance] = []exchange{}
+	}
+}
+
+func (t *cassetteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.base == nil {
+		return t.replay(req)
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	t.cassette.Cases[t.current] = append(t.cassette.Cases[t.current], exchange{
+		Method:      req.Method,
+		Path:        req.URL.Path,
+		Status:      resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        string(body),
+	})
+	return resp, nil
+}
+
+func (t *cassetteTransport) replay(req *http.Request) (*http.Response, error) {
+	exchanges, ok := t.cassette.Cases[t.current]
+	if !ok {
+		return nil, fmt.Errorf("no recording for %q, record the corpus again", t.current)
+	}
+	if t.position >= len(exchanges) {
+		return nil, fmt.Errorf("recording of %q ends before %s %s, record the corpus again", t.current, req.Method, req.URL.Path)
+	}
+	recorded := exchanges[t.position]
+	if recorded.Method != req.Method || recorded.Path != req.URL.Path {
+		return nil, fmt.Errorf("recording of %q expects %s %s, not %s %s, record the corpus again", t.current, recorded.Method, recorded.Path, req.Method, req.URL.Path)
+	}
+	t.position++
+	header := http.Header{}
+	if recorded.ContentType != "" {
+		header.Set("Content-Type", recorded.ContentType)
+	}
+	return &http.Response{
+		Status:     fmt.Sprintf("%d %s", recorded.Status, http.StatusText(recorded.Status)),
+		StatusCode: recorded.Status,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(recorded.Body)),
+		Request:    req,
+	}, nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// noAction is the expected action of the utterances the assistant should
+// answer without calling any function.
+const noAction = "none"
+
+// evalCase is a user utterance with the action the assistant should call for
+// it and the arguments it should extract. Only the listed arguments are
+// checked.
+type evalCase struct {
+	Utterance string                 `yaml:"utterance" json:"utterance"`
+	Action    string                 `yaml:"action" json:"action"`
+	Arguments map[string]interface{} `yaml:"arguments" json:"arguments"`
+}
+
+// loadCorpus reads the cases of a YAML file, holding a "cases" list, or of a
+// JSONL file, one case per line.
+func loadCorpus(path string) ([]evalCase, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cases []evalCase
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		corpus := struct {
+			Cases []evalCase `yaml:"cases"`
+		}{}
+		err = yaml.Unmarshal(content, &corpus)
+		if err != nil {
+			return nil, fmt.Errorf("invalid corpus %s: %w", path, err)
+		}
+		cases = corpus.Cases
+	case ".jsonl":
+		scanner := bufio.NewScanner(bytes.NewReader(content))
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for line := 1; scanner.Scan(); line++ {
+			if strings.TrimSpace(scanner.Text()) == "" {
+				continue
+			}
+			var evalCase evalCase
+			err = json.Unmarshal(scanner.Bytes(), &evalCase)
+			if err != nil {
+				return nil, fmt.Errorf("invalid corpus %s line %d: %w", path, line, err)
+			}
+			cases = append(cases, evalCase)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported corpus format: %s", path)
+	}
+
+	seen := map[string]bool{}
+	for i := range cases {
+		if strings.TrimSpace(cases[i].Utterance) == "" {
+			return nil, fmt.Errorf("corpus %s case %d has no utterance", path, i+1)
+		}
+		// Recorded responses are keyed by utterance.
+		if seen[cases[i].Utterance] {
+			return nil, fmt.Errorf("corpus %s has the utterance %q twice", path, cases[i].Utterance)
+		}
+		seen[cases[i].Utterance] = true
+		if cases[i].Action == "" {
+			cases[i].Action = noAction
+		}
+	}
+	return cases, nil
+}
@@ -0,0 +1,124 @@
+// Command evalintent measures how well the assistants detect the actions users
+// ask for. It sends each utterance of a corpus to the default assistant, the
+// way AddAndRunMessage does including the handoffs between assistants, and
+// reports the precision and recall of each action, a confusion matrix and the
+// expected arguments the detected actions miss.
+//
+//	go run ./cmd/evalintent -corpus evalintent.yaml -record evalintent.recording.json
+//	go run ./cmd/evalintent -corpus evalintent.yaml -replay evalintent.recording.json -min-accuracy 0.9
+//
+// The corpus is a YAML file with a "cases" list, or a JSONL file with one
+// case per line; utterances the assistant should answer without calling an
+// action expect the action "none":
+//
+//	cases:
+//	  - utterance: I want to take leave on 2024-05-02 from 8:00 to 12:00
+//	    action: take_leave
+//	    arguments:
+//	      request_date_from: "2024-05-02"
+//	      hour_from: "08:00"
+//	  - utterance: Hello!
+//	    action: none
+//
+// With -record, the exchanges with the LLM API are saved so that -replay runs
+// the evaluation again without network access, e.g. in CI where .env.example
+// can be copied to .env. Record again after changing the prompts, the
+// functions or the corpus.
+package main
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/config"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/services"
+	"github.com/sotatek-dev/hyper-automation-chatbot/pkg/logger"
+)
+
+func main() {
+	corpusPath := flag.String("corpus", "", "YAML or JSONL file of the utterances and their expected actions")
+	recordPath := flag.String("record", "", "file to save the LLM API exchanges to")
+	replayPath := flag.String("replay", "", "file of recorded LLM API exchanges to replay instead of calling the API")
+	configPath := flag.String("config", ".", "directory of the .env configuration")
+	pollInterval := flag.Duration("poll-interval", time.Second, "how often a run is checked until it is over, when not replaying")
+	minAccuracy := flag.Float64("min-accuracy", 0, "exit with an error when the accuracy is below this value")
+	flag.Parse()
+
+	log := logger.NewLogger()
+	if *corpusPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *recordPath != "" && *replayPath != "" {
+		log.Fatal().Msg("-record and -replay are exclusive")
+	}
+
+	cases, err := loadCorpus(*corpusPath)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Cannot load corpus")
+	}
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Cannot load config")
+	}
+	router, err := services.NewAssistantRouter(cfg.Assistants)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Cannot load assistants")
+	}
+
+	var recorded *cassette
+	if *replayPath != "" {
+		recorded, err = loadCassette(*replayPath)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Cannot load recording")
+		}
+		if recorded.Backend != cfg.LLM.Backend {
+			log.Fatal().Str("recorded", recorded.Backend).Str("configured", cfg.LLM.Backend).Msg("Recording is of another LLM backend")
+		}
+		*pollInterval = time.Millisecond
+	}
+	var transport *cassetteTransport
+	wrap := func(base http.RoundTripper) http.RoundTripper {
+		switch {
+		case recorded != nil:
+			transport = newReplayingTransport(recorded)
+		case *recordPath != "":
+			transport = newRecordingTransport(base, cfg.LLM.Backend)
+		default:
+			return base
+		}
+		return transport
+	}
+	backend := services.NewLLMBackendWithTransport(cfg.LLM, cfg.AzureOpenAI, wrap)
+	aiChatbotService := services.NewAIChatbotService(backend, cfg.AzureOpenAI, nil, nil, nil, nil, router, nil, nil, nil)
+	aiChatbotService.SetPollInterval(*pollInterval)
+
+	results := make([]caseResult, 0, len(cases))
+	for _, evalCase := range cases {
+		if transport != nil {
+			transport.begin(evalCase.Utterance)
+		}
+		toolCalls, err := aiChatbotService.DetectActions(context.Background(), evalCase.Utterance)
+		if err != nil {
+			log.Warn().Err(err).Str("utterance", evalCase.Utterance).Msg("Cannot detect actions")
+		}
+		results = append(results, newCaseResult(evalCase, toolCalls, err))
+	}
+
+	if *recordPath != "" {
+		err = transport.cassette.save(*recordPath)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Cannot save recording")
+		}
+	}
+
+	evaluation := evaluate(results)
+	evaluation.write(os.Stdout)
+	if evaluation.Accuracy() < *minAccuracy {
+		log.Error().Float64("accuracy", evaluation.Accuracy()).Float64("min_accuracy", *minAccuracy).Msg("Accuracy below the minimum")
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+)
+
+// errorAction is the predicted action of the cases whose detection failed.
+const errorAction = "error"
+
+// caseResult is what the assistant did for a case: the first action it called,
+// with its arguments.
+type caseResult struct {
+	Case      evalCase
+	Action    string
+	Arguments json.RawMessage
+	Err       error
+}
+
+func newCaseResult(evalCase evalCase, toolCalls []dto.AIToolCall, err error) caseResult {
+	result := caseResult{Case: evalCase, Action: noAction, Err: err}
+	if err != nil {
+		result.Action = errorAction
+	} else if len(toolCalls) > 0 {
+		result.Action = toolCalls[0].Name
+		result.Arguments = toolCalls[0].Arguments
+	}
+	return result
+}
+
+// actionScore counts the cases of an action: true positives, false positives
+// and false negatives.
+type actionScore struct {
+	TruePositives  int
+	FalsePositives int
+	FalseNegatives int
+}
+
+// Precision and Recall report false when undefined, i.e. the action was
+// never predicted, or never expected.
+func (s actionScore) Precision() (float64, bool) {
+	predicted := s.TruePositives + s.FalsePositives
+	if predicted == 0 {
+		return 0, false
+	}
+	return float64(s.TruePositives) / float64(predicted), true
+}
+
+func (s actionScore) Recall() (float64, bool) {
+	expected := s.TruePositives + s.FalseNegatives
+	if expected == 0 {
+		return 0, false
+	}
+	return float64(s.TruePositives) / float64(expected), true
+}
+
+// evaluation scores the results of a corpus.
+type evaluation struct {
+	Cases     int
+	Correct   int
+	Actions   []string
+	Scores    map[string]*actionScore
+	Confusion map[string]map[string]int
+	// ArgumentCases counts the correctly detected cases with expected
+	// arguments, ArgumentMatches the ones where they were all extracted.
+	ArgumentCases   int
+	ArgumentMatches int
+	Mismatches      []string
+}
+
+func evaluate(results []caseResult) evaluation {
+	e := evaluation{
+		Cases:     len(results),
+		Scores:    map[string]*actionScore{},
+		Confusion: map[string]map[string]int{},
+	}
+	score := func(action string) *actionScore {
+		if _, ok := e.Scores[action]; !ok {
+			e.Scores[action] = &actionScore{}
+		}
+		return e.Scores[action]
+	}
+	for _, result := range results {
+		expected := result.Case.Action
+		if e.Confusion[expected] == nil {
+			e.Confusion[expected] = map[string]int{}
+		}
+		e.Confusion[expected][result.Action]++
+		score(result.Action)
+		if result.Action == expected {
+			e.Correct++
+			score(expected).TruePositives++
+		} else {
+			score(expected).FalseNegatives++
+			score(result.Action).FalsePositives++
+			mismatch := fmt.Sprintf("%q: expected %s, got %s", result.Case.Utterance, expected, result.Action)
+			if result.Err != nil {
+				mismatch += ": " + result.Err.Error()
+			}
+			e.Mismatches = append(e.Mismatches, mismatch)
+			continue
+		}
+		if len(result.Case.Arguments) == 0 {
+			continue
+		}
+		e.ArgumentCases++
+		differences := compareArguments(result.Case.Arguments, result.Arguments)
+		if len(differences) == 0 {
+			e.ArgumentMatches++
+			continue
+		}
+		e.Mismatches = append(e.Mismatches, fmt.Sprintf("%q: %s arguments %s", result.Case.Utterance, expected, strings.Join(differences, ", ")))
+	}
+	for action := range e.Scores {
+		e.Actions = append(e.Actions, action)
+	}
+	// The actions are sorted, with none and error last.
+	sort.Slice(e.Actions, func(i, j int) bool {
+		rank := func(action string) int {
+			switch action {
+			case noAction:
+				return 1
+			case errorAction:
+				return 2
+			}
+			return 0
+		}
+		if rank(e.Actions[i]) != rank(e.Actions[j]) {
+			return rank(e.Actions[i]) < rank(e.Actions[j])
+		}
+		return e.Actions[i] < e.Actions[j]
+	})
+	return e
+}
+
+// Accuracy is the share of cases whose action was detected.
+func (e evaluation) Accuracy() float64 {
+	if e.Cases == 0 {
+		return 0
+	}
+	return float64(e.Correct) / float64(e.Cases)
+}
+
+// compareArguments lists the expected arguments the extracted ones miss or
+// hold another value for. Values are compared as text, ignoring case and
+// surrounding spaces.
+func compareArguments(expected map[string]interface{}, extracted json.RawMessage) []string {
+	actual := map[string]interface{}{}
+	if len(extracted) > 0 {
+		if err := json.Unmarshal(extracted, &actual); err != nil {
+			return []string{fmt.Sprintf("are invalid JSON: %v", err)}
+		}
+	}
+	names := make([]string, 0, len(expected))
+	for name := range expected {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	differences := []string{}
+	for _, name := range names {
+		value, ok := actual[name]
+		if !ok {
+			differences = append(differences, fmt.Sprintf("%s: missing", name))
+			continue
+		}
+		if normalizeArgument(value) != normalizeArgument(expected[name]) {
+			differences = append(differences, fmt.Sprintf("%s: expected %q, got %q", name, fmt.Sprint(expected[name]), fmt.Sprint(value)))
+		}
+	}
+	return differences
+}
+
+func normalizeArgument(value interface{}) string {
+	return strings.ToLower(strings.TrimSpace(fmt.Sprint(value)))
+}
+
+func (e evaluation) write(out io.Writer) {
+	fmt.Fprintf(out, "Intent detection: %d cases, accuracy %.3f\n\n", e.Cases, e.Accuracy())
+
+	table := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(table, "action\tprecision\trecall\tsupport\t")
+	for _, action := range e.Actions {
+		score := e.Scores[action]
+		fmt.Fprintf(table, "%s\t%s\t%s\t%d\t\n", action, formatRatio(score.Precision()), formatRatio(score.Recall()), score.TruePositives+score.FalseNegatives)
+	}
+	table.Flush()
+
+	fmt.Fprintln(out, "\nConfusion matrix (rows: expected, columns: detected)")
+	table = tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(table, "\t%s\t\n", strings.Join(e.Actions, "\t"))
+	for _, expected := range e.Actions {
+		if e.Confusion[expected] == nil {
+			continue
+		}
+		row := []string{expected}
+		for _, detected := range e.Actions {
+			row = append(row, fmt.Sprint(e.Confusion[expected][detected]))
+		}
+		fmt.Fprintf(table, "%s\t\n", strings.Join(row, "\t"))
+	}
+	table.Flush()
+
+	if e.ArgumentCases > 0 {
+		fmt.Fprintf(out, "\nArguments: %d/%d detected cases with all the expected arguments\n", e.ArgumentMatches, e.ArgumentCases)
+	}
+	if len(e.Mismatches) > 0 {
+		fmt.Fprintln(out, "\nMismatches:")
+		for _, mismatch := range e.Mismatches {
+			fmt.Fprintf(out, "- %s\n", mismatch)
+		}
+	}
+}
+
+func formatRatio(ratio float64, ok bool) string {
+	if !ok {
+		return "-"
+	}
+	return fmt.Sprintf("%.3f", ratio)
+}
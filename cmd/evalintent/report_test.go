@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadCorpus(t *testing.T) {
+	tests := []struct {
+		name          string
+		path          string
+		expectedCases []evalCase
+	}{
+		{
+			name: "yaml",
+			path: "testdata/corpus.yaml",
+			expectedCases: []evalCase{
+				{
+					Utterance: "I want to take leave on 2024-05-02 from 8:00 to 12:00",
+					Action:    dto.ToolTakeLeave,
+					Arguments: map[string]interface{}{"request_date_from": "2024-05-02", "hour_from": "08:00"},
+				},
+				{
+					Utterance: "Register me for the Go training in https://docs.google.com/spreadsheets/d/abc",
+					Action:    dto.ToolTrainingRequest,
+					Arguments: map[string]interface{}{"sheet_url": "https://docs.google.com/spreadsheets/d/abc"},
+				},
+				{Utterance: "Create the buddy form for the new joiners", Action: dto.ToolCreateBuddyFormFile},
+				{Utterance: "Hello!", Action: noAction},
+			},
+		},
+		{
+			name: "jsonl",
+			path: "testdata/corpus.jsonl",
+			expectedCases: []evalCase{
+				{
+					Utterance: "Xin nghỉ phép ngày 2024-05-02",
+					Action:    dto.ToolTakeLeave,
+					Arguments: map[string]interface{}{"request_date_from": "2024-05-02"},
+				},
+				{Utterance: "Thanks", Action: noAction},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cases, err := loadCorpus(tt.path)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedCases, cases)
+		})
+	}
+}
+
+func TestLoadCorpusRejectsDuplicateUtterances(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "corpus.jsonl")
+	assert.NoError(t, os.WriteFile(path, []byte("{\"utterance\": \"Hi\"}\n{\"utterance\": \"Hi\"}\n"), 0o644))
+
+	_, err := loadCorpus(path)
+
+	assert.ErrorContains(t, err, "twice")
+}
+
+func TestEvaluate(t *testing.T) {
+	takeLeave := evalCase{Utterance: "leave", Action: dto.ToolTakeLeave, Arguments: map[string]interface{}{"request_date_from": "2024-05-02"}}
+	training := evalCase{Utterance: "training", Action: dto.ToolTrainingRequest}
+	greeting := evalCase{Utterance: "hello", Action: noAction}
+	results := []caseResult{
+		newCaseResult(takeLeave, []dto.AIToolCall{{Name: dto.ToolTakeLeave, Arguments: json.RawMessage(`{"request_date_from": " 2024-05-02 "}`)}}, nil),
+		newCaseResult(evalCase{Utterance: "leave 2", Action: dto.ToolTakeLeave, Arguments: map[string]interface{}{"hour_from": "08:00"}}, []dto.AIToolCall{{Name: dto.ToolTakeLeave, Arguments: json.RawMessage(`{}`)}}, nil),
+		newCaseResult(training, []dto.AIToolCall{{Name: dto.ToolTakeLeave}}, nil),
+		newCaseResult(greeting, nil, nil),
+		newCaseResult(evalCase{Utterance: "hi", Action: noAction}, nil, errors.New("run failed")),
+	}
+
+	evaluation := evaluate(results)
+
+	assert.Equal(t, 5, evaluation.Cases)
+	assert.Equal(t, 3, evaluation.Correct)
+	assert.Equal(t, []string{dto.ToolTakeLeave, dto.ToolTrainingRequest, noAction, errorAction}, evaluation.Actions)
+	assert.Equal(t, actionScore{TruePositives: 2, FalsePositives: 1}, *evaluation.Scores[dto.ToolTakeLeave])
+	assert.Equal(t, actionScore{FalseNegatives: 1}, *evaluation.Scores[dto.ToolTrainingRequest])
+	assert.Equal(t, actionScore{TruePositives: 1, FalseNegatives: 1}, *evaluation.Scores[noAction])
+	precision, ok := evaluation.Scores[dto.ToolTakeLeave].Precision()
+	assert.True(t, ok)
+	assert.InDelta(t, 2.0/3, precision, 1e-9)
+	_, ok = evaluation.Scores[dto.ToolTrainingRequest].Precision()
+	assert.False(t, ok)
+	assert.Equal(t, map[string]map[string]int{
+		dto.ToolTakeLeave:       {dto.ToolTakeLeave: 2},
+		dto.ToolTrainingRequest: {dto.ToolTakeLeave: 1},
+		noAction:                {noAction: 1, errorAction: 1},
+	}, evaluation.Confusion)
+	assert.Equal(t, 2, evaluation.ArgumentCases)
+	assert.Equal(t, 1, evaluation.ArgumentMatches)
+	assert.Equal(t, []string{
+		`"leave 2": take_leave arguments hour_from: missing`,
+		`"training": expected training_request, got take_leave`,
+		`"hi": expected none, got error: run failed`,
+	}, evaluation.Mismatches)
+
+	var report strings.Builder
+	evaluation.write(&report)
+	assert.Contains(t, report.String(), "accuracy 0.600")
+	assert.Contains(t, report.String(), "Arguments: 1/2")
+}
+
+// fakeRoundTripper answers every request with the next of its bodies.
+type fakeRoundTripper struct {
+	bodies []string
+}
+
+func (f *fakeRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	body := f.bodies[0]
+	f.bodies = f.bodies[1:]
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func TestCassetteRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recording.json")
+	recording := newRecordingTransport(&fakeRoundTripper{bodies: []string{`{"id": "thread_1"}`, `{"id": "run_1"}`}}, "azure_assistants")
+	recording.begin("hello")
+	for _, path := range []string{"/openai/threads", "/openai/threads/thread_1/runs"} {
+		req, err := http.NewRequest(http.MethodPost, "https://example.com"+path, nil)
+		assert.NoError(t, err)
+		resp, err := recording.RoundTrip(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+	assert.NoError(t, recording.cassette.save(path))
+
+	recorded, err := loadCassette(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "azure_assistants", recorded.Backend)
+	replaying := newReplayingTransport(recorded)
+	replaying.begin("hello")
+
+	req, _ := http.NewRequest(http.MethodPost, "https://example.com/openai/threads", nil)
+	resp, err := replaying.RoundTrip(req)
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, `{"id": "thread_1"}`, string(body))
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	req, _ = http.NewRequest(http.MethodGet, "https://example.com/openai/threads/thread_1/runs", nil)
+	_, err = replaying.RoundTrip(req)
+	assert.ErrorContains(t, err, "record the corpus again")
+
+	replaying.begin("unknown")
+	_, err = replaying.RoundTrip(req)
+	assert.ErrorContains(t, err, "no recording")
+}
@@ -0,0 +1,107 @@
+// Command ingest indexes company policy documents for the knowledge
+// assistants. It reads Markdown (.md), Google Docs HTML exports (.html) and
+// text extracted from PDFs (.txt, one page per form feed, e.g. pdftotext
+// output), splits them into passages and replaces the passages previously
+// ingested from the same files.
+//
+//	go run ./cmd/ingest -root docs -url-prefix https://wiki.example.com/policies/ docs/hr/leave-policy.md
+//
+// Documents are known by their path relative to the -root directory, e.g.
+// hr/leave-policy.md, so documents named alike in different folders do not
+// replace each other. Each document is linked as the URL prefix followed by
+// that path, or by the URL given with -url when a single document is ingested.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/config"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/database"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/repository"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/services"
+	"github.com/sotatek-dev/hyper-automation-chatbot/pkg/logger"
+)
+
+func main() {
+	root := flag.String("root", ".", "directory the documents are named relative to")
+	urlPrefix := flag.String("url-prefix", "", "URL the documents are published under, followed by their path relative to -root")
+	documentURL := flag.String("url", "", "URL of the document, when a single one is ingested")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] document...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	log := logger.NewLogger()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *documentURL != "" && flag.NArg() > 1 {
+		log.Fatal().Msg("-url is only allowed with a single document")
+	}
+
+	cfg, err := config.LoadConfig(".")
+	if err != nil {
+		log.Fatal().Err(err).Msg("Cannot load config")
+	}
+	db, err := database.Initialize(&cfg.DB)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Cannot initialize database")
+	}
+	err = database.Migrate(db)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Cannot migrate database")
+	}
+
+	knowledgeService := services.NewKnowledgeService(
+		repository.NewDocumentChunkRepository(db),
+		services.NewEmbedder(cfg.RAG, cfg.AzureOpenAI),
+		cfg.RAG,
+	)
+	for _, path := range flag.Args() {
+		source, err := documentSource(*root, path)
+		if err != nil {
+			log.Fatal().Err(err).Str("document", path).Msg("Cannot name document")
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatal().Err(err).Str("document", path).Msg("Cannot read document")
+		}
+		url := *documentURL
+		if url == "" && *urlPrefix != "" {
+			url = *urlPrefix + source
+		}
+		count, err := knowledgeService.IngestDocument(context.Background(), source, url, path, content)
+		if err != nil {
+			log.Fatal().Err(err).Str("document", path).Msg("Cannot ingest document")
+		}
+		log.Info().Str("document", path).Int("passages", count).Msg("Document ingested")
+	}
+}
+
+// documentSource names the document by its slash separated path relative to
+// root, the document must be inside root.
+func documentSource(root string, path string) (string, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	relative, err := filepath.Rel(absRoot, absPath)
+	if err != nil {
+		return "", err
+	}
+	if relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("document %s is outside of %s", path, root)
+	}
+	return filepath.ToSlash(relative), nil
+}
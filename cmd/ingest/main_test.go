@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocumentSource(t *testing.T) {
+	tests := []struct {
+		name     string
+		root     string
+		path     string
+		expected string
+		wantErr  bool
+	}{
+		{name: "document in the root", root: "docs", path: "docs/leave.md", expected: "leave.md"},
+		{name: "documents named alike in different folders", root: "docs", path: "docs/hr/leave.md", expected: "hr/leave.md"},
+		{name: "default root", root: ".", path: "./it/leave.md", expected: "it/leave.md"},
+		{name: "document outside of the root", root: "docs", path: "other/leave.md", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, err := documentSource(tt.root, tt.path)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, source)
+		})
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/sotatek-dev/hyper-automation-chatbot/internal/config"
 	"github.com/sotatek-dev/hyper-automation-chatbot/internal/database"
 	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/models"
 	"github.com/sotatek-dev/hyper-automation-chatbot/internal/rabbit_handler"
 	"github.com/sotatek-dev/hyper-automation-chatbot/internal/shared"
 	"github.com/sotatek-dev/hyper-automation-chatbot/internal/slack_handlers"
@@ -57,6 +58,12 @@ func main() {
 		log.Fatal().Err(err).Msg("Cannot connect rabbit")
 	}
 	dependencies := shared.InitDependencies(db, rabbitConn, &cfg)
+	// Render the Home tab of the users again when their jobs progress
+	dependencies.UIPathJobService.OnJobStateChange(func(job *models.UIPathJob) {
+		if err := dependencies.SlackHandler.HandleJobStateChange(job); err != nil {
+			dependencies.Logger.Error().Err(err).Int("job_id", job.JobID).Msg("Cannot refresh home tab")
+		}
+	})
 	checkUIPathJobDependencies := rabbit_handler.PollingCheckUIPathJobDependencies{
 		UIPathJobService: dependencies.UIPathJobService,
 		Logger:           dependencies.Logger,
@@ -130,10 +137,16 @@ func main() {
 		}
 	}()
 
-	go socket(
-		context.Background(),
-		&dependencies,
-	)
+	go dependencies.ThreadExpiryService.Run(context.Background())
+
+	// Over HTTP, Slack reaches the bot through the routes of the Gin server
+	if cfg.SlackConfig.Mode == config.SlackModeSocket {
+		go socket(
+			context.Background(),
+			&dependencies,
+			dependencies.SlackHandler,
+		)
+	}
 	runGinServer(&dependencies)
 }
 
@@ -154,14 +167,8 @@ func runGinServer(
 
 func socket(ctx context.Context,
 	dependencies *shared.AppDependencies,
+	slackHandler *slack_handlers.SlackHandler,
 ) {
-	slackHandler := slack_handlers.NewSlackHandler(
-		dependencies.SlackClient,
-		dependencies.SlackService,
-		dependencies.AiChatbotService,
-		dependencies.GgSheetService,
-		dependencies.UIPathJobService,
-	)
 	socketClient := socketmode.New(
 		dependencies.SlackClient,
 		socketmode.OptionDebug(true),
@@ -203,10 +210,10 @@ func socket(ctx context.Context,
 						log.Printf("Could not type cast the message to a SlashCommand: %v\n", command)
 						continue
 					}
-					// handleSlashCommand will take care of the command
-					payload, err := slackHandler.HandleSlashCommand(command, client)
+					// Errors are answered with an ephemeral message too
+					payload, err := slackHandler.HandleSlashCommand(command)
 					if err != nil {
-						log.Fatal(err)
+						dependencies.Logger.Error().Err(err).Str("command", command.Command).Msg("Cannot handle slash command")
 					}
 					// Dont forget to acknowledge the request
 					socketClient.Ack(*event.Request, payload)
@@ -216,12 +223,18 @@ func socket(ctx context.Context,
 						dependencies.Logger.Printf("Could not type cast the event to an InteractionCallback: %v\n", event)
 						continue
 					}
-					// handleSlashCommand will take care of the command
-					payload, err := slackHandler.HandleBlockAction(interactionCallback)
+					// Acknowledge the interaction before running the work
+					// following it, Slack shows an error after 3 seconds.
+					payload, after, err := slackHandler.HandleInteraction(interactionCallback)
 					if err != nil {
-						dependencies.Logger.Error().Err(err).Msg("Cannot handle block actions")
+						dependencies.Logger.Error().Err(err).Str("type", string(interactionCallback.Type)).Msg("Cannot handle interaction")
 					}
 					socketClient.Ack(*event.Request, payload)
+					if after != nil {
+						if err := after(); err != nil {
+							dependencies.Logger.Error().Err(err).Msg("Cannot submit form")
+						}
+					}
 				}
 			}
 		}
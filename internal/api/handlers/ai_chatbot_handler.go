@@ -12,10 +12,11 @@ import (
 
 type AIChatbotHandler struct {
 	aiChatbotService *services.AIChatbotService
+	messageService   services.MessageServiceInterface
 }
 
-func NewAIChatbotHandler(aiChatbotService *services.AIChatbotService) *AIChatbotHandler {
-	return &AIChatbotHandler{aiChatbotService: aiChatbotService}
+func NewAIChatbotHandler(aiChatbotService *services.AIChatbotService, messageService services.MessageServiceInterface) *AIChatbotHandler {
+	return &AIChatbotHandler{aiChatbotService: aiChatbotService, messageService: messageService}
 }
 
 func (h *AIChatbotHandler) AddMessage(c *gin.Context) {
@@ -25,15 +26,44 @@ func (h *AIChatbotHandler) AddMessage(c *gin.Context) {
 		return
 	}
 
-	messageID, action, err := h.aiChatbotService.AddAndRunMessage(c.Request.Context(),
-		req.ChannelID,
-		req.Message,
-		req.UserID,
-	)
+	messageID, toolCalls, err := h.aiChatbotService.AddAndRunMessage(c.Request.Context(), req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"messageID": messageID, "action": action})
+	c.JSON(http.StatusOK, gin.H{"messageID": messageID, "toolCalls": toolCalls})
+}
+
+func (h *AIChatbotHandler) ListThreadMessages(ctx *gin.Context) {
+	var uri dto.ThreadMessagesUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req dto.ListThreadMessagesQuery
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	messages, total, err := h.messageService.ListMessagesByThreadID(uri.ThreadID, req.PerPage, req.Page)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	messagesResponse := []dto.MessageResponse{}
+	for _, v := range messages {
+		messagesResponse = append(messagesResponse, *dto.ToMessageResponse(&v))
+	}
+
+	ctx.JSON(http.StatusOK, dto.ListMessageResponse{
+		Items: messagesResponse,
+		Metadata: dto.MetadataDto{
+			Total:   total,
+			Page:    req.Page,
+			PerPage: req.PerPage,
+		},
+	})
 }
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/services"
+)
+
+// SlackDispatcher answers the Slack events, interactions and slash commands,
+// it is the same for the Socket Mode and the HTTP transports.
+type SlackDispatcher interface {
+	HandleEventMessage(event slackevents.EventsAPIEvent) error
+	HandleInteraction(payload slack.InteractionCallback) (interface{}, func() error, error)
+	HandleSlashCommand(command slack.SlashCommand) (interface{}, error)
+}
+
+// SlackEventsHandler receives the Slack requests of the HTTP transport, they
+// are verified by VerifySlackRequest before. Slack waits 3 seconds for the
+// response: the events and the work following the interactions are handled
+// after responding.
+type SlackEventsHandler struct {
+	dispatcher        SlackDispatcher
+	slackEventService *services.SlackEventService
+	logger            *zerolog.Logger
+}
+
+func NewSlackEventsHandler(dispatcher SlackDispatcher, slackEventService *services.SlackEventService, logger *zerolog.Logger) *SlackEventsHandler {
+	return &SlackEventsHandler{dispatcher: dispatcher, slackEventService: slackEventService, logger: logger}
+}
+
+// HandleEvents answers the Events API requests, the URL verification
+// included. Slack retries the events it got no timely response for, the
+// events already delivered to any instance are acknowledged without being
+// handled again. An event is handled when its delivery cannot be checked,
+// rather than lost.
+func (h *SlackEventsHandler) HandleEvents(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	event, err := slackevents.ParseEvent(json.RawMessage(body), slackevents.OptionNoVerifyToken())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if event.Type == slackevents.URLVerification {
+		var challenge slackevents.ChallengeResponse
+		if err := json.Unmarshal(body, &challenge); err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		c.String(http.StatusOK, challenge.Challenge)
+		return
+	}
+
+	c.Status(http.StatusOK)
+	if callback, ok := event.Data.(*slackevents.EventsAPICallbackEvent); ok {
+		first, err := h.slackEventService.FirstDelivery(callback.EventID)
+		if err != nil {
+			h.logger.Error().Err(err).Str("event_id", callback.EventID).Msg("Cannot check event delivery")
+		} else if !first {
+			h.logger.Info().Str("event_id", callback.EventID).Str("retry_num", c.GetHeader("X-Slack-Retry-Num")).Str("retry_reason", c.GetHeader("X-Slack-Retry-Reason")).Msg("Ignoring event delivered again")
+			return
+		}
+	}
+	go func() {
+		if err := h.dispatcher.HandleEventMessage(event); err != nil {
+			h.logger.Error().Err(err).Msg("Cannot handle event message")
+		}
+	}()
+}
+
+// HandleInteractivity answers the interactions, posted form-encoded with the
+// interaction as JSON in the payload field.
+func (h *SlackEventsHandler) HandleInteractivity(c *gin.Context) {
+	var payload slack.InteractionCallback
+	if err := json.Unmarshal([]byte(c.PostForm("payload")), &payload); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	response, after, err := h.dispatcher.HandleInteraction(payload)
+	if err != nil {
+		h.logger.Error().Err(err).Str("type", string(payload.Type)).Msg("Cannot handle interaction")
+	}
+	respond(c, response)
+	if after != nil {
+		go func() {
+			if err := after(); err != nil {
+				h.logger.Error().Err(err).Msg("Cannot submit form")
+			}
+		}()
+	}
+}
+
+// HandleCommands answers the slash commands, errors are answered with an
+// ephemeral message too.
+func (h *SlackEventsHandler) HandleCommands(c *gin.Context) {
+	command, err := slack.SlashCommandParse(c.Request)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	response, err := h.dispatcher.HandleSlashCommand(command)
+	if err != nil {
+		h.logger.Error().Err(err).Str("command", command.Command).Msg("Cannot handle slash command")
+	}
+	respond(c, response)
+}
+
+// respond acknowledges the request with the response, or with an empty body
+// when there is none.
+func respond(c *gin.Context, response interface{}) {
+	if response == nil {
+		c.Status(http.StatusOK)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/config"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/mocks"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/models"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/services"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSigningSecret = "signing_secret"
+
+type fakeSlackDispatcher struct {
+	events       chan slackevents.EventsAPIEvent
+	interactions []slack.InteractionCallback
+	commands     []slack.SlashCommand
+	submitted    chan struct{}
+}
+
+func newFakeSlackDispatcher() *fakeSlackDispatcher {
+	return &fakeSlackDispatcher{events: make(chan slackevents.EventsAPIEvent, 1), submitted: make(chan struct{}, 1)}
+}
+
+func (f *fakeSlackDispatcher) HandleEventMessage(event slackevents.EventsAPIEvent) error {
+	f.events <- event
+	return nil
+}
+
+func (f *fakeSlackDispatcher) HandleInteraction(payload slack.InteractionCallback) (interface{}, func() error, error) {
+	f.interactions = append(f.interactions, payload)
+	if payload.Type != slack.InteractionTypeViewSubmission {
+		return nil, nil, nil
+	}
+	if payload.View.CallbackID == "invalid" {
+		return slack.NewErrorsViewSubmissionResponse(map[string]string{"sheet_url": "Invalid sheet link"}), nil, nil
+	}
+	return nil, func() error {
+		f.submitted <- struct{}{}
+		return nil
+	}, nil
+}
+
+func (f *fakeSlackDispatcher) HandleSlashCommand(command slack.SlashCommand) (interface{}, error) {
+	f.commands = append(f.commands, command)
+	return &slack.Msg{ResponseType: slack.ResponseTypeEphemeral, Text: "Commands"}, nil
+}
+
+func newSlackEventsRouter(dispatcher SlackDispatcher, slackEventRepo *mocks.FakeSlackEventRepository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	slackHandler := NewSlackHandler(services.NewSlackService(&config.SlackConfig{SigningSecret: testSigningSecret}, nil), nil)
+	logger := zerolog.Nop()
+	slackEventsHandler := NewSlackEventsHandler(dispatcher, services.NewSlackEventService(slackEventRepo), &logger)
+	slackRoutes := router.Group("/slack")
+	slackRoutes.Use(slackHandler.VerifySlackRequest())
+	slackRoutes.POST("/actions", slackHandler.HandleBlockActions)
+	slackRoutes.POST("/events", slackEventsHandler.HandleEvents)
+	slackRoutes.POST("/interactivity", slackEventsHandler.HandleInteractivity)
+	slackRoutes.POST("/commands", slackEventsHandler.HandleCommands)
+	return router
+}
+
+func signedSlackRequest(path string, contentType string, body string, secret string) *http.Request {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fmt.Sprintf("v0:%s:%s", timestamp, body)))
+	request := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	request.Header.Set("Content-Type", contentType)
+	request.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	request.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return request
+}
+
+func TestSlackEventsHandlerIgnoresRetries(t *testing.T) {
+	dispatcher := newFakeSlackDispatcher()
+	slackEventRepo := mocks.NewFakeSlackEventRepository()
+	slackEventRepo.Events["Ev0"] = models.SlackEvent{EventID: "Ev0", CreatedAt: time.Now().Add(-time.Hour)}
+	// Two instances behind a load balancer, sharing the database.
+	routers := []*gin.Engine{newSlackEventsRouter(dispatcher, slackEventRepo), newSlackEventsRouter(dispatcher, slackEventRepo)}
+	event := `{"type":"event_callback","event_id":"Ev1","event":{"type":"app_home_opened","user":"U1","tab":"home"}}`
+
+	for retryNum := 0; retryNum < 3; retryNum++ {
+		request := signedSlackRequest("/slack/events", "application/json", event, testSigningSecret)
+		if retryNum > 0 {
+			request.Header.Set("X-Slack-Retry-Num", strconv.Itoa(retryNum))
+			request.Header.Set("X-Slack-Retry-Reason", "http_timeout")
+		}
+		recorder := httptest.NewRecorder()
+		routers[retryNum%2].ServeHTTP(recorder, request)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	}
+	request := signedSlackRequest("/slack/events", "application/json", strings.Replace(event, "Ev1", "Ev2", 1), testSigningSecret)
+	routers[1].ServeHTTP(httptest.NewRecorder(), request)
+
+	eventIDs := []string{}
+	for len(eventIDs) < 2 {
+		select {
+		case event := <-dispatcher.events:
+			eventIDs = append(eventIDs, event.Data.(*slackevents.EventsAPICallbackEvent).EventID)
+		case <-time.After(time.Second):
+			t.Fatal("event not dispatched")
+		}
+	}
+	assert.ElementsMatch(t, []string{"Ev1", "Ev2"}, eventIDs)
+	select {
+	case <-dispatcher.events:
+		t.Fatal("retried event dispatched again")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.NotContains(t, slackEventRepo.Events, "Ev0")
+}
+
+func TestSlackEventsHandler(t *testing.T) {
+	form := func(values url.Values) string { return values.Encode() }
+	interaction := func(payload string) string { return form(url.Values{"payload": {payload}}) }
+
+	tests := []struct {
+		name             string
+		path             string
+		contentType      string
+		body             string
+		secret           string
+		wantStatus       int
+		wantBody         string
+		wantEvent        string
+		wantInteractions int
+		wantSubmit       bool
+		wantCommand      string
+	}{
+		{
+			name:        "URL verification is answered with the challenge",
+			path:        "/slack/events",
+			contentType: "application/json",
+			body:        `{"type":"url_verification","token":"token","challenge":"challenge_1"}`,
+			wantStatus:  http.StatusOK,
+			wantBody:    "challenge_1",
+		},
+		{
+			name:        "events are dispatched after responding",
+			path:        "/slack/events",
+			contentType: "application/json",
+			body:        `{"type":"event_callback","event":{"type":"app_home_opened","user":"U1","tab":"home"}}`,
+			wantStatus:  http.StatusOK,
+			wantEvent:   "app_home_opened",
+		},
+		{
+			name:             "form-encoded interaction is dispatched",
+			path:             "/slack/interactivity",
+			contentType:      "application/x-www-form-urlencoded",
+			body:             interaction(`{"type":"block_actions","user":{"id":"U1"},"actions":[{"action_id":"open_form","value":"{}"}]}`),
+			wantStatus:       http.StatusOK,
+			wantInteractions: 1,
+		},
+		{
+			name:             "invalid form is answered with its errors",
+			path:             "/slack/interactivity",
+			contentType:      "application/x-www-form-urlencoded",
+			body:             interaction(`{"type":"view_submission","user":{"id":"U1"},"view":{"callback_id":"invalid"}}`),
+			wantStatus:       http.StatusOK,
+			wantBody:         `{"response_action":"errors","errors":{"sheet_url":"Invalid sheet link"}}`,
+			wantInteractions: 1,
+		},
+		{
+			name:             "valid form is submitted after responding",
+			path:             "/slack/interactivity",
+			contentType:      "application/x-www-form-urlencoded",
+			body:             interaction(`{"type":"view_submission","user":{"id":"U1"},"view":{"callback_id":"create_buddy"}}`),
+			wantStatus:       http.StatusOK,
+			wantInteractions: 1,
+			wantSubmit:       true,
+		},
+		{
+			name:        "form-encoded block action is answered",
+			path:        "/slack/actions",
+			contentType: "application/x-www-form-urlencoded",
+			body:        interaction(`{"type":"block_actions","user":{"id":"U1"},"block_id":"candidate_file","actions":[{"action_id":"candidate_file","value":"https://docs.google.com/spreadsheets/d/sheet/edit"}]}`),
+			wantStatus:  http.StatusOK,
+		},
+		{
+			name:        "JSON block action is rejected",
+			path:        "/slack/actions",
+			contentType: "application/json",
+			body:        `{"type":"block_actions","user":{"id":"U1"},"block_id":"candidate_file"}`,
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:        "invalid interaction",
+			path:        "/slack/interactivity",
+			contentType: "application/x-www-form-urlencoded",
+			body:        form(url.Values{"payload": {"block_actions"}}),
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:        "slash command is answered with its response",
+			path:        "/slack/commands",
+			contentType: "application/x-www-form-urlencoded",
+			body:        form(url.Values{"command": {"/hyper"}, "text": {"help"}, "user_id": {"U1"}, "channel_id": {"C1"}}),
+			wantStatus:  http.StatusOK,
+			wantBody:    `{"text":"Commands","response_type":"ephemeral","replace_original":false,"delete_original":false,"metadata":{"event_type":"","event_payload":null},"blocks":null}`,
+			wantCommand: "/hyper",
+		},
+		{
+			name:        "requests with an invalid signature are rejected",
+			path:        "/slack/commands",
+			contentType: "application/x-www-form-urlencoded",
+			body:        form(url.Values{"command": {"/hyper"}}),
+			secret:      "other_secret",
+			wantStatus:  http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dispatcher := newFakeSlackDispatcher()
+			router := newSlackEventsRouter(dispatcher, mocks.NewFakeSlackEventRepository())
+			secret := tt.secret
+			if secret == "" {
+				secret = testSigningSecret
+			}
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, signedSlackRequest(tt.path, tt.contentType, tt.body, secret))
+
+			assert.Equal(t, tt.wantStatus, recorder.Code)
+			if strings.HasPrefix(tt.wantBody, "{") {
+				assert.JSONEq(t, tt.wantBody, recorder.Body.String())
+			} else if tt.wantStatus == http.StatusOK {
+				assert.Equal(t, tt.wantBody, recorder.Body.String())
+			}
+			if tt.wantEvent != "" {
+				select {
+				case event := <-dispatcher.events:
+					assert.Equal(t, tt.wantEvent, event.InnerEvent.Type)
+				case <-time.After(time.Second):
+					t.Fatal("event not dispatched")
+				}
+			}
+			assert.Len(t, dispatcher.interactions, tt.wantInteractions)
+			if tt.wantSubmit {
+				select {
+				case <-dispatcher.submitted:
+				case <-time.After(time.Second):
+					t.Fatal("form not submitted")
+				}
+			}
+			if tt.wantCommand != "" {
+				assert.Len(t, dispatcher.commands, 1)
+				assert.Equal(t, tt.wantCommand, dispatcher.commands[0].Command)
+			} else {
+				assert.Empty(t, dispatcher.commands)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -81,7 +82,7 @@ func (h *SlackHandler) SendMessage(ctx *gin.Context) {
 		return
 	}
 
-	err := h.slackService.SendMessage(ctx, input.ChannelID, input.Message)
+	err := h.slackService.SendMessage(ctx, input.ChannelID, input.ThreadTs, input.Message)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
@@ -90,12 +91,18 @@ func (h *SlackHandler) SendMessage(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"message": "Message sent to Slack"})
 }
 
+// HandleBlockActions answers the block actions, posted form-encoded with the
+// interaction as JSON in the payload field.
 func (s *SlackHandler) HandleBlockActions(c *gin.Context) {
 	var payload slack.InteractionCallback
-	if err := c.BindJSON(&payload); err != nil {
+	if err := json.Unmarshal([]byte(c.PostForm("payload")), &payload); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse action response"})
 		return
 	}
+	if len(payload.ActionCallback.BlockActions) == 0 {
+		c.Status(http.StatusOK)
+		return
+	}
 
 	// Check if this is a rating submission
 	if payload.BlockID == "rating_input" {
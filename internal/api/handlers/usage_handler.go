@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/services"
+)
+
+type UsageHandler struct {
+	usageService services.UsageServiceInterface
+}
+
+func NewUsageHandler(usageService services.UsageServiceInterface) *UsageHandler {
+	return &UsageHandler{usageService: usageService}
+}
+
+func (h *UsageHandler) ListUsageSummary(ctx *gin.Context) {
+	var req dto.UsageSummaryQuery
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	summary, err := h.usageService.ListUsageSummary(req)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, summary)
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/sotatek-dev/hyper-automation-chatbot/internal/api/handlers"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/config"
 	"github.com/sotatek-dev/hyper-automation-chatbot/internal/google_internal"
 	"github.com/sotatek-dev/hyper-automation-chatbot/internal/repository"
 	"github.com/sotatek-dev/hyper-automation-chatbot/internal/services"
@@ -36,14 +37,8 @@ func SetupRoutes(
 	)
 	slackHandler := handlers.NewSlackHandler(slackService, ggSheetService)
 
-	threadRepo := repository.NewThreadRepository(dependencies.DB)
-	threadService := services.NewThreadService(threadRepo)
-
-	messageRepo := repository.NewMessageRepository(dependencies.DB)
-	messageService := services.NewMessageService(messageRepo)
-
-	aiChatbotService := services.NewAIChatbotService(dependencies.Config.AzureOpenAI, slackService, threadService, messageService)
-	aiChatbotHandler := handlers.NewAIChatbotHandler(aiChatbotService)
+	aiChatbotHandler := handlers.NewAIChatbotHandler(dependencies.AiChatbotService, dependencies.MessageService)
+	usageHandler := handlers.NewUsageHandler(dependencies.UsageService)
 
 	userGroup := routes.Group("users")
 	{
@@ -63,18 +58,29 @@ func SetupRoutes(
 		adminRoutes.GET("", userHandler.ListUsers)
 	}
 
-	//TODO: remove after testing AI Chatbot, Slack done
 	slackRoutes := routes.Group("/slack")
 	slackRoutes.Use(slackHandler.VerifySlackRequest())
 	{
+		//TODO: remove after testing AI Chatbot, Slack done
 		slackRoutes.POST("/send-message", slackHandler.SendMessage)
 		slackRoutes.POST("/actions", slackHandler.HandleBlockActions)
 	}
+	if dependencies.Config.SlackConfig.Mode == config.SlackModeHTTP {
+		slackEventsHandler := handlers.NewSlackEventsHandler(dependencies.SlackHandler, services.NewSlackEventService(repository.NewSlackEventRepository(dependencies.DB)), dependencies.Logger)
+		slackRoutes.POST("/events", slackEventsHandler.HandleEvents)
+		slackRoutes.POST("/interactivity", slackEventsHandler.HandleInteractivity)
+		slackRoutes.POST("/commands", slackEventsHandler.HandleCommands)
+	}
 
 	aiAssistantRoutes := routes.Group("/ai-assistant")
 	{
 		aiAssistantRoutes.POST("/add-message", aiChatbotHandler.AddMessage)
 	}
+	aiAssistantAdminRoutes := aiAssistantRoutes.Group("/").Use(middleware.AuthMiddleware(tokenMaker, []string{"admin"}))
+	{
+		aiAssistantAdminRoutes.GET("/threads/:threadID/messages", aiChatbotHandler.ListThreadMessages)
+		aiAssistantAdminRoutes.GET("/usage", usageHandler.ListUsageSummary)
+	}
 
 	sheetHandler := handlers.NewSheetHandler(ggSheetService)
 	sheetRoutes := routes.Group("/sheets")
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	AssistantIntentDetection = "intent_detection"
+	AssistantHeaderMapping   = "header_mapping"
+)
+
+// AssistantDefinition is one named assistant the chatbot can run. Tools lists
+// the chatbot functions the assistant may call, "*" for all of them; the tools
+// reading attached files are always available. Instructions, when set,
+// replace the instructions stored on the assistant for the runs of the
+// chatbot, they may be kept in the InstructionsFile instead, relative to the
+// assistants file. Knowledge assistants are given the policy passages
+// matching the user message, to answer with citations.
+//
+// Version names the definition, it is bumped with every change and recorded
+// on the threads the assistant answers. Model and FileSearchStores, the vector
+// stores searched by file_search, are only used by chatbotctl assistants sync
+// to create and update the assistant on Azure.
+type AssistantDefinition struct {
+	Name             string   `yaml:"name"`
+	AssistantID      string   `yaml:"assistant_id"`
+	Version          string   `yaml:"version"`
+	Model            string   `yaml:"model"`
+	Instructions     string   `yaml:"instructions"`
+	InstructionsFile string   `yaml:"instructions_file"`
+	Tools            []string `yaml:"tools"`
+	FileSearchStores []string `yaml:"file_search_stores"`
+	Knowledge        bool     `yaml:"knowledge"`
+}
+
+// IntentRoute sends the conversation to Assistant when the running assistant
+// recognizes Intent. Description tells the assistants when to pick it.
+type IntentRoute struct {
+	Intent      string `yaml:"intent"`
+	Assistant   string `yaml:"assistant"`
+	Description string `yaml:"description"`
+}
+
+// AssistantsConfig declares the assistants of the chatbot, read from the YAML
+// file named by ASSISTANTS_FILE. New conversations start with the Default
+// assistant and move to another one through the Intents. Values may reference
+// environment variables as ${NAME}.
+type AssistantsConfig struct {
+	Default    string                `yaml:"default"`
+	Assistants []AssistantDefinition `yaml:"assistants"`
+	Intents    []IntentRoute         `yaml:"intents"`
+}
+
+// LoadAssistantsConfig reads the assistants file. When it does not exist the
+// intent detection and header mapping assistants are built from the
+// AZURE_OPENAI_ASSISTANT_ID_* variables.
+func LoadAssistantsConfig(path string, azureOpenAI AzureOpenAIConfig) (AssistantsConfig, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return AssistantsConfig{
+			Default: AssistantIntentDetection,
+			Assistants: []AssistantDefinition{
+				{
+					Name:        AssistantIntentDetection,
+					AssistantID: azureOpenAI.AssistantIdDetectAction,
+					Tools:       []string{"*"},
+					Knowledge:   true,
+				},
+				{
+					Name:        AssistantHeaderMapping,
+					AssistantID: azureOpenAI.AssistantIdHeaderMapping,
+				},
+			},
+		}, nil
+	}
+	if err != nil {
+		return AssistantsConfig{}, err
+	}
+
+	var assistants AssistantsConfig
+	err = yaml.Unmarshal([]byte(os.ExpandEnv(string(content))), &assistants)
+	if err != nil {
+		return AssistantsConfig{}, fmt.Errorf("invalid assistants file %s: %w", path, err)
+	}
+	err = assistants.Validate()
+	if err != nil {
+		return AssistantsConfig{}, fmt.Errorf("invalid assistants file %s: %w", path, err)
+	}
+	for i, assistant := range assistants.Assistants {
+		if assistant.InstructionsFile == "" {
+			continue
+		}
+		instructionsPath := assistant.InstructionsFile
+		if !filepath.IsAbs(instructionsPath) {
+			instructionsPath = filepath.Join(filepath.Dir(path), instructionsPath)
+		}
+		instructions, err := os.ReadFile(instructionsPath)
+		if err != nil {
+			return AssistantsConfig{}, fmt.Errorf("cannot read the instructions of assistant %s: %w", assistant.Name, err)
+		}
+		assistants.Assistants[i].Instructions = strings.TrimSpace(string(instructions))
+	}
+	return assistants, nil
+}
+
+// Validate checks the assistant names are unique and every reference to an
+// assistant points to a declared one.
+func (c AssistantsConfig) Validate() error {
+	names := map[string]bool{}
+	for _, assistant := range c.Assistants {
+		if assistant.Name == "" {
+			return fmt.Errorf("assistant without a name")
+		}
+		if names[assistant.Name] {
+			return fmt.Errorf("duplicate assistant %s", assistant.Name)
+		}
+		if assistant.Instructions != "" && assistant.InstructionsFile != "" {
+			return fmt.Errorf("assistant %s has both instructions and instructions_file", assistant.Name)
+		}
+		names[assistant.Name] = true
+	}
+	if !names[c.Default] {
+		return fmt.Errorf("unknown default assistant %q", c.Default)
+	}
+	intents := map[string]bool{}
+	for _, route := range c.Intents {
+		if route.Intent == "" {
+			return fmt.Errorf("intent without a name")
+		}
+		if intents[route.Intent] {
+			return fmt.Errorf("duplicate intent %s", route.Intent)
+		}
+		intents[route.Intent] = true
+		if !names[route.Assistant] {
+			return fmt.Errorf("intent %s routes to unknown assistant %q", route.Intent, route.Assistant)
+		}
+	}
+	return nil
+}
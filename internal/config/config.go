@@ -1,6 +1,9 @@
 package config
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
@@ -26,20 +29,165 @@ type AuthConfig struct {
 	RefreshTokenDuration time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
 }
 
+// SlackConfig configures the Slack app. SLACK_MODE picks how Slack reaches
+// the bot: over a Socket Mode connection, the default, or with HTTP requests
+// to /slack/events, /slack/interactivity and /slack/commands signed with the
+// signing secret, e.g. behind a load balancer.
+//
+// SLACK_USER_GROUPS maps the groups slash commands are restricted to onto
+// Slack user groups, as name=group entries separated by commas where group is
+// the handle or the ID of the user group, e.g. "hr=hr-team,admin=S0123ABCD".
+// A group missing from it is the user group of the same handle.
 type SlackConfig struct {
-	WebhookURL    string `mapstructure:"SLACK_WEBHOOK_URL"`
-	Channel       string `mapstructure:"SLACK_CHANNEL_ID"`
-	Token         string `mapstructure:"SLACK_TOKEN"`
-	BotToken      string `mapstructure:"SLACK_BOT_TOKEN"`
-	SigningSecret string `mapstructure:"SLACK_SIGNING_SECRET"`
+	WebhookURL    string            `mapstructure:"SLACK_WEBHOOK_URL"`
+	Channel       string            `mapstructure:"SLACK_CHANNEL_ID"`
+	Token         string            `mapstructure:"SLACK_TOKEN"`
+	BotToken      string            `mapstructure:"SLACK_BOT_TOKEN"`
+	SigningSecret string            `mapstructure:"SLACK_SIGNING_SECRET"`
+	Mode          string            `mapstructure:"SLACK_MODE"`
+	Groups        string            `mapstructure:"SLACK_USER_GROUPS"`
+	UserGroups    map[string]string `mapstructure:"-"`
 }
 
+const (
+	SlackModeSocket = "socket"
+	SlackModeHTTP   = "http"
+)
+
+// ParseUserGroups parses the SLACK_USER_GROUPS format described on
+// SlackConfig.
+func ParseUserGroups(groups string) (map[string]string, error) {
+	userGroups := map[string]string{}
+	for _, entry := range strings.Split(groups, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, group, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(name) == "" || strings.TrimSpace(group) == "" {
+			return nil, fmt.Errorf("invalid SLACK_USER_GROUPS entry: %s", entry)
+		}
+		userGroups[strings.TrimSpace(name)] = strings.TrimPrefix(strings.TrimSpace(group), "@")
+	}
+	return userGroups, nil
+}
+
+// AzureOpenAIConfig configures the Azure OpenAI Assistants API. Requests
+// answered 429, idempotent requests answered 5xx and requests that could not
+// connect are sent again up to MaxRetries times, -1 disables the retries.
+// RequestTimeout bounds the wait for the response headers.
 type AzureOpenAIConfig struct {
-	Endpoint                 string `mapstructure:"AZURE_OPENAI_ENDPOINT"`
-	Key                      string `mapstructure:"AZURE_OPENAI_KEY"`
-	ApiVersion               string `mapstructure:"AZURE_OPENAI_API_VERSION"`
-	AssistantIdDetectAction  string `mapstructure:"AZURE_OPENAI_ASSISTANT_ID_DETECT_ACTION"`
-	AssistantIdHeaderMapping string `mapstructure:"AZURE_OPENAI_ASSISTANT_ID_HEADER_MAPPING"`
+	Endpoint                 string        `mapstructure:"AZURE_OPENAI_ENDPOINT"`
+	Key                      string        `mapstructure:"AZURE_OPENAI_KEY"`
+	ApiVersion               string        `mapstructure:"AZURE_OPENAI_API_VERSION"`
+	AssistantIdDetectAction  string        `mapstructure:"AZURE_OPENAI_ASSISTANT_ID_DETECT_ACTION"`
+	AssistantIdHeaderMapping string        `mapstructure:"AZURE_OPENAI_ASSISTANT_ID_HEADER_MAPPING"`
+	Streaming                bool          `mapstructure:"AZURE_OPENAI_STREAMING"`
+	MaxRetries               int           `mapstructure:"AZURE_OPENAI_MAX_RETRIES"`
+	RequestTimeout           time.Duration `mapstructure:"AZURE_OPENAI_REQUEST_TIMEOUT"`
+}
+
+const (
+	LLMBackendAzureAssistants = "azure_assistants"
+	LLMBackendOpenAIChat      = "openai_chat"
+)
+
+// LLMConfig selects the backend used by the AI chatbot. The Azure Assistants
+// backend reads its settings from AzureOpenAIConfig, the OpenAI compatible
+// chat completions backend from the LLM_CHAT_* variables. ChatRequestTimeout
+// bounds a whole chat completion request.
+type LLMConfig struct {
+	Backend            string        `mapstructure:"LLM_BACKEND"`
+	ChatEndpoint       string        `mapstructure:"LLM_CHAT_ENDPOINT"`
+	ChatApiKey         string        `mapstructure:"LLM_CHAT_API_KEY"`
+	ChatModel          string        `mapstructure:"LLM_CHAT_MODEL"`
+	ChatInstructions   string        `mapstructure:"LLM_CHAT_INSTRUCTIONS"`
+	ChatRequestTimeout time.Duration `mapstructure:"LLM_CHAT_REQUEST_TIMEOUT"`
+}
+
+// ThreadConfig controls when idle conversations are closed. The close
+// confirmation is posted CloseConfirmWindow before the thread expires. Once
+// the backend thread of a conversation reaches RolloverTokens, the
+// conversation moves to a new backend thread seeded with its summary; -1
+// disables rollovers.
+type ThreadConfig struct {
+	IdleTimeout         time.Duration `mapstructure:"THREAD_IDLE_TIMEOUT"`
+	CloseConfirmWindow  time.Duration `mapstructure:"THREAD_CLOSE_CONFIRM_WINDOW"`
+	ExpiryCheckInterval time.Duration `mapstructure:"THREAD_EXPIRY_CHECK_INTERVAL"`
+	RolloverTokens      int64         `mapstructure:"THREAD_ROLLOVER_TOKENS"`
+}
+
+// ModelPrice is the price of a model in USD per million tokens.
+type ModelPrice struct {
+	Prompt     float64
+	Completion float64
+}
+
+// UsageConfig prices the assistant runs and caps the tokens a Slack user may
+// spend per day. LLM_PRICING lists model=prompt:completion prices in USD per
+// million tokens separated by commas, e.g. "gpt-4o=2.5:10,gpt-4o-mini=0.15:0.6".
+// A zero DailyTokenQuota means no quota.
+type UsageConfig struct {
+	Pricing         string                `mapstructure:"LLM_PRICING"`
+	DailyTokenQuota int64                 `mapstructure:"USAGE_DAILY_TOKEN_QUOTA"`
+	Prices          map[string]ModelPrice `mapstructure:"-"`
+}
+
+// ParseModelPricing parses the LLM_PRICING format described on UsageConfig.
+func ParseModelPricing(pricing string) (map[string]ModelPrice, error) {
+	prices := map[string]ModelPrice{}
+	for _, entry := range strings.Split(pricing, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		model, price, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid LLM_PRICING entry: %s", entry)
+		}
+		promptPrice, completionPrice, ok := strings.Cut(price, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid LLM_PRICING entry: %s", entry)
+		}
+		prompt, err := strconv.ParseFloat(strings.TrimSpace(promptPrice), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid LLM_PRICING entry %s: %w", entry, err)
+		}
+		completion, err := strconv.ParseFloat(strings.TrimSpace(completionPrice), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid LLM_PRICING entry %s: %w", entry, err)
+		}
+		prices[strings.TrimSpace(model)] = ModelPrice{Prompt: prompt, Completion: completion}
+	}
+	return prices, nil
+}
+
+// RAGConfig controls the policy passages given to the knowledge assistants.
+// TopK passages are injected in each run. When EmbeddingDeployment names an
+// Azure OpenAI embeddings deployment, passages are also matched by meaning
+// and not only by keywords.
+type RAGConfig struct {
+	TopK                int    `mapstructure:"RAG_TOP_K"`
+	EmbeddingDeployment string `mapstructure:"RAG_EMBEDDING_DEPLOYMENT"`
+}
+
+// RedactionConfig selects the personal data replaced by placeholders before
+// Slack messages are sent to the LLM. PII_REDACTION lists the detectors among
+// email, phone, citizen_id and bank_account separated by commas, all of them
+// when empty, "off" to send messages as they are. PII_REDACTION_PATTERNS adds
+// detectors as name=regex entries separated by spaces, e.g.
+// "employee_id=EMP-\d{6}".
+type RedactionConfig struct {
+	Detectors string `mapstructure:"PII_REDACTION"`
+	Patterns  string `mapstructure:"PII_REDACTION_PATTERNS"`
+}
+
+// AnswerCacheConfig controls the cache of the answers to the questions
+// starting a conversation, e.g. FAQ lookups. An answer is replayed without
+// running the assistant for TTL when the same question is asked to the same
+// assistant version; a negative TTL, e.g. -1s, disables the cache.
+type AnswerCacheConfig struct {
+	TTL time.Duration `mapstructure:"ANSWER_CACHE_TTL"`
 }
 
 type GoogleConfig struct {
@@ -71,6 +219,13 @@ type Config struct {
 	Auth           AuthConfig
 	SlackConfig    SlackConfig
 	AzureOpenAI    AzureOpenAIConfig
+	LLM            LLMConfig
+	Thread         ThreadConfig
+	Usage          UsageConfig
+	Assistants     AssistantsConfig
+	RAG            RAGConfig
+	Redaction      RedactionConfig
+	AnswerCache    AnswerCacheConfig
 	Google         GoogleConfig
 	UIPath         UIPathConfig
 	RabbitMQConfig RabbitMQConfig
@@ -93,6 +248,12 @@ func LoadConfig(path string) (Config, error) {
 	var auth AuthConfig
 	var slackConfig SlackConfig
 	var azureOpenAI AzureOpenAIConfig
+	var llm LLMConfig
+	var thread ThreadConfig
+	var usage UsageConfig
+	var rag RAGConfig
+	var redaction RedactionConfig
+	var answerCache AnswerCacheConfig
 	var google GoogleConfig
 	var uiPath UIPathConfig
 	var rabbitMQ RabbitMQConfig
@@ -112,10 +273,92 @@ func LoadConfig(path string) (Config, error) {
 	if err != nil {
 		return Config{}, err
 	}
+	slackConfig.UserGroups, err = ParseUserGroups(slackConfig.Groups)
+	if err != nil {
+		return Config{}, err
+	}
+	if slackConfig.Mode == "" {
+		slackConfig.Mode = SlackModeSocket
+	}
+	if slackConfig.Mode != SlackModeSocket && slackConfig.Mode != SlackModeHTTP {
+		return Config{}, fmt.Errorf("unsupported SLACK_MODE: %s", slackConfig.Mode)
+	}
+	if slackConfig.Mode == SlackModeHTTP && slackConfig.SigningSecret == "" {
+		return Config{}, fmt.Errorf("SLACK_SIGNING_SECRET is required when SLACK_MODE is %s", SlackModeHTTP)
+	}
 	err = viper.Unmarshal(&azureOpenAI)
 	if err != nil {
 		return Config{}, err
 	}
+	if azureOpenAI.MaxRetries == 0 {
+		azureOpenAI.MaxRetries = 3
+	}
+	if azureOpenAI.RequestTimeout == 0 {
+		azureOpenAI.RequestTimeout = time.Minute
+	}
+	err = viper.Unmarshal(&llm)
+	if err != nil {
+		return Config{}, err
+	}
+	if llm.Backend == "" {
+		llm.Backend = LLMBackendAzureAssistants
+	}
+	if llm.Backend != LLMBackendAzureAssistants && llm.Backend != LLMBackendOpenAIChat {
+		return Config{}, fmt.Errorf("unsupported LLM_BACKEND: %s", llm.Backend)
+	}
+	if llm.ChatRequestTimeout == 0 {
+		llm.ChatRequestTimeout = 2 * time.Minute
+	}
+	err = viper.Unmarshal(&thread)
+	if err != nil {
+		return Config{}, err
+	}
+	if thread.IdleTimeout == 0 {
+		thread.IdleTimeout = 5 * time.Minute
+	}
+	if thread.CloseConfirmWindow == 0 {
+		thread.CloseConfirmWindow = time.Minute
+	}
+	if thread.ExpiryCheckInterval == 0 {
+		thread.ExpiryCheckInterval = 15 * time.Second
+	}
+	if thread.RolloverTokens == 0 {
+		thread.RolloverTokens = 60000
+	}
+	err = viper.Unmarshal(&usage)
+	if err != nil {
+		return Config{}, err
+	}
+	usage.Prices, err = ParseModelPricing(usage.Pricing)
+	if err != nil {
+		return Config{}, err
+	}
+	assistantsFile := viper.GetString("ASSISTANTS_FILE")
+	if assistantsFile == "" {
+		assistantsFile = "assistants.yaml"
+	}
+	assistants, err := LoadAssistantsConfig(assistantsFile, azureOpenAI)
+	if err != nil {
+		return Config{}, err
+	}
+	err = viper.Unmarshal(&rag)
+	if err != nil {
+		return Config{}, err
+	}
+	if rag.TopK == 0 {
+		rag.TopK = 4
+	}
+	err = viper.Unmarshal(&redaction)
+	if err != nil {
+		return Config{}, err
+	}
+	err = viper.Unmarshal(&answerCache)
+	if err != nil {
+		return Config{}, err
+	}
+	if answerCache.TTL == 0 {
+		answerCache.TTL = 24 * time.Hour
+	}
 	err = viper.Unmarshal(&google)
 	if err != nil {
 		return Config{}, err
@@ -135,6 +378,13 @@ func LoadConfig(path string) (Config, error) {
 		Auth:           auth,
 		SlackConfig:    slackConfig,
 		AzureOpenAI:    azureOpenAI,
+		LLM:            llm,
+		Thread:         thread,
+		Usage:          usage,
+		Assistants:     assistants,
+		RAG:            rag,
+		Redaction:      redaction,
+		AnswerCache:    answerCache,
 		Google:         google,
 		UIPath:         uiPath,
 		RabbitMQConfig: rabbitMQ,
@@ -32,12 +32,27 @@ func Initialize(dbConfig *config.DBConfig) (*gorm.DB, error) {
 }
 
 func Migrate(db *gorm.DB) error {
-	return db.AutoMigrate(
+	err := db.AutoMigrate(
 		&models.User{},
 		&models.UserPoint{},
 		&models.Thread{},
+		&models.ThreadRollover{},
 		&models.Message{},
+		&models.RunUsage{},
+		&models.ColumnMapping{},
+		&models.ColumnMappingProposal{},
+		&models.DocumentChunk{},
+		&models.PIIPlaceholder{},
 		&models.UIPathJob{},
+		&models.CachedAnswer{},
+		&models.ChatCompletionThread{},
+		&models.ChatCompletionMessage{},
+		&models.ChatCompletionRun{},
+		&models.SlackEvent{},
 		// Add other models here as needed
 	)
+	if err != nil {
+		return err
+	}
+	return db.Exec("CREATE INDEX IF NOT EXISTS idx_document_chunks_search ON document_chunks USING GIN (" + models.DocumentChunkSearchVector + ")").Error
 }
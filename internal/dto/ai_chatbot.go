@@ -1,7 +1,69 @@
 package dto
 
+import (
+	"time"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/i18n"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/models"
+)
+
 type AddMessageRequest struct {
 	ChannelID *string `json:"channel_id"`
 	Message   string  `json:"message"`
 	UserID    string  `json:"user_id"`
+	SlackTs   string  `json:"slack_ts"`
+	// SlackThreadTs is the Slack thread of the message, each Slack thread
+	// holds its own conversation with the assistant.
+	SlackThreadTs string        `json:"slack_thread_ts"`
+	Files         []MessageFile `json:"files"`
+	// Locale is the language the bot answers in, it becomes the language of
+	// the thread. The thread keeps its language when empty.
+	Locale i18n.Locale `json:"locale"`
+}
+
+// MessageFile is a file shared on Slack along with a message.
+type MessageFile struct {
+	Name        string `json:"name"`
+	MimeType    string `json:"mime_type"`
+	DownloadURL string `json:"download_url"`
+}
+
+type ThreadMessagesUri struct {
+	ThreadID string `uri:"threadID" binding:"required"`
+}
+
+type ListThreadMessagesQuery struct {
+	Page    int32 `form:"page" binding:"required,min=1"`
+	PerPage int32 `form:"per_page" binding:"required,min=5,max=100"`
+}
+
+type MessageResponse struct {
+	ID          string    `json:"id"`
+	ThreadID    string    `json:"thread_id"`
+	Role        string    `json:"role"`
+	Content     string    `json:"content"`
+	SlackUserID string    `json:"slack_user_id"`
+	SlackTs     string    `json:"slack_ts"`
+	RunID       string    `json:"run_id"`
+	Action      string    `json:"action"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type ListMessageResponse struct {
+	Items    []MessageResponse `json:"items"`
+	Metadata MetadataDto       `json:"metadata"`
+}
+
+func ToMessageResponse(message *models.Message) *MessageResponse {
+	return &MessageResponse{
+		ID:          message.ID,
+		ThreadID:    message.ThreadID,
+		Role:        message.Role,
+		Content:     message.Content,
+		SlackUserID: message.SlackUserID,
+		SlackTs:     message.SlackTs,
+		RunID:       message.RunID,
+		Action:      message.Action,
+		CreatedAt:   message.CreatedAt,
+	}
 }
@@ -0,0 +1,57 @@
+package dto
+
+import "encoding/json"
+
+const (
+	ToolOnboardNhanVien      = "onboard_nhan_vien"
+	ToolWelcomeNewEmployee   = "welcome_new_employee"
+	ToolCreateBuddyFormFile  = "create_buddy_form_file"
+	ToolTakeLeave            = "take_leave"
+	ToolTrainingRequest      = "training_request"
+	ToolRouteToAssistant     = "route_to_assistant"
+	ToolOutputStatusAccepted = "accepted"
+	ToolOutputStatusRejected = "rejected"
+)
+
+// AIToolCall is a function call requested by the assistant, with its JSON
+// arguments kept raw so each Slack handler can bind its own typed struct.
+type AIToolCall struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+type OnboardEmployeeArguments struct {
+	CandidateSheetURL string `json:"candidate_sheet_url"`
+	SkillSheetURL     string `json:"skill_sheet_url"`
+}
+
+type WelcomeNewEmployeeArguments struct {
+	SkillFileURL  string `json:"skill_file_url"`
+	PersonalEmail string `json:"personal_email"`
+}
+
+type CreateBuddyFormArguments struct {
+	InputSheetURL  string `json:"input_sheet_url"`
+	OutputSheetURL string `json:"output_sheet_url"`
+}
+
+type TakeLeaveArguments struct {
+	RequestDateFrom string `json:"request_date_from"`
+	RequestDateTo   string `json:"request_date_to"`
+	HourFrom        string `json:"hour_from"`
+	HourTo          string `json:"hour_to"`
+	Description     string `json:"description"`
+}
+
+type TrainingRequestArguments struct {
+	SheetURL  string `json:"sheet_url"`
+	SheetName string `json:"sheet_name"`
+}
+
+// RouteToAssistantArguments hand the conversation over to the assistant
+// configured for Intent.
+type RouteToAssistantArguments struct {
+	Intent string `json:"intent"`
+	Reason string `json:"reason"`
+}
@@ -1,5 +1,7 @@
 package dto
 
+import "encoding/json"
+
 type AzureAIChatbotMessage struct {
 	ID      string                         `json:"id"`
 	Role    string                         `json:"role"`
@@ -9,13 +11,140 @@ type AzureAIChatbotMessage struct {
 type AzureAIChatbotMessageContent struct {
 	Type string `json:"type"`
 	Text struct {
-		Value       string `json:"value"`
-		Annotations []struct {
-			Type         string `json:"type"`
-			FileCitation struct {
-				FileID string `json:"file_id"`
-			} `json:"file_citation"`
-			Text string `json:"text"`
-		} `json:"annotations"`
+		Value       string                            `json:"value"`
+		Annotations []AzureAIChatbotMessageAnnotation `json:"annotations"`
 	} `json:"text"`
+	ImageFile struct {
+		FileID string `json:"file_id"`
+	} `json:"image_file"`
+}
+
+// AzureAIChatbotMessageAnnotation marks the part of a text content, given in
+// Text, that cites a file (file_citation) or points to a file generated by the
+// assistant (file_path).
+type AzureAIChatbotMessageAnnotation struct {
+	Type         string `json:"type"`
+	Text         string `json:"text"`
+	FileCitation struct {
+		FileID string `json:"file_id"`
+	} `json:"file_citation"`
+	FilePath struct {
+		FileID string `json:"file_id"`
+	} `json:"file_path"`
+}
+
+// AzureAIMessageInput is a message added to a thread, a user message unless
+// Role is "assistant", e.g. for an answer replayed without a run. Images are
+// shown to the model as content parts, other files are attached for the tools
+// able to read them.
+type AzureAIMessageInput struct {
+	Role         string
+	Text         string
+	ImageFileIDs []string
+	Attachments  []AzureAIMessageAttachment
+}
+
+type AzureAIMessageAttachment struct {
+	FileID string        `json:"file_id"`
+	Tools  []AzureAITool `json:"tools"`
+}
+
+type AzureAIRun struct {
+	ID             string                    `json:"id"`
+	ThreadID       string                    `json:"thread_id"`
+	AssistantID    string                    `json:"assistant_id"`
+	Status         string                    `json:"status"`
+	RequiredAction *AzureAIRunRequiredAction `json:"required_action"`
+	LastError      *AzureAIRunError          `json:"last_error"`
+	Model          string                    `json:"model"`
+	// Usage is only set once the run reached a terminal status.
+	Usage *AzureAIRunUsage `json:"usage"`
+}
+
+type AzureAIRunUsage struct {
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+	TotalTokens      int64 `json:"total_tokens"`
+}
+
+type AzureAIRunRequiredAction struct {
+	Type              string `json:"type"`
+	SubmitToolOutputs struct {
+		ToolCalls []AzureAIToolCall `json:"tool_calls"`
+	} `json:"submit_tool_outputs"`
+}
+
+type AzureAIRunError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type AzureAIToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type AzureAIToolOutput struct {
+	ToolCallID string `json:"tool_call_id"`
+	Output     string `json:"output"`
+}
+
+type AzureAITool struct {
+	Type     string                     `json:"type"`
+	Function *AzureAIFunctionDefinition `json:"function,omitempty"`
+}
+
+type AzureAIFunctionDefinition struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+type AzureAIRunRequest struct {
+	AssistantID            string        `json:"assistant_id"`
+	Instructions           string        `json:"instructions,omitempty"`
+	AdditionalInstructions string        `json:"additional_instructions,omitempty"`
+	Tools                  []AzureAITool `json:"tools,omitempty"`
+	ToolChoice             string        `json:"tool_choice,omitempty"`
+	Stream                 bool          `json:"stream,omitempty"`
+}
+
+// AzureAIAssistant is an assistant stored on Azure OpenAI. Metadata holds
+// string values only.
+type AzureAIAssistant struct {
+	ID            string               `json:"id,omitempty"`
+	Name          string               `json:"name"`
+	Model         string               `json:"model"`
+	Instructions  string               `json:"instructions"`
+	Tools         []AzureAITool        `json:"tools"`
+	ToolResources AzureAIToolResources `json:"tool_resources"`
+	Metadata      map[string]string    `json:"metadata"`
+}
+
+type AzureAIToolResources struct {
+	FileSearch *AzureAIFileSearchResources `json:"file_search,omitempty"`
+}
+
+type AzureAIFileSearchResources struct {
+	VectorStoreIDs []string `json:"vector_store_ids"`
+}
+
+// AzureAIRunStreamEvent is one server-sent event of a streamed run, e.g.
+// thread.message.delta or thread.run.completed. Data holds the raw JSON object
+// the event carries.
+type AzureAIRunStreamEvent struct {
+	Event string
+	Data  json.RawMessage
+}
+
+type AzureAIChatbotMessageDelta struct {
+	ID    string `json:"id"`
+	Delta struct {
+		Role    string                         `json:"role"`
+		Content []AzureAIChatbotMessageContent `json:"content"`
+	} `json:"delta"`
 }
@@ -12,3 +12,14 @@ type CreateNewSheetResponse struct {
 	SpreadsheetId  string
 	SpreadsheetUrl string
 }
+
+// ColumnMappingRequest asks to copy the rows of the source sheet into the
+// target sheet, matching their columns by header. Without a target sheet a new
+// skill sheet is created.
+type ColumnMappingRequest struct {
+	SourceSheetURL string
+	TargetSheetURL string
+	SlackUserID    string
+	ChannelID      string
+	SlackThreadTs  string
+}
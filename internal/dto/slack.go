@@ -3,4 +3,28 @@ package dto
 type SendMessageDto struct {
 	Message   string  `json:"message" binding:"required"`
 	ChannelID *string `json:"channel_id"`
+	ThreadTs  string  `json:"thread_ts"`
+}
+
+// The workflow forms, each one is the callback_id of its modal.
+const (
+	FormCandidateFile      = "candidate_file"
+	FormWelcomeNewEmployee = "welcome_new_employee"
+	FormCreateBuddy        = "create_buddy"
+	FormIntegrateTraining  = "integrate_training"
+	FormCreateLeaveRequest = "create_leave_request"
+)
+
+// FormLauncher is the value of the button opening a form modal, Prefill is
+// the initial values of the form by field ID.
+type FormLauncher struct {
+	Form    string            `json:"form"`
+	Prefill map[string]string `json:"prefill,omitempty"`
+}
+
+// FormMetadata is the private_metadata of a form modal, the conversation the
+// form was opened from and answered in.
+type FormMetadata struct {
+	ChannelID string `json:"channel_id"`
+	ThreadTs  string `json:"thread_ts"`
 }
@@ -0,0 +1,20 @@
+package dto
+
+import "time"
+
+type UsageSummaryQuery struct {
+	From        time.Time `form:"from" binding:"required" time_format:"2006-01-02"`
+	To          time.Time `form:"to" binding:"required" time_format:"2006-01-02"`
+	SlackUserID string    `form:"slack_user_id"`
+	ChannelID   string    `form:"channel_id"`
+}
+
+type UsageSummaryResponse struct {
+	Day              time.Time `json:"day"`
+	SlackUserID      string    `json:"slack_user_id"`
+	ChannelID        string    `json:"channel_id"`
+	PromptTokens     int64     `json:"prompt_tokens"`
+	CompletionTokens int64     `json:"completion_tokens"`
+	TotalTokens      int64     `json:"total_tokens"`
+	Cost             float64   `json:"cost"`
+}
@@ -0,0 +1,207 @@
+package forms
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/i18n"
+	"github.com/sotatek-dev/hyper-automation-chatbot/pkg/util"
+)
+
+const (
+	dateLayout = "2006-01-02"
+	timeLayout = "15:04"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Bind validates the values of a submitted form and binds them into a T. The
+// fields of T are tagged with the ID of the form field they get the value of:
+//
+//	type leaveRequest struct {
+//		From        time.Time `form:"request_date_from"`
+//		LeaveType   int       `form:"leave_type"`
+//		Description string    `form:"description"`
+//	}
+//
+// Values are bound into string and int fields, and time.Time fields for dates
+// and times. Bind returns the errors to show under the invalid inputs, by
+// field ID, and an error when T does not match the form.
+func Bind[T any](form *Form, locale i18n.Locale, state map[string]map[string]slack.BlockAction) (T, map[string]string, error) {
+	var target T
+	bindings, err := form.bindings(reflect.TypeOf(target))
+	if err != nil {
+		return target, nil, err
+	}
+
+	values := map[string]string{}
+	fieldErrors := map[string]string{}
+	for _, field := range form.Fields {
+		value := field.value(state)
+		switch {
+		case value == "" && !field.Optional:
+			fieldErrors[field.ID] = i18n.T(locale, "validation.required")
+		case value != "" && !field.valid(locale, value):
+			fieldErrors[field.ID] = i18n.T(locale, field.invalid())
+		}
+		values[field.ID] = value
+	}
+	if len(fieldErrors) > 0 {
+		return target, fieldErrors, nil
+	}
+
+	targetValue := reflect.ValueOf(&target).Elem()
+	for index, field := range bindings {
+		if err := set(targetValue.Field(index), field, values[field.ID]); err != nil {
+			fieldErrors[field.ID] = i18n.T(locale, field.invalid())
+		}
+	}
+	if len(fieldErrors) > 0 {
+		return target, fieldErrors, nil
+	}
+	return target, nil, nil
+}
+
+// Prefill encodes the fields of v, a struct tagged like for Bind, into the
+// initial values of the form. Zero values are left out.
+func (f *Form) Prefill(v interface{}) (map[string]string, error) {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	bindings, err := f.bindings(value.Type())
+	if err != nil {
+		return nil, err
+	}
+	prefill := map[string]string{}
+	for index, field := range bindings {
+		fieldValue := value.Field(index)
+		if fieldValue.IsZero() {
+			continue
+		}
+		switch {
+		case fieldValue.Type() == timeType && field.Type == FieldTime:
+			prefill[field.ID] = fieldValue.Interface().(time.Time).Format(timeLayout)
+		case fieldValue.Type() == timeType:
+			prefill[field.ID] = fieldValue.Interface().(time.Time).Format(dateLayout)
+		case fieldValue.Kind() == reflect.String:
+			prefill[field.ID] = fieldValue.String()
+		default:
+			prefill[field.ID] = strconv.FormatInt(fieldValue.Int(), 10)
+		}
+	}
+	return prefill, nil
+}
+
+// bindings maps the index of each tagged field of the struct t to the form
+// field it is bound to.
+func (f *Form) bindings(t reflect.Type) (map[int]Field, error) {
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("form %s cannot be bound into %v", f.ID, t)
+	}
+	fields := map[string]Field{}
+	for _, field := range f.Fields {
+		fields[field.ID] = field
+	}
+	bindings := map[int]Field{}
+	for index := 0; index < t.NumField(); index++ {
+		structField := t.Field(index)
+		id := structField.Tag.Get("form")
+		if id == "" {
+			continue
+		}
+		field, ok := fields[id]
+		if !ok {
+			return nil, fmt.Errorf("form %s has no field %s", f.ID, id)
+		}
+		switch {
+		case structField.Type == timeType && (field.Type == FieldDate || field.Type == FieldTime):
+		case structField.Type.Kind() == reflect.String:
+		case structField.Type.Kind() >= reflect.Int && structField.Type.Kind() <= reflect.Int64:
+		default:
+			return nil, fmt.Errorf("form %s cannot bind field %s into %s", f.ID, id, structField.Type)
+		}
+		bindings[index] = field
+	}
+	return bindings, nil
+}
+
+// value is the submitted value of the field in the state of the modal.
+func (f Field) value(state map[string]map[string]slack.BlockAction) string {
+	action := state[f.ID][f.actionID()]
+	switch f.Type {
+	case FieldDate:
+		return action.SelectedDate
+	case FieldTime:
+		return action.SelectedTime
+	case FieldSelect:
+		return action.SelectedOption.Value
+	default:
+		return action.Value
+	}
+}
+
+// valid checks the value of the field, the value is not empty.
+func (f Field) valid(locale i18n.Locale, value string) bool {
+	switch f.Type {
+	case FieldEmail:
+		if !util.IsValidEmail(value) {
+			return false
+		}
+	case FieldSheetURL:
+		if !util.IsValidGoogleSheetLink(value) {
+			return false
+		}
+	case FieldDate:
+		if _, err := time.Parse(dateLayout, value); err != nil {
+			return false
+		}
+	case FieldTime:
+		if _, err := time.Parse(timeLayout, value); err != nil {
+			return false
+		}
+	case FieldSelect:
+		known := false
+		for _, option := range f.options(locale) {
+			known = known || option.Value == value
+		}
+		if !known {
+			return false
+		}
+	}
+	return f.Validate == nil || f.Validate(value)
+}
+
+func (f Field) invalid() string {
+	if f.Invalid == "" {
+		return "validation.invalid_value"
+	}
+	return f.Invalid
+}
+
+func set(target reflect.Value, field Field, value string) error {
+	if value == "" {
+		return nil
+	}
+	switch {
+	case target.Type() == timeType:
+		layout := dateLayout
+		if field.Type == FieldTime {
+			layout = timeLayout
+		}
+		parsed, err := time.Parse(layout, value)
+		if err != nil {
+			return err
+		}
+		target.Set(reflect.ValueOf(parsed))
+	case target.Kind() == reflect.String:
+		target.SetString(value)
+	default:
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		target.SetInt(parsed)
+	}
+	return nil
+}
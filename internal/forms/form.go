@@ -0,0 +1,155 @@
+// Package forms declares the workflow forms filled in Slack modals. A form
+// lists its fields once, the package builds their Block Kit, validates the
+// submitted values and binds them into the struct of the workflow.
+package forms
+
+import (
+	"github.com/slack-go/slack"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/i18n"
+)
+
+// FieldType is the kind of value of a field, it picks the Block Kit element
+// of the field and how its value is validated and bound.
+type FieldType string
+
+const (
+	FieldText     FieldType = "text"
+	FieldEmail    FieldType = "email"
+	FieldSheetURL FieldType = "sheet_url"
+	// FieldDate values are "2006-01-02", bound into a string or a time.Time.
+	FieldDate FieldType = "date"
+	// FieldTime values are "15:04".
+	FieldTime FieldType = "time"
+	// FieldSelect values are the value of one of the options of the field.
+	FieldSelect FieldType = "select"
+)
+
+// Option is a choice of a select field.
+type Option struct {
+	Label string
+	Value string
+}
+
+// OptionProvider lists the choices of a select field when its form is built.
+type OptionProvider func(locale i18n.Locale) []Option
+
+// Field is an input of a form. Its label and placeholder are the i18n keys
+// "form.<ID>.label" and "form.<ID>.placeholder".
+type Field struct {
+	// ID is the block ID of the input, the action ID of its element is the
+	// ID followed by "_input".
+	ID       string
+	Type     FieldType
+	Optional bool
+	// Hint is the i18n key of the hint shown under the input, if any.
+	Hint      string
+	Multiline bool
+	MaxLength int
+	// Options lists the choices of a FieldSelect.
+	Options OptionProvider
+	// Validate checks the value further than its type does.
+	Validate func(value string) bool
+	// Invalid is the i18n key of the error shown when the value is invalid,
+	// "validation.invalid_value" by default.
+	Invalid string
+}
+
+// Form is a workflow form. It is filled in a modal whose callback_id is the
+// ID, opened by a button of a message the bot posts in the conversation or
+// straight from a command.
+type Form struct {
+	ID string
+	// Title is the i18n key of the modal title, at most 24 characters long.
+	Title string
+	// Prompt is the i18n key of the message with the button opening the form.
+	Prompt string
+	Fields []Field
+}
+
+// Blocks builds the input blocks of the form, initialized with the prefill
+// values by field ID.
+func (f *Form) Blocks(locale i18n.Locale, prefill map[string]string) []slack.Block {
+	blocks := make([]slack.Block, 0, len(f.Fields))
+	for _, field := range f.Fields {
+		block := slack.NewInputBlock(
+			field.ID,
+			plainText(i18n.T(locale, "form."+field.ID+".label")),
+			nil,
+			field.element(locale, prefill[field.ID]),
+		)
+		if field.Hint != "" {
+			block.Hint = plainText(i18n.T(locale, field.Hint))
+		}
+		block.Optional = field.Optional
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+// Modal is the modal view of the form, privateMetadata is given back with
+// the submission.
+func (f *Form) Modal(locale i18n.Locale, prefill map[string]string, privateMetadata string) slack.ModalViewRequest {
+	return slack.ModalViewRequest{
+		Type:            slack.VTModal,
+		CallbackID:      f.ID,
+		Title:           plainText(i18n.T(locale, f.Title)),
+		Submit:          plainText(i18n.T(locale, "form.submit")),
+		Close:           plainText(i18n.T(locale, "form.cancel")),
+		Blocks:          slack.Blocks{BlockSet: f.Blocks(locale, prefill)},
+		PrivateMetadata: privateMetadata,
+	}
+}
+
+func (f Field) actionID() string {
+	return f.ID + "_input"
+}
+
+func (f Field) element(locale i18n.Locale, initialValue string) slack.BlockElement {
+	placeholder := plainText(i18n.T(locale, "form."+f.ID+".placeholder"))
+	switch f.Type {
+	case FieldDate:
+		return &slack.DatePickerBlockElement{
+			Type:        slack.METDatepicker,
+			ActionID:    f.actionID(),
+			Placeholder: placeholder,
+			InitialDate: initialValue,
+		}
+	case FieldTime:
+		return &slack.TimePickerBlockElement{
+			Type:        slack.METTimepicker,
+			ActionID:    f.actionID(),
+			Placeholder: placeholder,
+			InitialTime: initialValue,
+		}
+	case FieldSelect:
+		element := slack.NewOptionsSelectBlockElement(slack.OptTypeStatic, placeholder, f.actionID())
+		for _, option := range f.options(locale) {
+			choice := slack.NewOptionBlockObject(option.Value, plainText(option.Label), nil)
+			element.Options = append(element.Options, choice)
+			if option.Value == initialValue {
+				element.InitialOption = choice
+			}
+		}
+		return element
+	default:
+		return &slack.PlainTextInputBlockElement{
+			Type:         slack.METPlainTextInput,
+			ActionID:     f.actionID(),
+			Placeholder:  placeholder,
+			InitialValue: initialValue,
+			Multiline:    f.Multiline,
+			MaxLength:    f.MaxLength,
+		}
+	}
+}
+
+func (f Field) options(locale i18n.Locale) []Option {
+	if f.Options == nil {
+		return nil
+	}
+	return f.Options(locale)
+}
+
+func plainText(text string) *slack.TextBlockObject {
+	return slack.NewTextBlockObject(slack.PlainTextType, text, false, false)
+}
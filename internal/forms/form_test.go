@@ -0,0 +1,161 @@
+package forms
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/i18n"
+	"github.com/stretchr/testify/assert"
+)
+
+var testForm = &Form{
+	ID:     "leave_request",
+	Title:  "form.leave_request.title",
+	Prompt: "form.leave_request.prompt",
+	Fields: []Field{
+		{ID: "leave_type", Type: FieldSelect, Options: func(i18n.Locale) []Option {
+			return []Option{{Label: "Remote work", Value: "39"}, {Label: "Onsite", Value: "56"}}
+		}},
+		{ID: "request_date_from", Type: FieldDate},
+		{ID: "hour_from", Type: FieldTime, Validate: func(hour string) bool { return hour != "12:30" }, Invalid: "validation.invalid_hour"},
+		{ID: "personal_email", Type: FieldEmail, Invalid: "validation.invalid_personal_email"},
+		{ID: "skill_file", Type: FieldSheetURL, Optional: true, Hint: "form.skill_file.optional_hint"},
+	},
+}
+
+type testValues struct {
+	LeaveType int       `form:"leave_type"`
+	From      time.Time `form:"request_date_from"`
+	HourFrom  string    `form:"hour_from"`
+	Email     string    `form:"personal_email"`
+	SkillFile string    `form:"skill_file"`
+	Ignored   string
+}
+
+func testState(leaveType string, from string, hourFrom string, email string, skillFile string) map[string]map[string]slack.BlockAction {
+	return map[string]map[string]slack.BlockAction{
+		"leave_type":        {"leave_type_input": {SelectedOption: slack.OptionBlockObject{Value: leaveType}}},
+		"request_date_from": {"request_date_from_input": {SelectedDate: from}},
+		"hour_from":         {"hour_from_input": {SelectedTime: hourFrom}},
+		"personal_email":    {"personal_email_input": {Value: email}},
+		"skill_file":        {"skill_file_input": {Value: skillFile}},
+	}
+}
+
+func TestFormModal(t *testing.T) {
+	modal := testForm.Modal(i18n.English, map[string]string{"leave_type": "56", "request_date_from": "2024-05-09"}, `{"channel_id":"C1"}`)
+
+	assert.Equal(t, "leave_request", modal.CallbackID)
+	assert.Equal(t, "Leave request", modal.Title.Text)
+	assert.Equal(t, `{"channel_id":"C1"}`, modal.PrivateMetadata)
+	encoded, err := json.Marshal(modal)
+	assert.NoError(t, err)
+	var decoded struct {
+		Blocks []struct {
+			BlockID  string `json:"block_id"`
+			Optional bool   `json:"optional"`
+			Element  struct {
+				Type          string `json:"type"`
+				ActionID      string `json:"action_id"`
+				InitialDate   string `json:"initial_date"`
+				InitialOption *struct {
+					Value string `json:"value"`
+				} `json:"initial_option"`
+			} `json:"element"`
+		} `json:"blocks"`
+	}
+	assert.NoError(t, json.Unmarshal(encoded, &decoded))
+	assert.Len(t, decoded.Blocks, 5)
+	assert.Equal(t, "static_select", decoded.Blocks[0].Element.Type)
+	assert.Equal(t, "leave_type_input", decoded.Blocks[0].Element.ActionID)
+	assert.Equal(t, "56", decoded.Blocks[0].Element.InitialOption.Value)
+	assert.Equal(t, "datepicker", decoded.Blocks[1].Element.Type)
+	assert.Equal(t, "2024-05-09", decoded.Blocks[1].Element.InitialDate)
+	assert.Equal(t, "timepicker", decoded.Blocks[2].Element.Type)
+	assert.Equal(t, "plain_text_input", decoded.Blocks[3].Element.Type)
+	assert.True(t, decoded.Blocks[4].Optional)
+}
+
+func TestBind(t *testing.T) {
+	sheet := "https://docs.google.com/spreadsheets/d/sheet/edit"
+
+	tests := []struct {
+		name       string
+		state      map[string]map[string]slack.BlockAction
+		wantValues testValues
+		wantErrors map[string]string
+	}{
+		{
+			name:  "valid values are bound",
+			state: testState("39", "2024-05-09", "08:30", "an@example.com", sheet),
+			wantValues: testValues{
+				LeaveType: 39,
+				From:      time.Date(2024, 5, 9, 0, 0, 0, 0, time.UTC),
+				HourFrom:  "08:30",
+				Email:     "an@example.com",
+				SkillFile: sheet,
+			},
+		},
+		{
+			name:  "optional fields can be left empty",
+			state: testState("39", "2024-05-09", "08:30", "an@example.com", ""),
+			wantValues: testValues{
+				LeaveType: 39,
+				From:      time.Date(2024, 5, 9, 0, 0, 0, 0, time.UTC),
+				HourFrom:  "08:30",
+				Email:     "an@example.com",
+			},
+		},
+		{
+			name:       "required fields",
+			state:      testState("", "", "08:30", "an@example.com", ""),
+			wantErrors: map[string]string{"leave_type": "This field is required", "request_date_from": "This field is required"},
+		},
+		{
+			name:  "invalid values",
+			state: testState("35", "09/05/2024", "12:30", "an", "https://example.com"),
+			wantErrors: map[string]string{
+				"leave_type":        "Invalid value",
+				"request_date_from": "Invalid value",
+				"hour_from":         "Pick a working hour, on the hour or half past",
+				"personal_email":    "Invalid personal email",
+				"skill_file":        "Invalid value",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, fieldErrors, err := Bind[testValues](testForm, i18n.English, tt.state)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantErrors, fieldErrors)
+			assert.Equal(t, tt.wantValues, values)
+		})
+	}
+}
+
+func TestBindMismatch(t *testing.T) {
+	_, _, err := Bind[struct {
+		Name string `form:"name"`
+	}](testForm, i18n.English, nil)
+	assert.EqualError(t, err, "form leave_request has no field name")
+
+	_, _, err = Bind[struct {
+		Email time.Time `form:"personal_email"`
+	}](testForm, i18n.English, nil)
+	assert.EqualError(t, err, "form leave_request cannot bind field personal_email into time.Time")
+}
+
+func TestFormPrefill(t *testing.T) {
+	prefill, err := testForm.Prefill(testValues{
+		LeaveType: 56,
+		From:      time.Date(2024, 5, 9, 0, 0, 0, 0, time.UTC),
+		HourFrom:  "08:30",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"leave_type": "56", "request_date_from": "2024-05-09", "hour_from": "08:30"}, prefill)
+}
@@ -0,0 +1,54 @@
+package i18n
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// vietnameseLetters are the letters found in Vietnamese and not in English:
+// the vowels with a diacritic and đ.
+const vietnameseLetters = "àáảãạăằắẳẵặâầấẩẫậđèéẻẽẹêềếểễệìíỉĩịòóỏõọôồốổỗộơờớởỡợùúủũụưừứửữựỳýỷỹỵ"
+
+// vietnameseWords are frequent Vietnamese words as people type them without
+// diacritics, englishWords frequent English words. None is a word of the
+// other language.
+var (
+	vietnameseWords = wordSet("toi tui minh ban em anh chi xin cho giup voi khong ko duoc dc nghi phep ngay gio lam cua nhe nha oi roi muon tao cai nay kia dang")
+	englishWords    = wordSet("i you we my me your the is are am was be to of for and with please want need can could would how what when where why hello hi thanks thank create leave day from this that it")
+)
+
+// slackMarkup matches the links, mentions and emojis of a Slack message,
+// which say nothing of its language.
+var slackMarkup = regexp.MustCompile(`<[^>]*>|:[a-z0-9_+-]+:`)
+
+// Detect tells the locale a message is written in, empty when the message is
+// too short or too mixed to tell. The words of each language are counted: the
+// words with Vietnamese diacritics and the frequent words of both languages.
+func Detect(text string) Locale {
+	text = strings.ToLower(slackMarkup.ReplaceAllString(text, " "))
+	vietnamese, english := 0, 0
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) }) {
+		switch {
+		case strings.ContainsAny(word, vietnameseLetters) || vietnameseWords[word]:
+			vietnamese++
+		case englishWords[word]:
+			english++
+		}
+	}
+	switch {
+	case vietnamese > english:
+		return Vietnamese
+	case english > vietnamese:
+		return English
+	}
+	return ""
+}
+
+func wordSet(words string) map[string]bool {
+	set := map[string]bool{}
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
@@ -0,0 +1,84 @@
+// Package i18n holds the messages the bot sends to Slack users in every
+// language it speaks, and tells which of them a user writes in.
+package i18n
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Locale string
+
+const (
+	English    Locale = "en"
+	Vietnamese Locale = "vi"
+
+	// DefaultLocale answers the users whose language is unknown.
+	DefaultLocale = English
+)
+
+//go:embed locales/*.yaml
+var localeFiles embed.FS
+
+// catalogs maps each locale to its messages by key, loaded from
+// locales/<locale>.yaml.
+var catalogs = mustLoadCatalogs()
+
+func mustLoadCatalogs() map[Locale]map[string]string {
+	catalogs, err := loadCatalogs()
+	if err != nil {
+		panic(err)
+	}
+	return catalogs
+}
+
+func loadCatalogs() (map[Locale]map[string]string, error) {
+	files, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		return nil, err
+	}
+	catalogs := map[Locale]map[string]string{}
+	for _, file := range files {
+		content, err := localeFiles.ReadFile(path.Join("locales", file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		messages := map[string]string{}
+		if err := yaml.Unmarshal(content, &messages); err != nil {
+			return nil, fmt.Errorf("invalid locale file %s: %w", file.Name(), err)
+		}
+		catalogs[Locale(strings.TrimSuffix(file.Name(), path.Ext(file.Name())))] = messages
+	}
+	return catalogs, nil
+}
+
+// T returns the message key in the locale, formatted with args like
+// fmt.Sprintf. Messages missing from the locale are taken from the default
+// locale, unknown keys are returned as they are.
+func T(locale Locale, key string, args ...interface{}) string {
+	message, ok := catalogs[locale][key]
+	if !ok {
+		message, ok = catalogs[DefaultLocale][key]
+	}
+	if !ok {
+		message = key
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+// ParseSlackLocale returns the locale of a Slack profile locale such as
+// "vi-VN", empty when the bot does not speak it.
+func ParseSlackLocale(slackLocale string) Locale {
+	language, _, _ := strings.Cut(strings.ToLower(slackLocale), "-")
+	if _, ok := catalogs[Locale(language)]; !ok {
+		return ""
+	}
+	return Locale(language)
+}
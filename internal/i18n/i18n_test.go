@@ -0,0 +1,98 @@
+package i18n
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var formatVerb = regexp.MustCompile(`%[-+# 0]*[0-9]*(\.[0-9]+)?[a-zA-Z%]`)
+
+func TestCatalogsMatchDefaultLocale(t *testing.T) {
+	assert.ElementsMatch(t, []Locale{English, Vietnamese}, mapKeys(catalogs))
+	for locale, messages := range catalogs {
+		for key, message := range catalogs[DefaultLocale] {
+			translated, ok := messages[key]
+			if !assert.True(t, ok, "%s misses %s", locale, key) {
+				continue
+			}
+			assert.Equal(t, formatVerb.FindAllString(message, -1), formatVerb.FindAllString(translated, -1), "%s %s", locale, key)
+		}
+		for key := range messages {
+			assert.Contains(t, catalogs[DefaultLocale], key, "%s has unknown key %s", locale, key)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	tests := []struct {
+		name     string
+		locale   Locale
+		key      string
+		args     []interface{}
+		expected string
+	}{
+		{name: "english", locale: English, key: "form.submit", expected: "Submit"},
+		{name: "vietnamese", locale: Vietnamese, key: "form.submit", expected: "Gửi"},
+		{name: "arguments", locale: Vietnamese, key: "uipath.buddy_form_created", args: []interface{}{"buddy.xlsx"}, expected: "Đã tạo buddy form thành công. Vui lòng kiểm tra file buddy.xlsx"},
+		{name: "unknown locale falls back to english", locale: "fr", key: "form.submit", expected: "Submit"},
+		{name: "empty locale falls back to english", key: "form.cancel", expected: "Cancel"},
+		{name: "unknown key", locale: Vietnamese, key: "form.unknown", expected: "form.unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, T(tt.locale, tt.key, tt.args...))
+		})
+	}
+}
+
+func TestParseSlackLocale(t *testing.T) {
+	tests := []struct {
+		slackLocale string
+		expected    Locale
+	}{
+		{slackLocale: "vi-VN", expected: Vietnamese},
+		{slackLocale: "en-US", expected: English},
+		{slackLocale: "en-GB", expected: English},
+		{slackLocale: "ja-JP", expected: ""},
+		{slackLocale: "", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.slackLocale, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ParseSlackLocale(tt.slackLocale))
+		})
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected Locale
+	}{
+		{name: "vietnamese with diacritics", text: "Xin nghỉ phép ngày 2024-05-02", expected: Vietnamese},
+		{name: "vietnamese without diacritics", text: "cho minh xin nghi phep ngay mai nhe", expected: Vietnamese},
+		{name: "english", text: "I want to take leave on 2024-05-02 from 8:00 to 12:00", expected: English},
+		{name: "english with a vietnamese name", text: "Please create the buddy form for Nguyễn Văn An", expected: English},
+		{name: "links and mentions are ignored", text: "<@U123> tạo file <https://docs.google.com/spreadsheets/d/abc|the sheet>", expected: Vietnamese},
+		{name: "too short", text: "ok", expected: ""},
+		{name: "empty", text: "", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Detect(tt.text))
+		})
+	}
+}
+
+func mapKeys(catalogs map[Locale]map[string]string) []Locale {
+	locales := []Locale{}
+	for locale := range catalogs {
+		locales = append(locales, locale)
+	}
+	return locales
+}
@@ -0,0 +1,259 @@
+package mocks
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+)
+
+// FakeAzureServer serves the subset of the Azure OpenAI Assistants API the
+// chatbot uses: threads, messages, runs, files and assistants. Runs play the
+// scripts of its FakeLLMBackend, which also records everything the client
+// sent; point the Azure OpenAI endpoint at URL.
+type FakeAzureServer struct {
+	*httptest.Server
+	Backend *FakeLLMBackend
+
+	mu         sync.Mutex
+	requests   []string
+	assistants []dto.AzureAIAssistant
+}
+
+func NewFakeAzureServer(runs ...FakeLLMRun) *FakeAzureServer {
+	f := &FakeAzureServer{Backend: NewFakeLLMBackend(runs...)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /openai/threads", f.createThread)
+	mux.HandleFunc("DELETE /openai/threads/{thread}", f.deleteThread)
+	mux.HandleFunc("POST /openai/threads/{thread}/messages", f.createMessage)
+	mux.HandleFunc("GET /openai/threads/{thread}/messages", f.listMessages)
+	mux.HandleFunc("POST /openai/threads/{thread}/runs", f.createRun)
+	mux.HandleFunc("GET /openai/threads/{thread}/runs/{run}", f.getRun)
+	mux.HandleFunc("POST /openai/threads/{thread}/runs/{run}/submit_tool_outputs", f.submitToolOutputs)
+	mux.HandleFunc("POST /openai/threads/{thread}/runs/{run}/cancel", f.cancelRun)
+	mux.HandleFunc("POST /openai/files", f.uploadFile)
+	mux.HandleFunc("GET /openai/files/{file}", f.getFileInformation)
+	mux.HandleFunc("GET /openai/files/{file}/content", f.getFileContent)
+	mux.HandleFunc("GET /openai/assistants", f.listAssistants)
+	mux.HandleFunc("POST /openai/assistants", f.createAssistant)
+	mux.HandleFunc("POST /openai/assistants/{assistant}", f.updateAssistant)
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+		f.mu.Unlock()
+		mux.ServeHTTP(w, r)
+	}))
+	return f
+}
+
+// Requests returns the method and path of the requests served so far.
+func (f *FakeAzureServer) Requests() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string{}, f.requests...)
+}
+
+// AddAssistant stores an assistant as if it was created on Azure.
+func (f *FakeAzureServer) AddAssistant(assistant dto.AzureAIAssistant) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.assistants = append(f.assistants, assistant)
+}
+
+// Assistants returns the assistants stored so far, in creation order.
+func (f *FakeAzureServer) Assistants() []dto.AzureAIAssistant {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]dto.AzureAIAssistant{}, f.assistants...)
+}
+
+func (f *FakeAzureServer) createThread(w http.ResponseWriter, r *http.Request) {
+	threadID, err := f.Backend.CreateThread(r.Context())
+	writeFakeAzureResponse(w, map[string]string{"id": threadID, "object": "thread"}, err)
+}
+
+func (f *FakeAzureServer) deleteThread(w http.ResponseWriter, r *http.Request) {
+	err := f.Backend.DeleteThread(r.Context(), r.PathValue("thread"))
+	writeFakeAzureResponse(w, map[string]interface{}{"id": r.PathValue("thread"), "object": "thread.deleted", "deleted": true}, err)
+}
+
+func (f *FakeAzureServer) createMessage(w http.ResponseWriter, r *http.Request) {
+	body := struct {
+		Role        string                         `json:"role"`
+		Content     json.RawMessage                `json:"content"`
+		Attachments []dto.AzureAIMessageAttachment `json:"attachments"`
+	}{}
+	if !decodeFakeAzureRequest(w, r, &body) {
+		return
+	}
+	message := dto.AzureAIMessageInput{Role: body.Role, Attachments: body.Attachments}
+	// Content is a string, or content parts when images are attached.
+	if json.Unmarshal(body.Content, &message.Text) != nil {
+		parts := []struct {
+			Type      string `json:"type"`
+			Text      string `json:"text"`
+			ImageFile struct {
+				FileID string `json:"file_id"`
+			} `json:"image_file"`
+		}{}
+		if err := json.Unmarshal(body.Content, &parts); err != nil {
+			writeFakeAzureError(w, http.StatusBadRequest, "invalid message content")
+			return
+		}
+		texts := []string{}
+		for _, part := range parts {
+			if part.Type == "image_file" {
+				message.ImageFileIDs = append(message.ImageFileIDs, part.ImageFile.FileID)
+			} else {
+				texts = append(texts, part.Text)
+			}
+		}
+		message.Text = strings.Join(texts, "\n")
+	}
+	messageID, err := f.Backend.CreateMessage(r.Context(), r.PathValue("thread"), message)
+	writeFakeAzureResponse(w, map[string]string{"id": messageID, "object": "thread.message"}, err)
+}
+
+func (f *FakeAzureServer) listMessages(w http.ResponseWriter, r *http.Request) {
+	messages, err := f.Backend.ListMessages(r.Context(), r.PathValue("thread"))
+	writeFakeAzureResponse(w, map[string]interface{}{"object": "list", "data": messages}, err)
+}
+
+func (f *FakeAzureServer) createRun(w http.ResponseWriter, r *http.Request) {
+	var request dto.AzureAIRunRequest
+	if !decodeFakeAzureRequest(w, r, &request) {
+		return
+	}
+	if request.Stream {
+		writeFakeAzureError(w, http.StatusBadRequest, "streaming is not supported by the fake server")
+		return
+	}
+	run, err := f.Backend.CreateRun(r.Context(), r.PathValue("thread"), request)
+	writeFakeAzureResponse(w, run, err)
+}
+
+func (f *FakeAzureServer) getRun(w http.ResponseWriter, r *http.Request) {
+	run, err := f.Backend.GetRun(r.Context(), r.PathValue("thread"), r.PathValue("run"))
+	writeFakeAzureResponse(w, run, err)
+}
+
+func (f *FakeAzureServer) submitToolOutputs(w http.ResponseWriter, r *http.Request) {
+	body := struct {
+		ToolOutputs []dto.AzureAIToolOutput `json:"tool_outputs"`
+	}{}
+	if !decodeFakeAzureRequest(w, r, &body) {
+		return
+	}
+	f.respondWithRun(w, r.PathValue("thread"), r.PathValue("run"),
+		f.Backend.SubmitToolOutputs(r.Context(), r.PathValue("thread"), r.PathValue("run"), body.ToolOutputs))
+}
+
+func (f *FakeAzureServer) cancelRun(w http.ResponseWriter, r *http.Request) {
+	f.respondWithRun(w, r.PathValue("thread"), r.PathValue("run"),
+		f.Backend.CancelRun(r.Context(), r.PathValue("thread"), r.PathValue("run")))
+}
+
+// respondWithRun answers the run like the API does after acting on it, the
+// status is not advanced by the answer.
+func (f *FakeAzureServer) respondWithRun(w http.ResponseWriter, threadID string, runID string, err error) {
+	writeFakeAzureResponse(w, map[string]string{"id": runID, "thread_id": threadID, "object": "thread.run"}, err)
+}
+
+func (f *FakeAzureServer) uploadFile(w http.ResponseWriter, r *http.Request) {
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeFakeAzureError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		writeFakeAzureError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	fileID, err := f.Backend.UploadFile(r.Context(), header.Filename, content)
+	writeFakeAzureResponse(w, map[string]string{"id": fileID, "object": "file", "filename": header.Filename}, err)
+}
+
+func (f *FakeAzureServer) getFileInformation(w http.ResponseWriter, r *http.Request) {
+	information, err := f.Backend.GetFileInformation(r.Context(), r.PathValue("file"))
+	writeFakeAzureResponse(w, information, err)
+}
+
+func (f *FakeAzureServer) getFileContent(w http.ResponseWriter, r *http.Request) {
+	content, err := f.Backend.GetFileContent(r.Context(), r.PathValue("file"))
+	if err != nil {
+		writeFakeAzureError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(content)
+}
+
+// listAssistants answers all the assistants in a single page.
+func (f *FakeAzureServer) listAssistants(w http.ResponseWriter, r *http.Request) {
+	writeFakeAzureResponse(w, map[string]interface{}{"object": "list", "data": f.Assistants(), "has_more": false}, nil)
+}
+
+func (f *FakeAzureServer) createAssistant(w http.ResponseWriter, r *http.Request) {
+	var assistant dto.AzureAIAssistant
+	if !decodeFakeAzureRequest(w, r, &assistant) {
+		return
+	}
+	f.mu.Lock()
+	assistant.ID = fmt.Sprintf("asst_%d", len(f.assistants)+1)
+	f.assistants = append(f.assistants, assistant)
+	f.mu.Unlock()
+	writeFakeAzureResponse(w, assistant, nil)
+}
+
+func (f *FakeAzureServer) updateAssistant(w http.ResponseWriter, r *http.Request) {
+	var assistant dto.AzureAIAssistant
+	if !decodeFakeAzureRequest(w, r, &assistant) {
+		return
+	}
+	assistant.ID = r.PathValue("assistant")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.assistants {
+		if f.assistants[i].ID == assistant.ID {
+			f.assistants[i] = assistant
+			writeFakeAzureResponse(w, assistant, nil)
+			return
+		}
+	}
+	writeFakeAzureError(w, http.StatusNotFound, "no assistant found with id '"+assistant.ID+"'")
+}
+
+func decodeFakeAzureRequest(w http.ResponseWriter, r *http.Request, body interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+		writeFakeAzureError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// writeFakeAzureResponse answers body as JSON, or err as a not found error:
+// the backend only fails on unknown threads, runs and files, and on scripts
+// running out.
+func writeFakeAzureResponse(w http.ResponseWriter, body interface{}, err error) {
+	if err != nil {
+		writeFakeAzureError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeFakeAzureError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]string{"code": "invalid_request_error", "type": "invalid_request_error", "message": message},
+	})
+}
@@ -0,0 +1,239 @@
+package mocks
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+)
+
+// FakeLLMRun scripts the outcome of one run created on a FakeLLMBackend.
+type FakeLLMRun struct {
+	// Statuses are returned by successive GetRun calls, the last one repeats.
+	// A requires_action status is held until tool outputs are submitted.
+	Statuses []string
+	// ToolCalls are requested while the run is in requires_action.
+	ToolCalls []dto.AzureAIToolCall
+	// Replies are added to the thread as assistant messages once the run
+	// reaches completed.
+	Replies   []string
+	LastError *dto.AzureAIRunError
+	// Usage is reported once the run is over.
+	Usage *dto.AzureAIRunUsage
+}
+
+type fakeLLMRunState struct {
+	script    FakeLLMRun
+	threadID  string
+	position  int
+	replied   bool
+	cancelled bool
+}
+
+// FakeLLMBackend is an in-memory LLMBackend for tests. Runs play the scripts
+// queued in Runs, in order; everything the service sends is recorded.
+type FakeLLMBackend struct {
+	mu                   sync.Mutex
+	Runs                 []FakeLLMRun
+	Files                map[string][]byte
+	FileInformation      map[string]map[string]interface{}
+	MessageInputs        []dto.AzureAIMessageInput
+	RunRequests          []dto.AzureAIRunRequest
+	SubmittedToolOutputs map[string][]dto.AzureAIToolOutput
+	CancelledRuns        []string
+	DeletedThreads       []string
+	threads              map[string][]dto.AzureAIChatbotMessage
+	runs                 map[string]*fakeLLMRunState
+	sequence             int
+}
+
+func NewFakeLLMBackend(runs ...FakeLLMRun) *FakeLLMBackend {
+	return &FakeLLMBackend{
+		Runs:                 runs,
+		Files:                map[string][]byte{},
+		FileInformation:      map[string]map[string]interface{}{},
+		SubmittedToolOutputs: map[string][]dto.AzureAIToolOutput{},
+		threads:              map[string][]dto.AzureAIChatbotMessage{},
+		runs:                 map[string]*fakeLLMRunState{},
+	}
+}
+
+func (f *FakeLLMBackend) CreateThread(ctx context.Context) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	threadID := f.nextID("thread")
+	f.threads[threadID] = []dto.AzureAIChatbotMessage{}
+	return threadID, nil
+}
+
+func (f *FakeLLMBackend) DeleteThread(ctx context.Context, threadID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.threads[threadID]; !ok {
+		return fmt.Errorf("thread not found: %s", threadID)
+	}
+	delete(f.threads, threadID)
+	f.DeletedThreads = append(f.DeletedThreads, threadID)
+	return nil
+}
+
+func (f *FakeLLMBackend) CreateMessage(ctx context.Context, threadID string, message dto.AzureAIMessageInput) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.threads[threadID]; !ok {
+		return "", fmt.Errorf("thread not found: %s", threadID)
+	}
+	messageID := f.nextID("msg")
+	role := message.Role
+	if role == "" {
+		role = "user"
+	}
+	f.prependMessage(threadID, messageID, role, message.Text)
+	f.MessageInputs = append(f.MessageInputs, message)
+	return messageID, nil
+}
+
+func (f *FakeLLMBackend) ListMessages(ctx context.Context, threadID string) ([]dto.AzureAIChatbotMessage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	messages, ok := f.threads[threadID]
+	if !ok {
+		return nil, fmt.Errorf("thread not found: %s", threadID)
+	}
+	return append([]dto.AzureAIChatbotMessage{}, messages...), nil
+}
+
+func (f *FakeLLMBackend) CreateRun(ctx context.Context, threadID string, request dto.AzureAIRunRequest) (*dto.AzureAIRun, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.threads[threadID]; !ok {
+		return nil, fmt.Errorf("thread not found: %s", threadID)
+	}
+	if len(f.Runs) == 0 {
+		return nil, fmt.Errorf("no scripted run left")
+	}
+	f.RunRequests = append(f.RunRequests, request)
+	state := &fakeLLMRunState{script: f.Runs[0], threadID: threadID}
+	f.Runs = f.Runs[1:]
+	runID := f.nextID("run")
+	f.runs[runID] = state
+	return &dto.AzureAIRun{ID: runID, ThreadID: threadID, AssistantID: request.AssistantID, Status: "queued"}, nil
+}
+
+func (f *FakeLLMBackend) GetRun(ctx context.Context, threadID string, runID string) (*dto.AzureAIRun, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	state, ok := f.runs[runID]
+	if !ok || state.threadID != threadID {
+		return nil, fmt.Errorf("run not found: %s", runID)
+	}
+	status := "completed"
+	if state.cancelled {
+		return &dto.AzureAIRun{ID: runID, ThreadID: threadID, Status: "cancelled"}, nil
+	}
+	if len(state.script.Statuses) > 0 {
+		status = state.script.Statuses[state.position]
+		if status != "requires_action" && state.position < len(state.script.Statuses)-1 {
+			state.position++
+		}
+	}
+	run := &dto.AzureAIRun{ID: runID, ThreadID: threadID, Status: status, LastError: state.script.LastError}
+	switch status {
+	case "requires_action":
+		run.RequiredAction = &dto.AzureAIRunRequiredAction{Type: "submit_tool_outputs"}
+		run.RequiredAction.SubmitToolOutputs.ToolCalls = state.script.ToolCalls
+	case "queued", "in_progress", "cancelling":
+	case "completed":
+		run.Usage = state.script.Usage
+		if !state.replied {
+			state.replied = true
+			for _, reply := range state.script.Replies {
+				f.prependMessage(threadID, f.nextID("msg"), "assistant", reply)
+			}
+		}
+	default:
+		run.Usage = state.script.Usage
+	}
+	return run, nil
+}
+
+func (f *FakeLLMBackend) SubmitToolOutputs(ctx context.Context, threadID string, runID string, outputs []dto.AzureAIToolOutput) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	state, ok := f.runs[runID]
+	if !ok || state.threadID != threadID {
+		return fmt.Errorf("run not found: %s", runID)
+	}
+	if len(state.script.Statuses) == 0 || state.script.Statuses[state.position] != "requires_action" {
+		return fmt.Errorf("run %s is not waiting for tool outputs", runID)
+	}
+	f.SubmittedToolOutputs[runID] = append(f.SubmittedToolOutputs[runID], outputs...)
+	if state.position < len(state.script.Statuses)-1 {
+		state.position++
+	}
+	return nil
+}
+
+// CancelRun records the run in CancelledRuns, later GetRun calls report it
+// cancelled.
+func (f *FakeLLMBackend) CancelRun(ctx context.Context, threadID string, runID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	state, ok := f.runs[runID]
+	if !ok || state.threadID != threadID {
+		return fmt.Errorf("run not found: %s", runID)
+	}
+	f.CancelledRuns = append(f.CancelledRuns, runID)
+	state.cancelled = true
+	return nil
+}
+
+// UploadFile stores the file in Files under a new ID.
+func (f *FakeLLMBackend) UploadFile(ctx context.Context, filename string, content []byte) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fileID := f.nextID("file")
+	f.Files[fileID] = content
+	f.FileInformation[fileID] = map[string]interface{}{"filename": filename}
+	return fileID, nil
+}
+
+func (f *FakeLLMBackend) GetFileContent(ctx context.Context, fileID string) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	content, ok := f.Files[fileID]
+	if !ok {
+		return nil, fmt.Errorf("file not found: %s", fileID)
+	}
+	return content, nil
+}
+
+func (f *FakeLLMBackend) GetFileInformation(ctx context.Context, fileID string) (map[string]interface{}, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	information, ok := f.FileInformation[fileID]
+	if !ok {
+		return nil, fmt.Errorf("file not found: %s", fileID)
+	}
+	return information, nil
+}
+
+// Messages returns the messages of a thread, newest first.
+func (f *FakeLLMBackend) Messages(threadID string) []dto.AzureAIChatbotMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]dto.AzureAIChatbotMessage{}, f.threads[threadID]...)
+}
+
+func (f *FakeLLMBackend) prependMessage(threadID string, messageID string, role string, text string) {
+	content := dto.AzureAIChatbotMessageContent{Type: "text"}
+	content.Text.Value = text
+	message := dto.AzureAIChatbotMessage{ID: messageID, Role: role, Content: []dto.AzureAIChatbotMessageContent{content}}
+	f.threads[threadID] = append([]dto.AzureAIChatbotMessage{message}, f.threads[threadID]...)
+}
+
+func (f *FakeLLMBackend) nextID(prefix string) string {
+	f.sequence++
+	return fmt.Sprintf("%s_%d", prefix, f.sequence)
+}
@@ -0,0 +1,420 @@
+package mocks
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/models"
+	"gorm.io/gorm"
+)
+
+// FakeThreadRepository keeps threads in memory, like ThreadRepository it
+// answers gorm.ErrRecordNotFound for the threads it does not hold.
+type FakeThreadRepository struct {
+	mu        sync.Mutex
+	Threads   map[string]*models.Thread
+	Rollovers []models.ThreadRollover
+}
+
+func NewFakeThreadRepository(threads ...models.Thread) *FakeThreadRepository {
+	f := &FakeThreadRepository{Threads: map[string]*models.Thread{}}
+	for i := range threads {
+		thread := threads[i]
+		f.Threads[thread.ID] = &thread
+	}
+	return f
+}
+
+func (f *FakeThreadRepository) CreateThread(thread *models.Thread) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	thread.Status = models.ThreadStatusOpen
+	thread.CreatedAt = time.Now()
+	stored := *thread
+	f.Threads[thread.ID] = &stored
+	return nil
+}
+
+func (f *FakeThreadRepository) GetThreadByID(threadID string) (*models.Thread, error) {
+	return f.find(func(thread *models.Thread) bool { return thread.ID == threadID })
+}
+
+func (f *FakeThreadRepository) GetLatestOpenThreadByChannelAndUserID(channelID string, userID string) (*models.Thread, error) {
+	return f.find(func(thread *models.Thread) bool {
+		return thread.ChannelId == channelID && thread.SlackUserId == userID && thread.Status == models.ThreadStatusOpen
+	})
+}
+
+func (f *FakeThreadRepository) GetLatestOpenThreadByUserID(userID string) (*models.Thread, error) {
+	return f.find(func(thread *models.Thread) bool {
+		return thread.SlackUserId == userID && thread.Status == models.ThreadStatusOpen
+	})
+}
+
+func (f *FakeThreadRepository) GetThreadBySlackThread(channelID string, slackThreadTs string) (*models.Thread, error) {
+	return f.find(func(thread *models.Thread) bool {
+		return thread.ChannelId == channelID && thread.SlackThreadTs == slackThreadTs
+	})
+}
+
+func (f *FakeThreadRepository) UpdateThreadStatus(threadID string, status string) error {
+	return f.update(threadID, func(thread *models.Thread) { thread.Status = status })
+}
+
+func (f *FakeThreadRepository) UpdateThreadAssistant(threadID string, assistantName string, assistantVersion string) error {
+	return f.update(threadID, func(thread *models.Thread) {
+		thread.AssistantName = assistantName
+		thread.AssistantVersion = assistantVersion
+	})
+}
+
+func (f *FakeThreadRepository) UpdateThreadContextTokens(threadID string, contextTokens int64) error {
+	return f.update(threadID, func(thread *models.Thread) { thread.ContextTokens = contextTokens })
+}
+
+func (f *FakeThreadRepository) UpdateThreadLocale(threadID string, locale string) error {
+	return f.update(threadID, func(thread *models.Thread) { thread.Locale = locale })
+}
+
+func (f *FakeThreadRepository) RolloverThread(rollover *models.ThreadRollover, contextTokens int64) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	thread, ok := f.Threads[rollover.ThreadID]
+	if !ok || thread.LLMThreadID() != rollover.FromBackendThreadID {
+		return false, nil
+	}
+	f.Rollovers = append(f.Rollovers, *rollover)
+	thread.BackendThreadID = rollover.ToBackendThreadID
+	thread.ContextTokens = contextTokens
+	return true, nil
+}
+
+func (f *FakeThreadRepository) ExtendThreadDeadline(threadID string, expiresAt time.Time) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	thread, ok := f.Threads[threadID]
+	if !ok || thread.Status != models.ThreadStatusOpen {
+		return false, nil
+	}
+	thread.ExpiresAt = expiresAt
+	thread.CloseConfirmSentAt = nil
+	return true, nil
+}
+
+func (f *FakeThreadRepository) ListThreadsToConfirmClose(before time.Time) ([]models.Thread, error) {
+	return f.list(func(thread *models.Thread) bool {
+		return thread.Status == models.ThreadStatusOpen && thread.CloseConfirmSentAt == nil && !thread.ExpiresAt.After(before)
+	}), nil
+}
+
+func (f *FakeThreadRepository) MarkCloseConfirmSent(threadID string, sentAt time.Time) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	thread, ok := f.Threads[threadID]
+	if !ok || thread.Status != models.ThreadStatusOpen || thread.CloseConfirmSentAt != nil {
+		return false, nil
+	}
+	thread.CloseConfirmSentAt = &sentAt
+	return true, nil
+}
+
+func (f *FakeThreadRepository) ListExpiredThreads(now time.Time) ([]models.Thread, error) {
+	return f.list(func(thread *models.Thread) bool {
+		return thread.Status == models.ThreadStatusOpen && !thread.ExpiresAt.After(now)
+	}), nil
+}
+
+func (f *FakeThreadRepository) CloseExpiredThread(threadID string, now time.Time) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	thread, ok := f.Threads[threadID]
+	if !ok || thread.Status != models.ThreadStatusOpen || thread.ExpiresAt.After(now) {
+		return false, nil
+	}
+	thread.Status = models.ThreadStatusClosed
+	return true, nil
+}
+
+// find returns a copy of the most recently created thread matching, like the
+// queries ordered by created_at.
+func (f *FakeThreadRepository) find(match func(thread *models.Thread) bool) (*models.Thread, error) {
+	threads := f.list(match)
+	if len(threads) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &threads[0], nil
+}
+
+// list returns copies of the matching threads, most recent first.
+func (f *FakeThreadRepository) list(match func(thread *models.Thread) bool) []models.Thread {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	threads := []models.Thread{}
+	for _, thread := range f.Threads {
+		if match(thread) {
+			threads = append(threads, *thread)
+		}
+	}
+	sort.Slice(threads, func(i, j int) bool { return threads[i].CreatedAt.After(threads[j].CreatedAt) })
+	return threads
+}
+
+func (f *FakeThreadRepository) update(threadID string, change func(thread *models.Thread)) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if thread, ok := f.Threads[threadID]; ok {
+		change(thread)
+	}
+	return nil
+}
+
+// FakeMessageRepository keeps messages in memory, in the order they were
+// created.
+type FakeMessageRepository struct {
+	mu       sync.Mutex
+	Messages []models.Message
+}
+
+func (f *FakeMessageRepository) CreateMessage(message *models.Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Messages = append(f.Messages, *message)
+	return nil
+}
+
+func (f *FakeMessageRepository) UpdateMessage(message *models.Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.Messages {
+		if f.Messages[i].ID == message.ID {
+			f.Messages[i] = *message
+			return nil
+		}
+	}
+	f.Messages = append(f.Messages, *message)
+	return nil
+}
+
+func (f *FakeMessageRepository) GetMessagesByThreadID(threadID string) ([]models.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	messages := []models.Message{}
+	for _, message := range f.Messages {
+		if message.ThreadID == threadID {
+			messages = append(messages, message)
+		}
+	}
+	return messages, nil
+}
+
+func (f *FakeMessageRepository) GetMessagesBySlackTs(slackTs string) ([]models.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	messages := []models.Message{}
+	for _, message := range f.Messages {
+		if message.SlackTs == slackTs {
+			messages = append(messages, message)
+		}
+	}
+	return messages, nil
+}
+
+func (f *FakeMessageRepository) ListMessagesByThreadID(threadID string, perPage, page int32) ([]models.Message, int64, error) {
+	messages, _ := f.GetMessagesByThreadID(threadID)
+	total := int64(len(messages))
+	start := int((page - 1) * perPage)
+	if start < 0 || start >= len(messages) {
+		return []models.Message{}, total, nil
+	}
+	end := start + int(perPage)
+	if end > len(messages) {
+		end = len(messages)
+	}
+	return messages[start:end], total, nil
+}
+
+// FakeRunUsageRepository keeps run usages in memory.
+type FakeRunUsageRepository struct {
+	mu     sync.Mutex
+	Usages []models.RunUsage
+}
+
+func (f *FakeRunUsageRepository) CreateRunUsage(usage *models.RunUsage) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if usage.CreatedAt.IsZero() {
+		usage.CreatedAt = time.Now()
+	}
+	f.Usages = append(f.Usages, *usage)
+	return nil
+}
+
+func (f *FakeRunUsageRepository) SumTotalTokensByUserSince(slackUserID string, since time.Time) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	total := int64(0)
+	for _, usage := range f.Usages {
+		if usage.SlackUserID == slackUserID && !usage.CreatedAt.Before(since) {
+			total += usage.TotalTokens
+		}
+	}
+	return total, nil
+}
+
+// ListUsageSummary is not supported, it answers no rows.
+func (f *FakeRunUsageRepository) ListUsageSummary(query dto.UsageSummaryQuery) ([]dto.UsageSummaryResponse, error) {
+	return []dto.UsageSummaryResponse{}, nil
+}
+
+// FakeCachedAnswerRepository keeps cached answers in memory, by key.
+type FakeCachedAnswerRepository struct {
+	mu      sync.Mutex
+	Answers map[string]*models.CachedAnswer
+}
+
+func NewFakeCachedAnswerRepository() *FakeCachedAnswerRepository {
+	return &FakeCachedAnswerRepository{Answers: map[string]*models.CachedAnswer{}}
+}
+
+func (f *FakeCachedAnswerRepository) GetCachedAnswer(key string, now time.Time) (*models.CachedAnswer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	answer, ok := f.Answers[key]
+	if !ok || !answer.ExpiresAt.After(now) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	found := *answer
+	return &found, nil
+}
+
+func (f *FakeCachedAnswerRepository) SaveCachedAnswer(answer *models.CachedAnswer) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	stored := *answer
+	f.Answers[answer.Key] = &stored
+	return nil
+}
+
+func (f *FakeCachedAnswerRepository) IncrementCachedAnswerHits(key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if answer, ok := f.Answers[key]; ok {
+		answer.Hits++
+	}
+	return nil
+}
+
+func (f *FakeCachedAnswerRepository) DeleteCachedAnswer(key string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.Answers[key]
+	delete(f.Answers, key)
+	return ok, nil
+}
+
+func (f *FakeCachedAnswerRepository) DeleteCachedAnswers(assistantName string) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	deleted := int64(0)
+	for key, answer := range f.Answers {
+		if assistantName == "" || answer.AssistantName == assistantName {
+			delete(f.Answers, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// FakeUIPathJobRepository keeps UiPath jobs in memory, like
+// UIPathJobRepository it answers gorm.ErrRecordNotFound for the jobs it does
+// not hold.
+type FakeUIPathJobRepository struct {
+	mu   sync.Mutex
+	Jobs []models.UIPathJob
+}
+
+func (f *FakeUIPathJobRepository) CreateJob(job *models.UIPathJob) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if job.CreatedAt.IsZero() {
+		job.CreatedAt = time.Now()
+	}
+	f.Jobs = append(f.Jobs, *job)
+	return nil
+}
+
+func (f *FakeUIPathJobRepository) GetJob(jobID int) (*models.UIPathJob, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, job := range f.Jobs {
+		if job.JobID == jobID {
+			return &job, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (f *FakeUIPathJobRepository) UpdateJob(job *models.UIPathJob) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.Jobs {
+		if f.Jobs[i].JobID == job.JobID {
+			f.Jobs[i] = *job
+			return nil
+		}
+	}
+	f.Jobs = append(f.Jobs, *job)
+	return nil
+}
+
+func (f *FakeUIPathJobRepository) ListJobsBySlackUser(slackUserID string, jobType string, limit int) ([]models.UIPathJob, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	jobs := []models.UIPathJob{}
+	for _, job := range f.Jobs {
+		if job.SlackUserID == slackUserID && (jobType == "" || job.JobType == jobType) {
+			jobs = append(jobs, job)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].CreatedAt.After(jobs[j].CreatedAt) })
+	if len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+	return jobs, nil
+}
+
+// FakeSlackEventRepository keeps the delivered Slack events in memory, by
+// event ID.
+type FakeSlackEventRepository struct {
+	mu     sync.Mutex
+	Events map[string]models.SlackEvent
+}
+
+func NewFakeSlackEventRepository() *FakeSlackEventRepository {
+	return &FakeSlackEventRepository{Events: map[string]models.SlackEvent{}}
+}
+
+func (f *FakeSlackEventRepository) CreateSlackEvent(event *models.SlackEvent) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.Events[event.EventID]; ok {
+		return false, nil
+	}
+	f.Events[event.EventID] = *event
+	return true, nil
+}
+
+func (f *FakeSlackEventRepository) DeleteSlackEventsBefore(before time.Time) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	deleted := int64(0)
+	for id, event := range f.Events {
+		if event.CreatedAt.Before(before) {
+			delete(f.Events, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
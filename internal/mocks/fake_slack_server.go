@@ -0,0 +1,127 @@
+package mocks
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/slack-go/slack"
+)
+
+// FakeSlackCall is a Web API call received by a FakeSlackServer.
+type FakeSlackCall struct {
+	Method      string
+	Channel     string
+	ThreadTs    string
+	Ts          string
+	Text        string
+	Attachments []slack.Attachment
+	Blocks      json.RawMessage
+	// TriggerID, UserID and View are the ones of the views.* methods, which
+	// are called with a JSON body.
+	TriggerID string
+	UserID    string
+	View      json.RawMessage
+}
+
+// FakeSlackServer answers the Slack Web API calls of a slack.Client created
+// with slack.OptionAPIURL(server.APIURL()) and records them. Posted messages
+// get the ts "1700000000.000001", "1700000000.000002" and so on.
+type FakeSlackServer struct {
+	*httptest.Server
+	// UserLocales are the profile locales users.info answers by user ID, set
+	// them before the calls.
+	UserLocales map[string]string
+	// UserGroups are the user groups usergroups.list answers.
+	UserGroups []slack.UserGroup
+
+	mu     sync.Mutex
+	calls  []FakeSlackCall
+	posted int
+}
+
+func NewFakeSlackServer() *FakeSlackServer {
+	f := &FakeSlackServer{UserLocales: map[string]string{}}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serve))
+	return f
+}
+
+// APIURL is the base URL of the Web API methods.
+func (f *FakeSlackServer) APIURL() string {
+	return f.URL + "/api/"
+}
+
+// Calls returns the calls to method received so far, or all of them when
+// method is empty.
+func (f *FakeSlackServer) Calls(method string) []FakeSlackCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	calls := []FakeSlackCall{}
+	for _, call := range f.calls {
+		if method == "" || call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+func (f *FakeSlackServer) serve(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	call := FakeSlackCall{
+		Method:   strings.TrimPrefix(r.URL.Path, "/api/"),
+		Channel:  r.Form.Get("channel"),
+		ThreadTs: r.Form.Get("thread_ts"),
+		Ts:       r.Form.Get("ts"),
+		Text:     r.Form.Get("text"),
+	}
+	if attachments := r.Form.Get("attachments"); attachments != "" {
+		json.Unmarshal([]byte(attachments), &call.Attachments)
+	}
+	if blocks := r.Form.Get("blocks"); blocks != "" {
+		call.Blocks = json.RawMessage(blocks)
+	}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		var body struct {
+			TriggerID string          `json:"trigger_id"`
+			UserID    string          `json:"user_id"`
+			View      json.RawMessage `json:"view"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		call.TriggerID = body.TriggerID
+		call.UserID = body.UserID
+		call.View = body.View
+	}
+
+	f.mu.Lock()
+	if call.Method == "chat.postMessage" {
+		f.posted++
+		call.Ts = fmt.Sprintf("1700000000.%06d", f.posted)
+	}
+	f.calls = append(f.calls, call)
+	f.mu.Unlock()
+
+	response := map[string]interface{}{"ok": true, "channel": call.Channel, "ts": call.Ts}
+	if call.Method == "users.info" {
+		user := r.Form.Get("user")
+		response["user"] = map[string]interface{}{
+			"id":      user,
+			"name":    user,
+			"locale":  f.UserLocales[user],
+			"profile": map[string]string{"email": user + "@example.com"},
+		}
+	}
+	if call.Method == "usergroups.list" {
+		response["usergroups"] = f.UserGroups
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// CachedAnswer is what an assistant answered to the first question of a
+// conversation, replayed without a run when the same question starts another
+// conversation before ExpiresAt. Key hashes the assistant name and version
+// with the normalized question, Sources is the list of the policy passages
+// cited by the Replies.
+type CachedAnswer struct {
+	Key              string    `json:"key" gorm:"primaryKey"`
+	AssistantName    string    `json:"assistant_name" gorm:"index"`
+	AssistantVersion string    `json:"assistant_version"`
+	Question         string    `json:"question"`
+	Replies          []string  `json:"replies" gorm:"serializer:json"`
+	Sources          string    `json:"sources"`
+	Hits             int64     `json:"hits"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ChatCompletionThread is a thread of the chat completions LLM backend. The
+// backend sends the whole history of a thread with every completion, the
+// threads are kept in the database so conversations survive restarts and are
+// shared by the instances.
+type ChatCompletionThread struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ChatCompletionMessage is a message of a chat completions thread, in the
+// order of ID. ToolCalls holds the tool calls requested by an assistant
+// message as JSON, ToolCallID the call a tool message answers.
+type ChatCompletionMessage struct {
+	ID         uint            `json:"id" gorm:"primaryKey"`
+	MessageID  string          `json:"message_id"`
+	ThreadID   string          `json:"thread_id" gorm:"index"`
+	Role       string          `json:"role"`
+	Content    string          `json:"content"`
+	ToolCalls  json.RawMessage `json:"tool_calls" gorm:"null"`
+	ToolCallID string          `json:"tool_call_id"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// ChatCompletionRun is a run of a chat completions thread. Run and Request
+// hold the run and the request it was created with as JSON, the request is
+// sent again with the tool outputs. Status is the status of Run, runs only
+// move on from the status they were read with.
+type ChatCompletionRun struct {
+	ID        string          `json:"id" gorm:"primaryKey"`
+	ThreadID  string          `json:"thread_id" gorm:"index"`
+	Status    string          `json:"status"`
+	Run       json.RawMessage `json:"run"`
+	Request   json.RawMessage `json:"request"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+const (
+	ColumnMappingProposalPending   = "pending"
+	ColumnMappingProposalApplied   = "applied"
+	ColumnMappingProposalCancelled = "cancelled"
+)
+
+// ColumnMapping is a column mapping confirmed in Slack, reused for any pair
+// of sheets with the same headers. Mappings are keyed by target header.
+type ColumnMapping struct {
+	Signature     string            `json:"signature" gorm:"primaryKey"`
+	SourceHeaders []string          `json:"source_headers" gorm:"serializer:json"`
+	TargetHeaders []string          `json:"target_headers" gorm:"serializer:json"`
+	Mappings      map[string]string `json:"mappings" gorm:"serializer:json"`
+	ConfirmedBy   string            `json:"confirmed_by"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
+
+// ColumnMappingProposal is a mapping suggested by the header mapping
+// assistant, waiting for the Slack user to confirm it.
+type ColumnMappingProposal struct {
+	ID             string            `json:"id" gorm:"primaryKey"`
+	Signature      string            `json:"signature"`
+	SourceSheetURL string            `json:"source_sheet_url"`
+	TargetSheetURL string            `json:"target_sheet_url"`
+	SourceHeaders  []string          `json:"source_headers" gorm:"serializer:json"`
+	TargetHeaders  []string          `json:"target_headers" gorm:"serializer:json"`
+	Mappings       map[string]string `json:"mappings" gorm:"serializer:json"`
+	SlackUserID    string            `json:"slack_user_id"`
+	ChannelID      string            `json:"channel_id"`
+	SlackThreadTs  string            `json:"slack_thread_ts"`
+	Status         string            `json:"status"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
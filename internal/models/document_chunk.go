@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// DocumentChunkSearchVector is the full text search vector of a chunk. The
+// "simple" configuration does not stem, it works for Vietnamese and English
+// alike. The index created by the migration uses the same expression.
+const DocumentChunkSearchVector = "to_tsvector('simple', coalesce(title, '') || ' ' || coalesce(section, '') || ' ' || content)"
+
+// DocumentChunk is a passage of a company policy document, the unit the
+// assistant is given to answer questions. URL links to the section the
+// passage comes from.
+type DocumentChunk struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Source    string    `json:"source" gorm:"index"`
+	Title     string    `json:"title"`
+	Section   string    `json:"section"`
+	URL       string    `json:"url"`
+	Position  int       `json:"position"`
+	Content   string    `json:"content"`
+	Embedding []float32 `json:"-" gorm:"serializer:json"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -2,11 +2,24 @@ package models
 
 import "time"
 
+const (
+	MessageRoleUser      = "user"
+	MessageRoleAssistant = "assistant"
+)
+
+// Message is a message of a conversation. The question and replies of an
+// answer replayed from the cache have no RunID, they hold the CachedAnswerKey
+// of the answer instead.
 type Message struct {
-	ID        string    `json:"id"`
-	Content   string    `json:"content"`
-	Role      string    `json:"role"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	ThreadID  string    `json:"thread_id"`
+	ID              string    `json:"id"`
+	Content         string    `json:"content"`
+	Role            string    `json:"role"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	ThreadID        string    `json:"thread_id" gorm:"index"`
+	SlackUserID     string    `json:"slack_user_id"`
+	SlackTs         string    `json:"slack_ts" gorm:"index"`
+	RunID           string    `json:"run_id"`
+	Action          string    `json:"action"`
+	CachedAnswerKey string    `json:"cached_answer_key,omitempty"`
 }
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// PIIPlaceholder is a personal value, e.g. an email, replaced by Placeholder
+// in the messages of a thread sent to the LLM. It is kept to restore the value
+// in the replies and tool calls of the thread.
+type PIIPlaceholder struct {
+	ThreadID    string    `json:"thread_id" gorm:"primaryKey"`
+	Placeholder string    `json:"placeholder" gorm:"primaryKey"`
+	Kind        string    `json:"kind"`
+	Value       string    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// RunUsage is the token usage of one assistant run, billed to the Slack user
+// who sent the message.
+type RunUsage struct {
+	RunID            string    `json:"run_id" gorm:"primaryKey"`
+	ThreadID         string    `json:"thread_id" gorm:"index"`
+	SlackUserID      string    `json:"slack_user_id" gorm:"index"`
+	ChannelID        string    `json:"channel_id" gorm:"index"`
+	Model            string    `json:"model"`
+	PromptTokens     int64     `json:"prompt_tokens"`
+	CompletionTokens int64     `json:"completion_tokens"`
+	TotalTokens      int64     `json:"total_tokens"`
+	Cost             float64   `json:"cost"`
+	CreatedAt        time.Time `json:"created_at" gorm:"index"`
+}
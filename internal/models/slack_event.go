@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// SlackEvent is an Events API event delivered over HTTP, remembered to ignore
+// its redeliveries on any instance.
+type SlackEvent struct {
+	EventID   string    `json:"event_id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
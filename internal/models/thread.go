@@ -7,13 +7,57 @@ const (
 	ThreadStatusClosed = "closed"
 )
 
+const (
+	ThreadRolloverReasonSize   = "size"
+	ThreadRolloverReasonManual = "manual"
+)
+
 type Thread struct {
-	ID          string    `json:"id"`
-	Messages    []Message `json:"messages"`
-	ChannelId   string    `json:"channel_id"`
-	SlackUserId string    `json:"slack_user_id"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	DeletedAt   time.Time `json:"deleted_at"`
-	Status      string    `json:"status"`
+	ID                 string     `json:"id"`
+	Messages           []Message  `json:"messages"`
+	ChannelId          string     `json:"channel_id"`
+	SlackUserId        string     `json:"slack_user_id"`
+	SlackThreadTs      string     `json:"slack_thread_ts" gorm:"index"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+	DeletedAt          time.Time  `json:"deleted_at"`
+	Status             string     `json:"status"`
+	ExpiresAt          time.Time  `json:"expires_at" gorm:"index"`
+	CloseConfirmSentAt *time.Time `json:"close_confirm_sent_at"`
+	// AssistantName is the configured assistant answering the thread.
+	AssistantName string `json:"assistant_name"`
+	// AssistantVersion is the version of the assistant definition the last
+	// run of the thread used.
+	AssistantVersion string `json:"assistant_version"`
+	// BackendThreadID is the LLM backend thread holding the conversation. The
+	// conversation moves to a new backend thread on every rollover while ID
+	// stays the key of its history.
+	BackendThreadID string `json:"backend_thread_id"`
+	// ContextTokens approximates the size of the backend thread, in tokens.
+	ContextTokens int64 `json:"context_tokens"`
+	// Locale is the language of the last message of the user, the bot answers
+	// in it.
+	Locale string `json:"locale"`
+}
+
+// LLMThreadID returns the backend thread of the conversation, ID for the
+// threads that never rolled over.
+func (t *Thread) LLMThreadID() string {
+	if t.BackendThreadID != "" {
+		return t.BackendThreadID
+	}
+	return t.ID
+}
+
+// ThreadRollover records a conversation moving from one backend thread to a
+// new one seeded with the Summary of the previous thread.
+type ThreadRollover struct {
+	ID                  uint      `json:"id" gorm:"primaryKey"`
+	ThreadID            string    `json:"thread_id" gorm:"index"`
+	FromBackendThreadID string    `json:"from_backend_thread_id"`
+	ToBackendThreadID   string    `json:"to_backend_thread_id"`
+	Reason              string    `json:"reason"`
+	Summary             string    `json:"summary"`
+	ContextTokens       int64     `json:"context_tokens"`
+	CreatedAt           time.Time `json:"created_at"`
 }
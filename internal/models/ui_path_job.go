@@ -6,16 +6,24 @@ import (
 )
 
 type UIPathJob struct {
-	JobID        int             `json:"jobId" gorm:"column:job_id;unique;primaryKey"`
-	State        string          `json:"state"`
-	Error        string          `json:"error" gorm:"column:error;null"`
-	Output       string          `json:"output" gorm:"column:output;null"`
-	SlackChannel string          `json:"slackChannel" gorm:"column:slack_channel;not null"`
-	JobType      string          `json:"jobType" gorm:"column:job_type;not null"`
-	CreatedAt    time.Time       `json:"created_at"`
-	UpdatedAt    time.Time       `json:"updated_at"`
-	DeletedAt    time.Time       `json:"deleted_at"`
-	Input        json.RawMessage `json:"input" gorm:"column:input;null"`
+	JobID        int    `json:"jobId" gorm:"column:job_id;unique;primaryKey"`
+	State        string `json:"state"`
+	Error        string `json:"error" gorm:"column:error;null"`
+	Output       string `json:"output" gorm:"column:output;null"`
+	SlackChannel string `json:"slackChannel" gorm:"column:slack_channel;not null"`
+	// SlackThreadTs is the Slack thread the job was requested in, the job
+	// notifications are posted there.
+	SlackThreadTs string `json:"slackThreadTs" gorm:"column:slack_thread_ts;null"`
+	// SlackUserID is the user who requested the job, their Home tab lists it.
+	SlackUserID string `json:"slackUserId" gorm:"column:slack_user_id;index"`
+	// Locale is the language of the user who requested the job, the job
+	// notifications are written in it.
+	Locale    string          `json:"locale" gorm:"column:locale;null"`
+	JobType   string          `json:"jobType" gorm:"column:job_type;not null"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	DeletedAt time.Time       `json:"deleted_at"`
+	Input     json.RawMessage `json:"input" gorm:"column:input;null"`
 }
 
 const (
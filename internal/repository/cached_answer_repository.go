@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CachedAnswerRepository struct {
+	db *gorm.DB
+}
+
+type CachedAnswerRepositoryInterface interface {
+	GetCachedAnswer(key string, now time.Time) (*models.CachedAnswer, error)
+	SaveCachedAnswer(answer *models.CachedAnswer) error
+	IncrementCachedAnswerHits(key string) error
+	DeleteCachedAnswer(key string) (bool, error)
+	DeleteCachedAnswers(assistantName string) (int64, error)
+}
+
+func NewCachedAnswerRepository(db *gorm.DB) *CachedAnswerRepository {
+	return &CachedAnswerRepository{db}
+}
+
+// GetCachedAnswer returns the answer cached for key, expired answers are not
+// found.
+func (r *CachedAnswerRepository) GetCachedAnswer(key string, now time.Time) (*models.CachedAnswer, error) {
+	var answer models.CachedAnswer
+	return &answer, r.db.Where("key = ? AND expires_at > ?", key, now).First(&answer).Error
+}
+
+// SaveCachedAnswer stores the answer, replacing the one cached earlier for the
+// same key.
+func (r *CachedAnswerRepository) SaveCachedAnswer(answer *models.CachedAnswer) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"question", "replies", "sources", "hits", "expires_at", "updated_at"}),
+	}).Create(answer).Error
+}
+
+func (r *CachedAnswerRepository) IncrementCachedAnswerHits(key string) error {
+	return r.db.Model(&models.CachedAnswer{}).
+		Where("key = ?", key).
+		Update("hits", gorm.Expr("hits + 1")).Error
+}
+
+// DeleteCachedAnswer reports false when nothing was cached for key.
+func (r *CachedAnswerRepository) DeleteCachedAnswer(key string) (bool, error) {
+	result := r.db.Where("key = ?", key).Delete(&models.CachedAnswer{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// DeleteCachedAnswers deletes the answers of the assistant, of all the
+// assistants when assistantName is empty, and returns how many were deleted.
+func (r *CachedAnswerRepository) DeleteCachedAnswers(assistantName string) (int64, error) {
+	query := r.db.Session(&gorm.Session{AllowGlobalUpdate: true})
+	if assistantName != "" {
+		query = r.db.Where("assistant_name = ?", assistantName)
+	}
+	result := query.Delete(&models.CachedAnswer{})
+	return result.RowsAffected, result.Error
+}
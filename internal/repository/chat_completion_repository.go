@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/models"
+	"gorm.io/gorm"
+)
+
+type ChatCompletionRepository struct {
+	db *gorm.DB
+}
+
+type ChatCompletionRepositoryInterface interface {
+	CreateThread(thread *models.ChatCompletionThread) error
+	GetThread(threadID string) (*models.ChatCompletionThread, error)
+	DeleteThread(threadID string) error
+	CreateMessage(message *models.ChatCompletionMessage) error
+	// ListMessages returns the messages of the thread, oldest first.
+	ListMessages(threadID string) ([]models.ChatCompletionMessage, error)
+	CreateRun(run *models.ChatCompletionRun) error
+	GetRun(threadID string, runID string) (*models.ChatCompletionRun, error)
+	// UpdateRun saves the run if it still has the status fromStatus and adds
+	// the messages to its thread. It reports whether the run was saved.
+	UpdateRun(run *models.ChatCompletionRun, fromStatus string, messages []models.ChatCompletionMessage) (bool, error)
+}
+
+func NewChatCompletionRepository(db *gorm.DB) *ChatCompletionRepository {
+	return &ChatCompletionRepository{db}
+}
+
+func (r *ChatCompletionRepository) CreateThread(thread *models.ChatCompletionThread) error {
+	return r.db.Create(thread).Error
+}
+
+func (r *ChatCompletionRepository) GetThread(threadID string) (*models.ChatCompletionThread, error) {
+	var thread models.ChatCompletionThread
+	return &thread, r.db.Where("id = ?", threadID).First(&thread).Error
+}
+
+// DeleteThread deletes the thread with its messages and runs.
+func (r *ChatCompletionRepository) DeleteThread(threadID string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("thread_id = ?", threadID).Delete(&models.ChatCompletionMessage{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("thread_id = ?", threadID).Delete(&models.ChatCompletionRun{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", threadID).Delete(&models.ChatCompletionThread{}).Error
+	})
+}
+
+func (r *ChatCompletionRepository) CreateMessage(message *models.ChatCompletionMessage) error {
+	return r.db.Create(message).Error
+}
+
+func (r *ChatCompletionRepository) ListMessages(threadID string) ([]models.ChatCompletionMessage, error) {
+	var messages []models.ChatCompletionMessage
+	return messages, r.db.Where("thread_id = ?", threadID).Order("id").Find(&messages).Error
+}
+
+func (r *ChatCompletionRepository) CreateRun(run *models.ChatCompletionRun) error {
+	return r.db.Create(run).Error
+}
+
+func (r *ChatCompletionRepository) GetRun(threadID string, runID string) (*models.ChatCompletionRun, error) {
+	var run models.ChatCompletionRun
+	return &run, r.db.Where("id = ? AND thread_id = ?", runID, threadID).First(&run).Error
+}
+
+func (r *ChatCompletionRepository) UpdateRun(run *models.ChatCompletionRun, fromStatus string, messages []models.ChatCompletionMessage) (bool, error) {
+	updated := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.ChatCompletionRun{}).
+			Where("id = ? AND status = ?", run.ID, fromStatus).
+			Updates(map[string]interface{}{"status": run.Status, "run": run.Run})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		updated = true
+		if len(messages) == 0 {
+			return nil
+		}
+		return tx.Create(&messages).Error
+	})
+	return updated, err
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ColumnMappingRepository struct {
+	db *gorm.DB
+}
+
+type ColumnMappingRepositoryInterface interface {
+	GetColumnMapping(signature string) (*models.ColumnMapping, error)
+	SaveColumnMapping(mapping *models.ColumnMapping) error
+	CreateProposal(proposal *models.ColumnMappingProposal) error
+	GetProposal(proposalID string) (*models.ColumnMappingProposal, error)
+	UpdateProposalStatus(proposalID string, fromStatus string, toStatus string) (bool, error)
+}
+
+func NewColumnMappingRepository(db *gorm.DB) *ColumnMappingRepository {
+	return &ColumnMappingRepository{db}
+}
+
+func (r *ColumnMappingRepository) GetColumnMapping(signature string) (*models.ColumnMapping, error) {
+	var mapping models.ColumnMapping
+	return &mapping, r.db.Where("signature = ?", signature).First(&mapping).Error
+}
+
+// SaveColumnMapping stores the mapping, replacing the one confirmed earlier
+// for the same signature.
+func (r *ColumnMappingRepository) SaveColumnMapping(mapping *models.ColumnMapping) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "signature"}},
+		DoUpdates: clause.AssignmentColumns([]string{"mappings", "confirmed_by", "updated_at"}),
+	}).Create(mapping).Error
+}
+
+func (r *ColumnMappingRepository) CreateProposal(proposal *models.ColumnMappingProposal) error {
+	proposal.Status = models.ColumnMappingProposalPending
+	return r.db.Create(proposal).Error
+}
+
+func (r *ColumnMappingRepository) GetProposal(proposalID string) (*models.ColumnMappingProposal, error) {
+	var proposal models.ColumnMappingProposal
+	return &proposal, r.db.Where("id = ?", proposalID).First(&proposal).Error
+}
+
+// UpdateProposalStatus moves the proposal from fromStatus to toStatus. It
+// reports false when the proposal was not in fromStatus anymore, e.g. after a
+// double click on the confirm button.
+func (r *ColumnMappingRepository) UpdateProposalStatus(proposalID string, fromStatus string, toStatus string) (bool, error) {
+	result := r.db.Model(&models.ColumnMappingProposal{}).
+		Where("id = ? AND status = ?", proposalID, fromStatus).
+		Update("status", toStatus)
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/models"
+	"gorm.io/gorm"
+)
+
+type DocumentChunkRepository struct {
+	db *gorm.DB
+}
+
+type DocumentChunkRepositoryInterface interface {
+	ReplaceSourceChunks(source string, chunks []models.DocumentChunk) error
+	SearchChunks(query string, limit int) ([]models.DocumentChunk, error)
+	ListChunksWithEmbedding() ([]models.DocumentChunk, error)
+	GetChunksVersion() (DocumentChunksVersion, error)
+}
+
+// DocumentChunksVersion changes whenever chunks are ingested or deleted:
+// ingested chunks get new IDs, deleted ones lower the count.
+type DocumentChunksVersion struct {
+	Count int64
+	MaxID uint
+}
+
+func NewDocumentChunkRepository(db *gorm.DB) *DocumentChunkRepository {
+	return &DocumentChunkRepository{db}
+}
+
+// ReplaceSourceChunks swaps the chunks of a document for new ones, so a
+// document can be ingested again after it changed.
+func (r *DocumentChunkRepository) ReplaceSourceChunks(source string, chunks []models.DocumentChunk) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("source = ?", source).Delete(&models.DocumentChunk{}).Error
+		if err != nil {
+			return err
+		}
+		if len(chunks) == 0 {
+			return nil
+		}
+		return tx.Create(&chunks).Error
+	})
+}
+
+// SearchChunks returns the chunks containing any word of the query, the best
+// full text search rank first.
+func (r *DocumentChunkRepository) SearchChunks(query string, limit int) ([]models.DocumentChunk, error) {
+	tsQuery := keywordQuery(query)
+	if tsQuery == "" {
+		return []models.DocumentChunk{}, nil
+	}
+	var chunks []models.DocumentChunk
+	err := r.db.
+		Where(models.DocumentChunkSearchVector+" @@ to_tsquery('simple', ?)", tsQuery).
+		Order(gorm.Expr("ts_rank("+models.DocumentChunkSearchVector+", to_tsquery('simple', ?)) DESC", tsQuery)).
+		Limit(limit).
+		Find(&chunks).Error
+	return chunks, err
+}
+
+func (r *DocumentChunkRepository) ListChunksWithEmbedding() ([]models.DocumentChunk, error) {
+	var chunks []models.DocumentChunk
+	return chunks, r.db.Where("embedding IS NOT NULL AND embedding <> 'null'").Find(&chunks).Error
+}
+
+// GetChunksVersion is a cheap query telling whether the chunks changed since
+// they were last listed.
+func (r *DocumentChunkRepository) GetChunksVersion() (DocumentChunksVersion, error) {
+	var version DocumentChunksVersion
+	err := r.db.Model(&models.DocumentChunk{}).
+		Select("COUNT(*) AS count, COALESCE(MAX(id), 0) AS max_id").
+		Scan(&version).Error
+	return version, err
+}
+
+// stopWords are the English and Vietnamese words found in almost every
+// passage. The "simple" configuration keeps them, a question would match
+// every chunk through them.
+var stopWords = wordSet(`
+	a an and are as at be been but by can could do does for from had has have how i if in into is it its
+	me my no not of on or our so than that the their them then there these they this to was we were what
+	when where which who why will with would you your
+	anh ai bao bị các cái cho chị có của cũng đã đang để đến đó được em gì hay hoặc khi không là làm
+	lại mà mình một này nào nên nếu nhiêu những ở ra rằng rồi sao sẽ tại thì thế theo tôi trong từ và
+	vào về với vẫn`)
+
+func wordSet(words string) map[string]bool {
+	set := map[string]bool{}
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
+
+// keywordQuery turns free text into a tsquery matching any of its words,
+// stop words left out. Only letters and digits are kept, tsquery operators
+// cannot be injected.
+func keywordQuery(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && !unicode.Is(unicode.Mn, r)
+	})
+	seen := map[string]bool{}
+	terms := []string{}
+	for _, word := range words {
+		if len([]rune(word)) < 2 || seen[word] || stopWords[word] {
+			continue
+		}
+		seen[word] = true
+		terms = append(terms, word)
+	}
+	return strings.Join(terms, " | ")
+}
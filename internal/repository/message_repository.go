@@ -11,7 +11,10 @@ type MessageRepository struct {
 
 type MessageRepositoryInterface interface {
 	CreateMessage(message *models.Message) error
+	UpdateMessage(message *models.Message) error
 	GetMessagesByThreadID(threadID string) ([]models.Message, error)
+	GetMessagesBySlackTs(slackTs string) ([]models.Message, error)
+	ListMessagesByThreadID(threadID string, perPage, page int32) ([]models.Message, int64, error)
 }
 
 func NewMessageRepository(db *gorm.DB) *MessageRepository {
@@ -22,7 +25,34 @@ func (m *MessageRepository) CreateMessage(message *models.Message) error {
 	return m.db.Create(message).Error
 }
 
+func (m *MessageRepository) UpdateMessage(message *models.Message) error {
+	return m.db.Save(message).Error
+}
+
 func (m *MessageRepository) GetMessagesByThreadID(threadID string) ([]models.Message, error) {
 	var messages []models.Message
 	return messages, m.db.Where("thread_id = ?", threadID).Find(&messages).Error
 }
+
+func (m *MessageRepository) GetMessagesBySlackTs(slackTs string) ([]models.Message, error) {
+	var messages []models.Message
+	return messages, m.db.Where("slack_ts = ?", slackTs).Find(&messages).Error
+}
+
+func (m *MessageRepository) ListMessagesByThreadID(threadID string, perPage, page int32) ([]models.Message, int64, error) {
+	var messages []models.Message
+	var total int64
+
+	query := m.db.Model(&models.Message{}).Where("thread_id = ?", threadID)
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = query.Order("created_at ASC").Offset(int((page - 1) * perPage)).Limit(int(perPage)).Find(&messages).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return messages, total, nil
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PIIPlaceholderRepository struct {
+	db *gorm.DB
+}
+
+type PIIPlaceholderRepositoryInterface interface {
+	ListThreadPlaceholders(threadID string) ([]models.PIIPlaceholder, error)
+	CreateThreadPlaceholders(threadID string, create func(existing []models.PIIPlaceholder) []models.PIIPlaceholder) error
+}
+
+func NewPIIPlaceholderRepository(db *gorm.DB) *PIIPlaceholderRepository {
+	return &PIIPlaceholderRepository{db}
+}
+
+func (r *PIIPlaceholderRepository) ListThreadPlaceholders(threadID string) ([]models.PIIPlaceholder, error) {
+	var placeholders []models.PIIPlaceholder
+	return placeholders, r.db.Where("thread_id = ?", threadID).Find(&placeholders).Error
+}
+
+// CreateThreadPlaceholders stores the placeholders create numbers after the
+// existing ones of the thread. The thread is locked meanwhile, the messages of
+// a thread handled at the same time would number theirs alike otherwise.
+func (r *PIIPlaceholderRepository) CreateThreadPlaceholders(threadID string, create func(existing []models.PIIPlaceholder) []models.PIIPlaceholder) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", threadID).Find(&[]models.Thread{}).Error
+		if err != nil {
+			return err
+		}
+		var existing []models.PIIPlaceholder
+		err = tx.Where("thread_id = ?", threadID).Find(&existing).Error
+		if err != nil {
+			return err
+		}
+		placeholders := create(existing)
+		if len(placeholders) == 0 {
+			return nil
+		}
+		return tx.Create(&placeholders).Error
+	})
+}
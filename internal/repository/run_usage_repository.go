@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/models"
+	"gorm.io/gorm"
+)
+
+type RunUsageRepository struct {
+	db *gorm.DB
+}
+
+type RunUsageRepositoryInterface interface {
+	CreateRunUsage(usage *models.RunUsage) error
+	SumTotalTokensByUserSince(slackUserID string, since time.Time) (int64, error)
+	ListUsageSummary(query dto.UsageSummaryQuery) ([]dto.UsageSummaryResponse, error)
+}
+
+func NewRunUsageRepository(db *gorm.DB) *RunUsageRepository {
+	return &RunUsageRepository{db}
+}
+
+func (r *RunUsageRepository) CreateRunUsage(usage *models.RunUsage) error {
+	return r.db.Create(usage).Error
+}
+
+func (r *RunUsageRepository) SumTotalTokensByUserSince(slackUserID string, since time.Time) (int64, error) {
+	var total int64
+	err := r.db.Model(&models.RunUsage{}).
+		Where("slack_user_id = ? AND created_at >= ?", slackUserID, since).
+		Select("COALESCE(SUM(total_tokens), 0)").
+		Scan(&total).Error
+	return total, err
+}
+
+// ListUsageSummary adds up the usage per day, Slack user and channel, most
+// recent day first.
+func (r *RunUsageRepository) ListUsageSummary(query dto.UsageSummaryQuery) ([]dto.UsageSummaryResponse, error) {
+	var summary []dto.UsageSummaryResponse
+	db := r.db.Model(&models.RunUsage{}).
+		Select("DATE(created_at) AS day, slack_user_id, channel_id, "+
+			"SUM(prompt_tokens) AS prompt_tokens, SUM(completion_tokens) AS completion_tokens, "+
+			"SUM(total_tokens) AS total_tokens, SUM(cost) AS cost").
+		Where("created_at >= ? AND created_at < ?", query.From, query.To)
+	if query.SlackUserID != "" {
+		db = db.Where("slack_user_id = ?", query.SlackUserID)
+	}
+	if query.ChannelID != "" {
+		db = db.Where("channel_id = ?", query.ChannelID)
+	}
+	err := db.Group("DATE(created_at), slack_user_id, channel_id").
+		Order("day DESC, cost DESC").
+		Scan(&summary).Error
+	return summary, err
+}
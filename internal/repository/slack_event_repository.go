@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SlackEventRepository struct {
+	db *gorm.DB
+}
+
+type SlackEventRepositoryInterface interface {
+	CreateSlackEvent(event *models.SlackEvent) (bool, error)
+	DeleteSlackEventsBefore(before time.Time) (int64, error)
+}
+
+func NewSlackEventRepository(db *gorm.DB) *SlackEventRepository {
+	return &SlackEventRepository{db}
+}
+
+// CreateSlackEvent reports false when the event was stored already.
+func (r *SlackEventRepository) CreateSlackEvent(event *models.SlackEvent) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(event)
+	return result.RowsAffected > 0, result.Error
+}
+
+// DeleteSlackEventsBefore deletes the events stored before the time and
+// returns how many were deleted.
+func (r *SlackEventRepository) DeleteSlackEventsBefore(before time.Time) (int64, error) {
+	result := r.db.Where("created_at < ?", before).Delete(&models.SlackEvent{})
+	return result.RowsAffected, result.Error
+}
@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/sotatek-dev/hyper-automation-chatbot/internal/models"
 	"gorm.io/gorm"
 )
@@ -13,7 +15,18 @@ type ThreadRepositoryInterface interface {
 	CreateThread(thread *models.Thread) error
 	GetThreadByID(threadID string) (*models.Thread, error)
 	GetLatestOpenThreadByChannelAndUserID(channelID string, userID string) (*models.Thread, error)
+	GetLatestOpenThreadByUserID(userID string) (*models.Thread, error)
+	GetThreadBySlackThread(channelID string, slackThreadTs string) (*models.Thread, error)
 	UpdateThreadStatus(threadID string, status string) error
+	UpdateThreadAssistant(threadID string, assistantName string, assistantVersion string) error
+	UpdateThreadContextTokens(threadID string, contextTokens int64) error
+	UpdateThreadLocale(threadID string, locale string) error
+	RolloverThread(rollover *models.ThreadRollover, contextTokens int64) (bool, error)
+	ExtendThreadDeadline(threadID string, expiresAt time.Time) (bool, error)
+	ListThreadsToConfirmClose(before time.Time) ([]models.Thread, error)
+	MarkCloseConfirmSent(threadID string, sentAt time.Time) (bool, error)
+	ListExpiredThreads(now time.Time) ([]models.Thread, error)
+	CloseExpiredThread(threadID string, now time.Time) (bool, error)
 }
 
 func NewThreadRepository(db *gorm.DB) *ThreadRepository {
@@ -35,6 +48,87 @@ func (t *ThreadRepository) GetLatestOpenThreadByChannelAndUserID(channelID strin
 	return &thread, t.db.Where("channel_id = ? AND slack_user_id = ? AND status = ?", channelID, userID, models.ThreadStatusOpen).Order("created_at DESC").First(&thread).Error
 }
 
+func (t *ThreadRepository) GetLatestOpenThreadByUserID(userID string) (*models.Thread, error) {
+	var thread models.Thread
+	return &thread, t.db.Where("slack_user_id = ? AND status = ?", userID, models.ThreadStatusOpen).Order("created_at DESC").First(&thread).Error
+}
+
+// GetThreadBySlackThread returns the latest conversation of the Slack
+// thread, a new one starts in the Slack thread once the previous is closed.
+func (t *ThreadRepository) GetThreadBySlackThread(channelID string, slackThreadTs string) (*models.Thread, error) {
+	var thread models.Thread
+	return &thread, t.db.Where("channel_id = ? AND slack_thread_ts = ?", channelID, slackThreadTs).Order("created_at DESC").First(&thread).Error
+}
+
 func (t *ThreadRepository) UpdateThreadStatus(threadID string, status string) error {
 	return t.db.Model(&models.Thread{}).Where("id = ?", threadID).Update("status", status).Error
 }
+
+func (t *ThreadRepository) UpdateThreadAssistant(threadID string, assistantName string, assistantVersion string) error {
+	return t.db.Model(&models.Thread{}).Where("id = ?", threadID).
+		Updates(map[string]interface{}{"assistant_name": assistantName, "assistant_version": assistantVersion}).Error
+}
+
+func (t *ThreadRepository) UpdateThreadContextTokens(threadID string, contextTokens int64) error {
+	return t.db.Model(&models.Thread{}).Where("id = ?", threadID).Update("context_tokens", contextTokens).Error
+}
+
+func (t *ThreadRepository) UpdateThreadLocale(threadID string, locale string) error {
+	return t.db.Model(&models.Thread{}).Where("id = ?", threadID).Update("locale", locale).Error
+}
+
+// RolloverThread records the rollover and points the thread to the new
+// backend thread, whose size is contextTokens. It reports false, recording
+// nothing, when the thread moved off the backend thread of the rollover in
+// the meantime.
+func (t *ThreadRepository) RolloverThread(rollover *models.ThreadRollover, contextTokens int64) (bool, error) {
+	rolledOver := false
+	err := t.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Thread{}).
+			Where("id = ? AND COALESCE(NULLIF(backend_thread_id, ''), id) = ?", rollover.ThreadID, rollover.FromBackendThreadID).
+			Updates(map[string]interface{}{"backend_thread_id": rollover.ToBackendThreadID, "context_tokens": contextTokens})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		rolledOver = true
+		return tx.Create(rollover).Error
+	})
+	return rolledOver && err == nil, err
+}
+
+// ExtendThreadDeadline moves the deadline of an open thread and forgets any
+// close confirmation already sent. It reports false when the thread is closed.
+func (t *ThreadRepository) ExtendThreadDeadline(threadID string, expiresAt time.Time) (bool, error) {
+	result := t.db.Model(&models.Thread{}).
+		Where("id = ? AND status = ?", threadID, models.ThreadStatusOpen).
+		Updates(map[string]interface{}{"expires_at": expiresAt, "close_confirm_sent_at": nil})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (t *ThreadRepository) ListThreadsToConfirmClose(before time.Time) ([]models.Thread, error) {
+	var threads []models.Thread
+	return threads, t.db.Where("status = ? AND close_confirm_sent_at IS NULL AND expires_at <= ?", models.ThreadStatusOpen, before).Find(&threads).Error
+}
+
+// MarkCloseConfirmSent claims the close confirmation of a thread so only one
+// server instance sends it. It reports false when another one already did.
+func (t *ThreadRepository) MarkCloseConfirmSent(threadID string, sentAt time.Time) (bool, error) {
+	result := t.db.Model(&models.Thread{}).
+		Where("id = ? AND status = ? AND close_confirm_sent_at IS NULL", threadID, models.ThreadStatusOpen).
+		Update("close_confirm_sent_at", sentAt)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (t *ThreadRepository) ListExpiredThreads(now time.Time) ([]models.Thread, error) {
+	var threads []models.Thread
+	return threads, t.db.Where("status = ? AND expires_at <= ?", models.ThreadStatusOpen, now).Find(&threads).Error
+}
+
+// CloseExpiredThread closes the thread if it is still open and past its
+// deadline. It reports false when the thread was extended or already closed.
+func (t *ThreadRepository) CloseExpiredThread(threadID string, now time.Time) (bool, error) {
+	result := t.db.Model(&models.Thread{}).
+		Where("id = ? AND status = ? AND expires_at <= ?", threadID, models.ThreadStatusOpen, now).
+		Update("status", models.ThreadStatusClosed)
+	return result.RowsAffected > 0, result.Error
+}
@@ -5,6 +5,13 @@ import (
 	"gorm.io/gorm"
 )
 
+type UIPathJobRepositoryInterface interface {
+	CreateJob(job *models.UIPathJob) error
+	GetJob(jobID int) (*models.UIPathJob, error)
+	UpdateJob(job *models.UIPathJob) error
+	ListJobsBySlackUser(slackUserID string, jobType string, limit int) ([]models.UIPathJob, error)
+}
+
 type UIPathJobRepository struct {
 	db *gorm.DB
 }
@@ -28,3 +35,14 @@ func (r *UIPathJobRepository) GetJob(jobID int) (*models.UIPathJob, error) {
 func (r *UIPathJobRepository) UpdateJob(job *models.UIPathJob) error {
 	return r.db.Save(job).Error
 }
+
+// ListJobsBySlackUser lists the latest jobs requested by the Slack user, of
+// any type when jobType is empty.
+func (r *UIPathJobRepository) ListJobsBySlackUser(slackUserID string, jobType string, limit int) ([]models.UIPathJob, error) {
+	query := r.db.Where("slack_user_id = ?", slackUserID)
+	if jobType != "" {
+		query = query.Where("job_type = ?", jobType)
+	}
+	var jobs []models.UIPathJob
+	return jobs, query.Order("created_at DESC").Limit(limit).Find(&jobs).Error
+}
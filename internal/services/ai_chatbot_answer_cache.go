@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/models"
+)
+
+// replayCachedAnswer posts a cached answer in the Slack thread of the input
+// instead of running the assistant. The question and the replies are added to
+// the backend thread, so the next runs of the conversation see them, and
+// recorded with the key of the cached answer. It returns the ID of the
+// question message.
+func (s *AIChatbotService) replayCachedAnswer(ctx context.Context, input dto.AddMessageRequest, thread *models.Thread, cached *models.CachedAnswer) (string, error) {
+	backendThreadID := thread.LLMThreadID()
+	messageID, err := s.llmBackend.CreateMessage(ctx, backendThreadID, dto.AzureAIMessageInput{Text: input.Message})
+	if err != nil {
+		return "", err
+	}
+	err = s.messageService.CreateMessage(&models.Message{
+		ID:              messageID,
+		ThreadID:        thread.ID,
+		Role:            models.MessageRoleUser,
+		Content:         input.Message,
+		SlackUserID:     input.UserID,
+		SlackTs:         input.SlackTs,
+		CachedAnswerKey: cached.Key,
+	})
+	if err != nil {
+		return "", err
+	}
+	for _, reply := range cached.Replies {
+		replyID, err := s.llmBackend.CreateMessage(ctx, backendThreadID, dto.AzureAIMessageInput{Role: models.MessageRoleAssistant, Text: reply})
+		if err != nil {
+			return "", err
+		}
+		slackTs, err := s.slackService.PostAttachmentMessage(ctx, input.ChannelID, input.SlackThreadTs, reply)
+		if err != nil {
+			return "", err
+		}
+		err = s.messageService.CreateMessage(&models.Message{
+			ID:              replyID,
+			ThreadID:        thread.ID,
+			Role:            models.MessageRoleAssistant,
+			Content:         reply,
+			SlackTs:         slackTs,
+			CachedAnswerKey: cached.Key,
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	if cached.Sources != "" {
+		err = s.slackService.SendMessage(ctx, input.ChannelID, input.SlackThreadTs, cached.Sources)
+		if err != nil {
+			return "", err
+		}
+	}
+	return messageID, s.answerCache.RecordHit(cached.Key)
+}
+
+// EvictCachedAnswer drops the cached answer a reply of the bot was replayed
+// from, e.g. after a thumbs down, so the question gets a fresh answer next
+// time. It reports false when the Slack message is not a replayed answer.
+func (s *AIChatbotService) EvictCachedAnswer(channelID string, slackTs string) (bool, error) {
+	if s.answerCache == nil {
+		return false, nil
+	}
+	messages, err := s.messageService.GetMessagesBySlackTs(slackTs)
+	if err != nil {
+		return false, err
+	}
+	for _, message := range messages {
+		if message.Role != models.MessageRoleAssistant || message.CachedAnswerKey == "" {
+			continue
+		}
+		thread, err := s.threadService.GetThreadByID(message.ThreadID)
+		if err != nil {
+			return false, err
+		}
+		if thread.ChannelId == channelID {
+			return s.answerCache.Evict(message.CachedAnswerKey)
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/mocks"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddAndRunMessageAnswerCache(t *testing.T) {
+	takeLeave := dto.AzureAIToolCall{ID: "call_1", Type: "function"}
+	takeLeave.Function.Name = dto.ToolTakeLeave
+	takeLeave.Function.Arguments = "{}"
+
+	tests := []struct {
+		name       string
+		first      mocks.FakeLLMRun
+		second     string
+		wantRuns   int
+		wantPosted []string
+		wantCached bool
+	}{
+		{
+			name:       "same question is replayed",
+			first:      mocks.FakeLLMRun{Statuses: []string{RunStatusCompleted}, Replies: []string{"You have 12 leave days."}},
+			second:     "how many leave days do I have",
+			wantRuns:   1,
+			wantPosted: []string{"You have 12 leave days.", "You have 12 leave days."},
+			wantCached: true,
+		},
+		{
+			name:       "other question runs the assistant",
+			first:      mocks.FakeLLMRun{Statuses: []string{RunStatusCompleted}, Replies: []string{"You have 12 leave days."}},
+			second:     "How many sick days do I have?",
+			wantRuns:   2,
+			wantPosted: []string{"You have 12 leave days.", "Sure."},
+		},
+		{
+			name: "answers calling tools are not cached",
+			first: mocks.FakeLLMRun{
+				Statuses:  []string{RunStatusRequiresAction, RunStatusCompleted},
+				ToolCalls: []dto.AzureAIToolCall{takeLeave},
+				Replies:   []string{"I prepared the leave request form."},
+			},
+			second:     "How many leave days do I have?",
+			wantRuns:   2,
+			wantPosted: []string{"I prepared the leave request form.", "Sure."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newChatbotFixture(t, tt.first, mocks.FakeLLMRun{Statuses: []string{RunStatusCompleted}, Replies: []string{"Sure."}})
+			channelID := "C1"
+			for i, question := range []string{"How many leave days do I have?", tt.second} {
+				threadTs := fmt.Sprintf("1700000000.%06d", (i+1)*100)
+				_, _, err := f.service.AddAndRunMessage(context.Background(), dto.AddMessageRequest{
+					ChannelID:     &channelID,
+					Message:       question,
+					UserID:        fmt.Sprintf("U%d", i+1),
+					SlackTs:       threadTs,
+					SlackThreadTs: threadTs,
+				})
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.wantPosted, f.postedTexts())
+			assert.Len(t, f.azure.Backend.RunRequests, tt.wantRuns)
+			thread, err := f.threads.GetThreadBySlackThread("C1", "1700000000.000200")
+			assert.NoError(t, err)
+			messages, _ := f.messages.GetMessagesByThreadID(thread.ID)
+			assert.Len(t, messages, 2)
+			for _, message := range messages {
+				assert.Equal(t, tt.wantCached, message.CachedAnswerKey != "", message.Content)
+			}
+			if !tt.wantCached {
+				return
+			}
+			// The replayed answer is in the backend thread for the next runs.
+			backendMessages, err := f.azure.Backend.ListMessages(context.Background(), thread.LLMThreadID())
+			assert.NoError(t, err)
+			assert.Equal(t, []string{models.MessageRoleAssistant, models.MessageRoleUser}, []string{backendMessages[0].Role, backendMessages[1].Role})
+			assert.Equal(t, int64(1), f.answers.Answers[messages[0].CachedAnswerKey].Hits)
+
+			// A thumbs down on the replayed answer evicts it.
+			evicted, err := f.service.EvictCachedAnswer("C1", messages[1].SlackTs)
+			assert.NoError(t, err)
+			assert.True(t, evicted)
+			assert.Empty(t, f.answers.Answers)
+		})
+	}
+}
+
+func TestAddAndRunMessageAnswerCacheFollowUp(t *testing.T) {
+	f := newChatbotFixture(t,
+		mocks.FakeLLMRun{Statuses: []string{RunStatusCompleted}, Replies: []string{"You have 12 leave days."}},
+		mocks.FakeLLMRun{Statuses: []string{RunStatusCompleted}, Replies: []string{"And 5 sick days."}},
+	)
+	channelID := "C1"
+	for i, question := range []string{"How many leave days do I have?", "What else?"} {
+		_, _, err := f.service.AddAndRunMessage(context.Background(), dto.AddMessageRequest{
+			ChannelID:     &channelID,
+			Message:       question,
+			UserID:        "U1",
+			SlackTs:       fmt.Sprintf("1700000000.%06d", (i+1)*100),
+			SlackThreadTs: "1700000000.000100",
+		})
+		assert.NoError(t, err)
+	}
+
+	// Only the question starting the conversation is cached.
+	assert.Len(t, f.answers.Answers, 1)
+	for _, answer := range f.answers.Answers {
+		assert.Equal(t, "how many leave days do i have", answer.Question)
+		assert.Equal(t, []string{"You have 12 leave days."}, answer.Replies)
+	}
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/config"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+)
+
+// DetectActions sends a message to the default assistant on a new thread and
+// returns the actions it asks for, following the handoffs between assistants
+// like AddAndRunMessage but without Slack nor storage. It serves the offline
+// evaluation of intent detection.
+func (s *AIChatbotService) DetectActions(ctx context.Context, message string) ([]dto.AIToolCall, error) {
+	threadID, err := s.llmBackend.CreateThread(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.llmBackend.CreateMessage(ctx, threadID, dto.AzureAIMessageInput{Text: message})
+	if err != nil {
+		return nil, err
+	}
+	runStep := func(assistant config.AssistantDefinition) (*dto.AzureAIRun, []dto.AIToolCall, error) {
+		run, err := s.llmBackend.CreateRun(ctx, threadID, s.assistantRouter.RunRequest(assistant))
+		if err != nil {
+			return nil, nil, err
+		}
+		return s.waitForRun(ctx, threadID, run.ID)
+	}
+	run, toolCalls, err := s.runWithHandoffs(s.assistantRouter.Assistant(""), runStep, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = unfinishedRunError(run)
+	if err != nil {
+		return nil, err
+	}
+	return toolCalls, nil
+}
+
+// SetPollInterval sets how often a run is checked until it is over, every 3
+// seconds by default.
+func (s *AIChatbotService) SetPollInterval(pollInterval time.Duration) {
+	s.pollInterval = pollInterval
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectActions(t *testing.T) {
+	routeCall := dto.AzureAIToolCall{ID: "call_route", Type: "function"}
+	routeCall.Function.Name = dto.ToolRouteToAssistant
+	routeCall.Function.Arguments = `{"intent":"hr_policy_question"}`
+	takeLeaveCall := dto.AzureAIToolCall{ID: "call_leave", Type: "function"}
+	takeLeaveCall.Function.Name = dto.ToolTakeLeave
+	takeLeaveCall.Function.Arguments = `{"request_date_from":"2024-05-02"}`
+
+	tests := []struct {
+		name               string
+		runs               []mocks.FakeLLMRun
+		expectedActions    []string
+		expectedAssistants []string
+		expectedErr        bool
+	}{
+		{
+			name:               "answer without action",
+			runs:               []mocks.FakeLLMRun{{Replies: []string{"Hello!"}}},
+			expectedActions:    []string{},
+			expectedAssistants: []string{"asst_intent"},
+		},
+		{
+			name: "action after a handoff",
+			runs: []mocks.FakeLLMRun{
+				{Statuses: []string{RunStatusRequiresAction, RunStatusCompleted}, ToolCalls: []dto.AzureAIToolCall{routeCall}},
+				{Statuses: []string{RunStatusRequiresAction, RunStatusCompleted}, ToolCalls: []dto.AzureAIToolCall{takeLeaveCall}},
+			},
+			expectedActions:    []string{dto.ToolTakeLeave},
+			expectedAssistants: []string{"asst_intent", "asst_hr"},
+		},
+		{
+			name:               "failed run",
+			runs:               []mocks.FakeLLMRun{{Statuses: []string{RunStatusFailed}}},
+			expectedAssistants: []string{"asst_intent"},
+			expectedErr:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, err := NewAssistantRouter(testAssistantsConfig)
+			assert.NoError(t, err)
+			backend := mocks.NewFakeLLMBackend(tt.runs...)
+			service := &AIChatbotService{
+				llmBackend:      backend,
+				assistantRouter: router,
+				pollInterval:    time.Millisecond,
+				runTimeout:      time.Second,
+			}
+
+			toolCalls, err := service.DetectActions(context.Background(), "I want to take leave on May 2")
+
+			assistants := []string{}
+			for _, request := range backend.RunRequests {
+				assistants = append(assistants, request.AssistantID)
+			}
+			assert.Equal(t, tt.expectedAssistants, assistants)
+			if tt.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedActions, toolCallNames(toolCalls))
+		})
+	}
+}
+
+func toolCallNames(toolCalls []dto.AIToolCall) []string {
+	names := []string{}
+	for _, toolCall := range toolCalls {
+		names = append(names, toolCall.Name)
+	}
+	return names
+}
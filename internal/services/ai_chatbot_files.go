@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+)
+
+// renderMessage turns an assistant message into the text posted to Slack.
+// File citation markers such as 【4:0†source】 become numbered footnotes
+// naming the cited file, links to generated files are replaced by the file
+// name. The IDs of the files generated by the assistant, images included, are
+// returned so they can be uploaded next to the reply.
+func (s *AIChatbotService) renderMessage(ctx context.Context, message dto.AzureAIChatbotMessage) (string, []string) {
+	fileNames := map[string]string{}
+	citations := map[string]int{}
+	footnotes := []string{}
+	texts := []string{}
+	fileIDs := []string{}
+	for _, content := range message.Content {
+		switch content.Type {
+		case "image_file":
+			if content.ImageFile.FileID != "" {
+				fileIDs = append(fileIDs, content.ImageFile.FileID)
+			}
+		case "text":
+			text := content.Text.Value
+			for _, annotation := range content.Text.Annotations {
+				switch annotation.Type {
+				case "file_citation":
+					fileID := annotation.FileCitation.FileID
+					number, ok := citations[fileID]
+					if !ok {
+						number = len(citations) + 1
+						citations[fileID] = number
+						footnotes = append(footnotes, fmt.Sprintf("[%d] %s", number, s.fileName(ctx, fileID, fileNames)))
+					}
+					text = strings.Replace(text, annotation.Text, fmt.Sprintf("[%d]", number), 1)
+				case "file_path":
+					fileID := annotation.FilePath.FileID
+					text = strings.Replace(text, annotation.Text, s.fileName(ctx, fileID, fileNames), 1)
+					fileIDs = append(fileIDs, fileID)
+				}
+			}
+			if text != "" {
+				texts = append(texts, text)
+			}
+		}
+	}
+	text := strings.Join(texts, "\n\n")
+	if len(footnotes) > 0 {
+		text += "\n\n" + strings.Join(footnotes, "\n")
+	}
+	return text, fileIDs
+}
+
+// fileName returns the name of an assistant file, falling back to its ID when
+// the file information cannot be read so a reply is never lost to it.
+func (s *AIChatbotService) fileName(ctx context.Context, fileID string, fileNames map[string]string) string {
+	if name, ok := fileNames[fileID]; ok {
+		return name
+	}
+	name := fileID
+	information, err := s.llmBackend.GetFileInformation(ctx, fileID)
+	if err == nil {
+		if filename, ok := information["filename"].(string); ok && filename != "" {
+			name = path.Base(filename)
+		}
+	}
+	fileNames[fileID] = name
+	return name
+}
+
+// uploadGeneratedFiles downloads files generated by the assistant and uploads
+// them to the Slack conversation.
+func (s *AIChatbotService) uploadGeneratedFiles(ctx context.Context, channelID *string, threadTs string, fileIDs []string) error {
+	fileNames := map[string]string{}
+	for _, fileID := range fileIDs {
+		content, err := s.llmBackend.GetFileContent(ctx, fileID)
+		if err != nil {
+			return fmt.Errorf("error downloading file %s: %w", fileID, err)
+		}
+		err = s.slackService.UploadFile(ctx, channelID, threadTs, s.fileName(ctx, fileID, fileNames), content)
+		if err != nil {
+			return fmt.Errorf("error uploading file %s: %w", fileID, err)
+		}
+	}
+	return nil
+}
+
+// fileSearchExtensions are the documents read through file_search, any other
+// attachment is given to code_interpreter.
+var fileSearchExtensions = map[string]bool{
+	".pdf":  true,
+	".doc":  true,
+	".docx": true,
+	".pptx": true,
+	".txt":  true,
+	".md":   true,
+	".html": true,
+}
+
+var imageMimeTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// attachSlackFiles downloads the files shared with a Slack message and
+// uploads them to the assistant. Images become content parts of the message,
+// the other files are attached for code_interpreter or file_search.
+func (s *AIChatbotService) attachSlackFiles(ctx context.Context, message *dto.AzureAIMessageInput, files []dto.MessageFile) error {
+	for _, file := range files {
+		content, err := s.slackService.DownloadFile(ctx, file.DownloadURL)
+		if err != nil {
+			return fmt.Errorf("error downloading slack file %s: %w", file.Name, err)
+		}
+		fileID, err := s.llmBackend.UploadFile(ctx, file.Name, content)
+		if err != nil {
+			return fmt.Errorf("error uploading slack file %s: %w", file.Name, err)
+		}
+		if imageMimeTypes[file.MimeType] {
+			message.ImageFileIDs = append(message.ImageFileIDs, fileID)
+			continue
+		}
+		tool := "code_interpreter"
+		if fileSearchExtensions[strings.ToLower(path.Ext(file.Name))] {
+			tool = "file_search"
+		}
+		message.Attachments = append(message.Attachments, dto.AzureAIMessageAttachment{
+			FileID: fileID,
+			Tools:  []dto.AzureAITool{{Type: tool}},
+		})
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/config"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+)
+
+// RunAssistant runs the named assistant once on a new thread and returns its
+// answer to prompt. It serves the steps of a flow answered by an assistant
+// outside of any Slack conversation, like mapping sheet columns. The run is
+// billed to the Slack user of the flow, the thread is deleted once answered.
+func (s *AIChatbotService) RunAssistant(ctx context.Context, name string, prompt string, slackUserID string, channelID string) (answer string, err error) {
+	assistant, ok := s.assistantRouter.NamedAssistant(name)
+	if !ok {
+		return "", fmt.Errorf("unknown assistant %s", name)
+	}
+	threadID, err := s.llmBackend.CreateThread(ctx)
+	if err != nil {
+		return "", err
+	}
+	if backend, ok := s.llmBackend.(ThreadDeletingLLMBackend); ok {
+		defer func() {
+			if deleteErr := backend.DeleteThread(ctx, threadID); deleteErr != nil && err == nil {
+				err = deleteErr
+			}
+		}()
+	}
+	_, err = s.llmBackend.CreateMessage(ctx, threadID, dto.AzureAIMessageInput{Text: prompt})
+	if err != nil {
+		return "", err
+	}
+	run, answer, err := s.completeRun(ctx, threadID, s.assistantRouter.RunRequest(assistant))
+	if run != nil {
+		if usageErr := s.usageService.RecordRunUsage(run, threadID, slackUserID, channelID); usageErr != nil && err == nil {
+			err = usageErr
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("assistant %s: %w", name, err)
+	}
+	return answer, nil
+}
+
+// completeRun runs request on the thread until it completes and returns the
+// text of the replies.
+func (s *AIChatbotService) completeRun(ctx context.Context, threadID string, request dto.AzureAIRunRequest) (*dto.AzureAIRun, string, error) {
+	run, err := s.llmBackend.CreateRun(ctx, threadID, request)
+	if err != nil {
+		return nil, "", err
+	}
+	run, _, err = s.waitForRun(ctx, threadID, run.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	if run.Status != RunStatusCompleted {
+		return run, "", fmt.Errorf("run %s %s", run.ID, run.Status)
+	}
+
+	messages, err := s.llmBackend.ListMessages(ctx, threadID)
+	if err != nil {
+		return run, "", err
+	}
+	answers := []string{}
+	replies := s.GetFirstConsecutiveAssistantMessages(messages)
+	for i := len(replies) - 1; i >= 0; i-- {
+		for _, content := range replies[i].Content {
+			if content.Type == "text" {
+				answers = append(answers, content.Text.Value)
+			}
+		}
+	}
+	return run, strings.Join(answers, "\n"), nil
+}
+
+// SuggestColumnMappings asks the header mapping assistant which source column
+// holds the data of each target column. The mappings are keyed by target
+// header; target headers without a matching source header are left out. The
+// run is billed to the Slack user asking for the mapping.
+func (s *AIChatbotService) SuggestColumnMappings(ctx context.Context, sourceHeaders []string, targetHeaders []string, slackUserID string, channelID string) (map[string]string, error) {
+	prompt, err := json.Marshal(map[string][]string{
+		"source_headers": sourceHeaders,
+		"target_headers": targetHeaders,
+	})
+	if err != nil {
+		return nil, err
+	}
+	answer, err := s.RunAssistant(ctx, config.AssistantHeaderMapping, string(prompt), slackUserID, channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The answer may come wrapped in a markdown code block.
+	answer = strings.TrimSpace(answer)
+	if start, end := strings.Index(answer, "{"), strings.LastIndex(answer, "}"); start >= 0 && end > start {
+		answer = answer[start : end+1]
+	}
+	suggested := map[string]string{}
+	err = json.Unmarshal([]byte(answer), &suggested)
+	if err != nil {
+		return nil, fmt.Errorf("invalid column mappings %q: %w", answer, err)
+	}
+
+	sources := map[string]bool{}
+	for _, header := range sourceHeaders {
+		sources[header] = true
+	}
+	mappings := map[string]string{}
+	for _, target := range targetHeaders {
+		if source, ok := suggested[target]; ok && sources[source] {
+			mappings[target] = source
+		}
+	}
+	return mappings, nil
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/models"
+)
+
+const (
+	threadSummaryInstructions = "Summarize the conversation so far for the assistant who will continue it: " +
+		"what the user asked, the facts and decisions, the actions done or still pending and the open questions. " +
+		"Keep placeholders such as [EMAIL_1] as they are. Answer with the summary only."
+	threadSummaryPrompt   = "Summarize our conversation so far."
+	threadSummaryPreamble = "Summary of our conversation so far, continue from it:\n\n"
+)
+
+// RolloverThread moves a conversation to a new backend thread seeded with a
+// summary of the current one, so long conversations do not slow the runs
+// down. The thread keeps its ID and history; the previous backend thread is
+// recorded with the rollover. Concurrent rollovers of a thread, from
+// /chatbot-reset and from a message, only move it once: the others drop
+// their new backend thread and pick up the one of the first.
+func (s *AIChatbotService) RolloverThread(ctx context.Context, thread *models.Thread, reason string) error {
+	fromBackendThreadID := thread.LLMThreadID()
+	_, err := s.llmBackend.CreateMessage(ctx, fromBackendThreadID, dto.AzureAIMessageInput{Text: threadSummaryPrompt})
+	if err != nil {
+		return err
+	}
+	request := s.assistantRouter.RunRequest(s.assistantRouter.Assistant(thread.AssistantName))
+	request.Instructions = threadSummaryInstructions
+	request.ToolChoice = "none"
+	run, summary, err := s.completeRun(ctx, fromBackendThreadID, request)
+	if run != nil {
+		if usageErr := s.usageService.RecordRunUsage(run, thread.ID, thread.SlackUserId, thread.ChannelId); usageErr != nil && err == nil {
+			err = usageErr
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("cannot summarize thread %s: %w", thread.ID, err)
+	}
+	if strings.TrimSpace(summary) == "" {
+		return fmt.Errorf("cannot summarize thread %s: empty summary", thread.ID)
+	}
+
+	toBackendThreadID, err := s.llmBackend.CreateThread(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = s.llmBackend.CreateMessage(ctx, toBackendThreadID, dto.AzureAIMessageInput{Text: threadSummaryPreamble + summary})
+	if err != nil {
+		return err
+	}
+	contextTokens, rolledOver, err := s.threadService.RolloverThread(&models.ThreadRollover{
+		ThreadID:            thread.ID,
+		FromBackendThreadID: fromBackendThreadID,
+		ToBackendThreadID:   toBackendThreadID,
+		Reason:              reason,
+		Summary:             summary,
+		ContextTokens:       thread.ContextTokens,
+	})
+	if err != nil {
+		return err
+	}
+	if !rolledOver {
+		if backend, ok := s.llmBackend.(ThreadDeletingLLMBackend); ok {
+			if err := backend.DeleteThread(ctx, toBackendThreadID); err != nil {
+				return err
+			}
+		}
+		current, err := s.threadService.GetThreadByID(thread.ID)
+		if err != nil {
+			return err
+		}
+		thread.BackendThreadID = current.BackendThreadID
+		thread.ContextTokens = current.ContextTokens
+		return nil
+	}
+	thread.BackendThreadID = toBackendThreadID
+	thread.ContextTokens = contextTokens
+	return nil
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/config"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/mocks"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/models"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeThreadRepository records rollovers, the other methods are not used.
+type fakeThreadRepository struct {
+	repository.ThreadRepositoryInterface
+	rollovers     []models.ThreadRollover
+	contextTokens int64
+}
+
+func (f *fakeThreadRepository) RolloverThread(rollover *models.ThreadRollover, contextTokens int64) (bool, error) {
+	f.rollovers = append(f.rollovers, *rollover)
+	f.contextTokens = contextTokens
+	return true, nil
+}
+
+func TestRolloverThread(t *testing.T) {
+	tests := []struct {
+		name        string
+		run         mocks.FakeLLMRun
+		expectedErr bool
+	}{
+		{
+			name: "summary seeds a new thread",
+			run:  mocks.FakeLLMRun{Replies: []string{"The user takes a leave on May 2, the form is pending."}},
+		},
+		{
+			name:        "failed summary keeps the thread",
+			run:         mocks.FakeLLMRun{Statuses: []string{RunStatusFailed}},
+			expectedErr: true,
+		},
+		{
+			name:        "empty summary keeps the thread",
+			run:         mocks.FakeLLMRun{Replies: []string{" "}},
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, err := NewAssistantRouter(testAssistantsConfig)
+			assert.NoError(t, err)
+			backend := mocks.NewFakeLLMBackend(tt.run)
+			threadRepo := &fakeThreadRepository{}
+			service := &AIChatbotService{
+				llmBackend:      backend,
+				assistantRouter: router,
+				threadService:   NewThreadService(threadRepo, config.ThreadConfig{}),
+				usageService:    NewUsageService(&fakeRunUsageRepository{}, config.UsageConfig{}),
+				pollInterval:    time.Millisecond,
+				runTimeout:      time.Second,
+			}
+			oldBackendThreadID, err := backend.CreateThread(context.Background())
+			assert.NoError(t, err)
+			thread := &models.Thread{ID: "thread_1", BackendThreadID: oldBackendThreadID, ContextTokens: 70000}
+
+			err = service.RolloverThread(context.Background(), thread, models.ThreadRolloverReasonManual)
+
+			if tt.expectedErr {
+				assert.Error(t, err)
+				assert.Equal(t, oldBackendThreadID, thread.BackendThreadID)
+				assert.Empty(t, threadRepo.rollovers)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "none", backend.RunRequests[0].ToolChoice)
+			assert.Equal(t, threadSummaryInstructions, backend.RunRequests[0].Instructions)
+			assert.NotEqual(t, oldBackendThreadID, thread.BackendThreadID)
+			assert.Equal(t, "thread_1", thread.ID)
+			assert.Equal(t, threadRepo.contextTokens, thread.ContextTokens)
+			assert.Equal(t, []models.ThreadRollover{{
+				ThreadID:            "thread_1",
+				FromBackendThreadID: oldBackendThreadID,
+				ToBackendThreadID:   thread.BackendThreadID,
+				Reason:              models.ThreadRolloverReasonManual,
+				Summary:             "The user takes a leave on May 2, the form is pending.",
+				ContextTokens:       70000,
+			}}, threadRepo.rollovers)
+			seeded := backend.Messages(thread.BackendThreadID)
+			assert.Len(t, seeded, 1)
+			assert.Equal(t, threadSummaryPreamble+"The user takes a leave on May 2, the form is pending.", seeded[0].Content[0].Text.Value)
+		})
+	}
+}
+
+func TestRolloverThreadRolledOverMeanwhile(t *testing.T) {
+	router, err := NewAssistantRouter(testAssistantsConfig)
+	assert.NoError(t, err)
+	backend := mocks.NewFakeLLMBackend(mocks.FakeLLMRun{Replies: []string{"The user takes a leave on May 2."}})
+	threads := mocks.NewFakeThreadRepository()
+	service := &AIChatbotService{
+		llmBackend:      backend,
+		assistantRouter: router,
+		threadService:   NewThreadService(threads, config.ThreadConfig{}),
+		usageService:    NewUsageService(&fakeRunUsageRepository{}, config.UsageConfig{}),
+		pollInterval:    time.Millisecond,
+		runTimeout:      time.Second,
+	}
+	oldBackendThreadID, err := backend.CreateThread(context.Background())
+	assert.NoError(t, err)
+	// The message rolled the thread over while /chatbot-reset summarized it.
+	threads.Threads["thread_1"] = &models.Thread{ID: "thread_1", BackendThreadID: "thread_rolled", ContextTokens: 300}
+	thread := &models.Thread{ID: "thread_1", BackendThreadID: oldBackendThreadID, ContextTokens: 70000}
+
+	err = service.RolloverThread(context.Background(), thread, models.ThreadRolloverReasonManual)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "thread_rolled", thread.BackendThreadID)
+	assert.Equal(t, int64(300), thread.ContextTokens)
+	assert.Equal(t, "thread_rolled", threads.Threads["thread_1"].BackendThreadID)
+	assert.Empty(t, threads.Rollovers)
+	assert.Len(t, backend.DeletedThreads, 1)
+	assert.NotEqual(t, oldBackendThreadID, backend.DeletedThreads[0])
+}
@@ -1,382 +1,618 @@
 package services
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/sotatek-dev/hyper-automation-chatbot/internal/config"
 	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/i18n"
 	"github.com/sotatek-dev/hyper-automation-chatbot/internal/models"
-	"github.com/sotatek-dev/hyper-automation-chatbot/pkg/util"
 	"gorm.io/gorm"
 )
 
+const (
+	RunStatusQueued         = "queued"
+	RunStatusInProgress     = "in_progress"
+	RunStatusRequiresAction = "requires_action"
+	RunStatusCancelling     = "cancelling"
+	RunStatusCancelled      = "cancelled"
+	RunStatusFailed         = "failed"
+	RunStatusCompleted      = "completed"
+	RunStatusExpired        = "expired"
+)
+
+// runCancelTimeout bounds the request cancelling a run that timed out.
+const runCancelTimeout = 30 * time.Second
+
 type AIChatbotService struct {
+	llmBackend        LLMBackend
 	azureOpenAIConfig config.AzureOpenAIConfig
 	slackService      *SlackService
 	threadService     *ThreadService
 	messageService    *MessageService
+	usageService      *UsageService
+	assistantRouter   *AssistantRouter
+	knowledgeService  *KnowledgeService
+	redactionService  *PIIRedactionService
+	answerCache       *AnswerCacheService
+	pollInterval      time.Duration
+	runTimeout        time.Duration
+	// streamUpdateInterval throttles the chat.update calls of a streamed reply.
+	streamUpdateInterval time.Duration
 }
 
-func NewAIChatbotService(azureOpenAIConfig config.AzureOpenAIConfig, slackService *SlackService, threadService *ThreadService, messageService *MessageService) *AIChatbotService {
-	return &AIChatbotService{azureOpenAIConfig: azureOpenAIConfig, slackService: slackService, threadService: threadService, messageService: messageService}
+func NewAIChatbotService(llmBackend LLMBackend, azureOpenAIConfig config.AzureOpenAIConfig, slackService *SlackService, threadService *ThreadService, messageService *MessageService, usageService *UsageService, assistantRouter *AssistantRouter, knowledgeService *KnowledgeService, redactionService *PIIRedactionService, answerCache *AnswerCacheService) *AIChatbotService {
+	return &AIChatbotService{
+		llmBackend:           llmBackend,
+		azureOpenAIConfig:    azureOpenAIConfig,
+		slackService:         slackService,
+		threadService:        threadService,
+		messageService:       messageService,
+		usageService:         usageService,
+		assistantRouter:      assistantRouter,
+		knowledgeService:     knowledgeService,
+		redactionService:     redactionService,
+		answerCache:          answerCache,
+		pollInterval:         3 * time.Second,
+		runTimeout:           5 * time.Minute,
+		streamUpdateInterval: time.Second,
+	}
 }
 
-func (s *AIChatbotService) CreateThread(ctx context.Context) (string, error) {
-	client := &http.Client{}
-	req, err := http.NewRequest("POST", s.getUrl("threads"), nil)
+func (s *AIChatbotService) AddAndRunMessage(ctx context.Context, input dto.AddMessageRequest) (string, []dto.AIToolCall, error) {
+	channelID := input.ChannelID
+	threadTs := input.SlackThreadTs
+	exceeded, err := s.usageService.IsDailyQuotaExceeded(input.UserID)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
-	s.addHeader(req, true)
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", err
+	var thread *models.Thread
+	newThread := false
+	if threadTs != "" {
+		thread, err = s.threadService.GetThreadBySlackThread(*channelID, threadTs)
+	} else {
+		thread, err = s.threadService.GetLatestOpenThreadByChannelAndUserID(*channelID, input.UserID)
 	}
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return "", nil, err
 	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
+	// The conversation of a Slack thread closed once idle, the next reply in
+	// the Slack thread starts a fresh one.
+	if err == gorm.ErrRecordNotFound || thread.Status == models.ThreadStatusClosed {
+		thread = nil
 	}
-	thread := struct {
-		ID string `json:"id"`
-	}{}
-	err = json.Unmarshal(body, &thread)
-	if err != nil {
-		return "", err
+	if exceeded {
+		messageID, err := s.refuseOverQuota(ctx, input, thread)
+		return messageID, nil, err
 	}
-	return thread.ID, nil
-}
-
-func (s *AIChatbotService) CreateMessage(ctx context.Context, threadID string, message string) (string, error) {
-	client := &http.Client{}
-	requestBody := struct {
-		Role    string `json:"role"`
-		Content string `json:"content"`
-	}{
-		Role:    "user",
-		Content: message,
-	}
-	requestBodyBytes, err := json.Marshal(requestBody)
-	if err != nil {
-		return "", err
+	if thread == nil {
+		backendThreadID, err := s.llmBackend.CreateThread(ctx)
+		if err != nil {
+			return "", nil, err
+		}
+		assistant := s.assistantRouter.Assistant("")
+		thread = &models.Thread{
+			ID:               backendThreadID,
+			BackendThreadID:  backendThreadID,
+			ChannelId:        *channelID,
+			SlackUserId:      input.UserID,
+			SlackThreadTs:    threadTs,
+			AssistantName:    assistant.Name,
+			AssistantVersion: assistant.Version,
+			Locale:           string(input.Locale),
+		}
+		err = s.threadService.CreateThread(thread)
+		if err != nil {
+			if deleter, ok := s.llmBackend.(ThreadDeletingLLMBackend); ok {
+				_ = deleter.DeleteThread(ctx, backendThreadID)
+			}
+			return "", nil, err
+		}
+		newThread = true
 	}
-	url := s.getUrl("threads/" + threadID + "/messages")
-	req, err := http.NewRequest(
-		"POST",
-		url,
-		bytes.NewBuffer(requestBodyBytes))
-	if err != nil {
-		return "", err
+	if !newThread {
+		_, err = s.threadService.ExtendThreadDeadline(thread.ID)
+		if err != nil {
+			return "", nil, err
+		}
+		// Threads record the version of the assistant definition answering
+		// them, definitions change when the chatbot is deployed.
+		assistant := s.assistantRouter.Assistant(thread.AssistantName)
+		if assistant.Name != thread.AssistantName || assistant.Version != thread.AssistantVersion {
+			err = s.threadService.UpdateThreadAssistant(thread.ID, assistant)
+			if err != nil {
+				return "", nil, err
+			}
+			thread.AssistantName, thread.AssistantVersion = assistant.Name, assistant.Version
+		}
+		if input.Locale != "" && string(input.Locale) != thread.Locale {
+			err = s.threadService.UpdateThreadLocale(thread.ID, input.Locale)
+			if err != nil {
+				return "", nil, err
+			}
+		}
+		if s.threadService.NeedsRollover(thread) {
+			err = s.RolloverThread(ctx, thread, models.ThreadRolloverReasonSize)
+			if err != nil {
+				return "", nil, err
+			}
+		}
 	}
-	s.addHeader(req, true)
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", err
+	threadID := thread.ID
+
+	// Personal data never reaches the LLM, the runs see placeholders instead.
+	question := input.Message
+	if s.redactionService != nil {
+		input.Message, err = s.redactionService.Redact(threadID, input.UserID, input.Message)
+		if err != nil {
+			return "", nil, err
+		}
 	}
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	// The answer to the question starting a conversation does not depend on
+	// earlier messages, it is shared with the next users asking it. Questions
+	// holding personal data or files are not cached.
+	assistant := s.assistantRouter.Assistant(thread.AssistantName)
+	cacheable := s.answerCache.Enabled() && newThread && len(input.Files) == 0 && input.Message == question
+	if cacheable {
+		cached, err := s.answerCache.Lookup(assistant, input.Message)
+		if err != nil {
+			return "", nil, err
+		}
+		if cached != nil {
+			messageID, err := s.replayCachedAnswer(ctx, input, thread, cached)
+			return messageID, nil, err
+		}
 	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
+	messageInput := dto.AzureAIMessageInput{Text: input.Message}
+	if len(input.Files) > 0 {
+		err = s.attachSlackFiles(ctx, &messageInput, input.Files)
+		if errors.Is(err, ErrLLMBackendUnsupported) {
+			messageInput.ImageFileIDs = nil
+			messageInput.Attachments = nil
+			_, err = s.slackService.PostAttachmentMessage(ctx, channelID, threadTs, i18n.T(input.Locale, "chatbot.files_unsupported"))
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		if messageInput.Text == "" {
+			fileNames := make([]string, 0, len(input.Files))
+			for _, file := range input.Files {
+				fileNames = append(fileNames, file.Name)
+			}
+			messageInput.Text = "Attached files: " + strings.Join(fileNames, ", ")
+		}
+	}
+	messageID, err := s.llmBackend.CreateMessage(ctx, thread.LLMThreadID(), messageInput)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
-	newMessage := struct {
-		ID string `json:"id"`
-	}{}
-	err = json.Unmarshal(body, &newMessage)
+	userMessage := &models.Message{
+		ID:          messageID,
+		ThreadID:    threadID,
+		Role:        models.MessageRoleUser,
+		Content:     messageInput.Text,
+		SlackUserID: input.UserID,
+		SlackTs:     input.SlackTs,
+	}
+	err = s.messageService.CreateMessage(userMessage)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
-	return newMessage.ID, nil
-}
 
-func (s *AIChatbotService) CreateRun(ctx context.Context, threadID string, assistantID string) (string, error) {
-	client := &http.Client{}
-	requestBody := struct {
-		AssistantID string `json:"assistant_id"`
-	}{
-		AssistantID: assistantID,
-	}
-	requestBodyBytes, err := json.Marshal(requestBody)
-	if err != nil {
-		return "", err
+	runs := 0
+	var answer postedAnswer
+	runStep := func(assistant config.AssistantDefinition) (*dto.AzureAIRun, []dto.AIToolCall, error) {
+		run, toolCalls, posted, err := s.runAssistant(ctx, input, thread, assistant)
+		if err == nil && userMessage.RunID == "" {
+			userMessage.RunID = run.ID
+		}
+		runs++
+		answer = posted
+		return run, toolCalls, err
 	}
-	url := s.getUrl("threads/" + threadID + "/runs")
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(requestBodyBytes))
-	if err != nil {
-		return "", err
+	onHandoff := func(next config.AssistantDefinition) error {
+		return s.threadService.UpdateThreadAssistant(threadID, next)
 	}
-	s.addHeader(req, true)
-	resp, err := client.Do(req)
+	run, toolCalls, err := s.runWithHandoffs(assistant, runStep, onHandoff)
 	if err != nil {
-		return "", err
-	}
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return "", nil, err
+	}
+	// Only plain answers are cached, not the ones calling tools, attaching
+	// files or handing the conversation over.
+	if cacheable && runs == 1 && run.Status == RunStatusCompleted && len(toolCalls) == 0 && !answer.hasFiles {
+		err = s.answerCache.Store(assistant, input.Message, answer.replies, answer.sources)
+		if err != nil {
+			return "", nil, err
+		}
 	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
+	userMessage.Action = joinToolCallNames(toolCalls)
+	err = s.messageService.UpdateMessage(userMessage)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
-	run := struct {
-		ID string `json:"id"`
-	}{}
-	err = json.Unmarshal(body, &run)
+	err = unfinishedRunError(run)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
-	return run.ID, nil
+	return messageID, toolCalls, nil
 }
 
-func (s *AIChatbotService) GetRun(ctx context.Context, threadID string, runID string) (string, error) {
-	client := &http.Client{}
-	url := s.getUrl("threads/" + threadID + "/runs/" + runID)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
+// refuseOverQuota answers a user who used up their daily quota instead of
+// running the assistant. The question never reaches the backend thread, it is
+// recorded with the answer so the transcript of the open thread shows both. No
+// thread is started for a refused conversation, nothing is recorded then. It
+// returns the ID of the question message.
+func (s *AIChatbotService) refuseOverQuota(ctx context.Context, input dto.AddMessageRequest, thread *models.Thread) (string, error) {
+	reply := i18n.T(input.Locale, "chatbot.quota_exceeded")
+	if thread == nil {
+		_, err := s.slackService.PostAttachmentMessage(ctx, input.ChannelID, input.SlackThreadTs, reply)
 		return "", err
 	}
-	s.addHeader(req, true)
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", err
-	}
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	question := input.Message
+	if s.redactionService != nil {
+		var err error
+		question, err = s.redactionService.Redact(thread.ID, input.UserID, question)
+		if err != nil {
+			return "", err
+		}
 	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
+	messageID := "msg_" + uuid.NewString()
+	err := s.messageService.CreateMessage(&models.Message{
+		ID:          messageID,
+		ThreadID:    thread.ID,
+		Role:        models.MessageRoleUser,
+		Content:     question,
+		SlackUserID: input.UserID,
+		SlackTs:     input.SlackTs,
+	})
 	if err != nil {
 		return "", err
 	}
-	run := struct {
-		Status string `json:"status"`
-	}{}
-	err = json.Unmarshal(body, &run)
+	slackTs, err := s.slackService.PostAttachmentMessage(ctx, input.ChannelID, input.SlackThreadTs, reply)
 	if err != nil {
 		return "", err
 	}
-	return run.Status, nil
+	return messageID, s.messageService.CreateMessage(&models.Message{
+		ID:       "msg_" + uuid.NewString(),
+		ThreadID: thread.ID,
+		Role:     models.MessageRoleAssistant,
+		Content:  reply,
+		SlackTs:  slackTs,
+	})
 }
 
-func (s *AIChatbotService) ListMessages(ctx context.Context, threadID string) ([]dto.AzureAIChatbotMessage, error) {
-	client := &http.Client{}
-	url := s.getUrl("threads/" + threadID + "/messages")
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-	s.addHeader(req, true)
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+// unfinishedRunError describes a run that did not complete, it is nil for a
+// completed run.
+func unfinishedRunError(run *dto.AzureAIRun) error {
+	if run.Status == RunStatusCompleted {
+		return nil
 	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	if run.LastError != nil {
+		return fmt.Errorf("run %s %s: %s", run.ID, run.Status, run.LastError.Message)
 	}
-	response := struct {
-		Data   []dto.AzureAIChatbotMessage `json:"data"`
-		Object string                      `json:"object"`
-	}{}
-	err = json.Unmarshal(body, &response)
-	if err != nil {
-		return nil, err
+	return fmt.Errorf("run %s %s", run.ID, run.Status)
+}
+
+// runWithHandoffs runs the assistant answering a user message, then the
+// assistants it hands the conversation over to, each handoff being one more
+// run on the same thread. It returns the last run and the tool calls left for
+// dispatch.
+func (s *AIChatbotService) runWithHandoffs(assistant config.AssistantDefinition, runStep func(assistant config.AssistantDefinition) (*dto.AzureAIRun, []dto.AIToolCall, error), onHandoff func(next config.AssistantDefinition) error) (*dto.AzureAIRun, []dto.AIToolCall, error) {
+	toolCalls := []dto.AIToolCall{}
+	for handoffs := 0; ; handoffs++ {
+		run, stepToolCalls, err := runStep(assistant)
+		if err != nil {
+			return nil, nil, err
+		}
+		next, calls, routed := s.assistantRouter.Route(stepToolCalls)
+		toolCalls = append(toolCalls, calls...)
+		if !routed || run.Status != RunStatusCompleted || handoffs >= maxAssistantHandoffs {
+			return run, toolCalls, nil
+		}
+		if onHandoff != nil {
+			err = onHandoff(next)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		assistant = next
 	}
-	return response.Data, nil
 }
 
-func (s *AIChatbotService) GetFileContent(ctx context.Context, fileID string) ([]byte, error) {
-	client := &http.Client{}
-	url := s.getUrl("files/" + fileID + "/content")
-	req, err := http.NewRequest("GET", url, nil)
+// postedAnswer is what a run posted in Slack: the replies of the assistant and
+// the sources they cite.
+type postedAnswer struct {
+	replies  []string
+	sources  string
+	hasFiles bool
+}
+
+// runAssistant runs the assistant on the thread, posting its replies in the
+// Slack thread of the input. The replies and the usage of the run are
+// recorded, the tool calls are returned for dispatch with the posted answer.
+// Knowledge assistants are given the policy passages matching the input, the
+// sections they cite are linked after their replies. The redacted values are
+// restored in the replies posted to Slack and in the tool calls.
+func (s *AIChatbotService) runAssistant(ctx context.Context, input dto.AddMessageRequest, thread *models.Thread, assistant config.AssistantDefinition) (*dto.AzureAIRun, []dto.AIToolCall, postedAnswer, error) {
+	channelID := input.ChannelID
+	threadTs := input.SlackThreadTs
+	threadID := thread.ID
+	backendThreadID := thread.LLMThreadID()
+	request := s.assistantRouter.RunRequest(assistant)
+	var restorer *PIIRestorer
+	var err error
+	if s.redactionService != nil {
+		restorer, err = s.redactionService.Restorer(threadID)
+		if err != nil {
+			return nil, nil, postedAnswer{}, err
+		}
+	}
+	poster := restoringReplyPoster{slackReplyPoster: s.slackService, restorer: restorer}
+	var passages []models.DocumentChunk
+	if assistant.Knowledge && s.knowledgeService != nil {
+		passages, err = s.knowledgeService.Search(ctx, input.Message)
+		if err != nil {
+			return nil, nil, postedAnswer{}, err
+		}
+		request.AdditionalInstructions = knowledgeInstructions(passages)
+	}
+	var run *dto.AzureAIRun
+	var toolCalls []dto.AIToolCall
+	var replies []assistantReply
+	if backend, ok := s.llmBackend.(StreamingLLMBackend); ok && backend.SupportsStreaming() {
+		run, toolCalls, replies, err = s.streamRun(ctx, backend, poster, channelID, threadTs, backendThreadID, request)
+	} else {
+		run, toolCalls, replies, err = s.pollRun(ctx, poster, channelID, threadTs, backendThreadID, request)
+	}
 	if err != nil {
-		return nil, err
+		return nil, nil, postedAnswer{}, err
 	}
-	s.addHeader(req, false)
-	resp, err := client.Do(req)
+	err = s.usageService.RecordRunUsage(run, threadID, input.UserID, *channelID)
 	if err != nil {
-		return nil, err
+		return nil, nil, postedAnswer{}, err
+	}
+	// The prompt of the run held the whole backend thread, its usage is the
+	// best estimate of the thread size.
+	if run.Usage != nil && run.Usage.TotalTokens > 0 {
+		thread.ContextTokens = run.Usage.TotalTokens
+		err = s.threadService.UpdateThreadContextTokens(threadID, thread.ContextTokens)
+		if err != nil {
+			return nil, nil, postedAnswer{}, err
+		}
 	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	action := joinToolCallNames(toolCalls)
+	answer := postedAnswer{}
+	for _, reply := range replies {
+		err = s.messageService.CreateMessage(&models.Message{
+			ID:       reply.messageID,
+			ThreadID: threadID,
+			Role:     models.MessageRoleAssistant,
+			Content:  reply.text,
+			SlackTs:  reply.slackTs,
+			RunID:    run.ID,
+			Action:   action,
+		})
+		if err != nil {
+			return nil, nil, postedAnswer{}, err
+		}
+		err = s.uploadGeneratedFiles(ctx, channelID, threadTs, reply.fileIDs)
+		if err != nil {
+			return nil, nil, postedAnswer{}, err
+		}
+		if reply.text != "" {
+			answer.replies = append(answer.replies, reply.text)
+		}
+		answer.hasFiles = answer.hasFiles || len(reply.fileIDs) > 0
 	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	if len(passages) > 0 {
+		texts := make([]string, 0, len(replies))
+		for _, reply := range replies {
+			texts = append(texts, reply.text)
+		}
+		answer.sources = knowledgeSources(passages, texts, input.Locale)
+		if answer.sources != "" {
+			err = s.slackService.SendMessage(ctx, channelID, threadTs, answer.sources)
+			if err != nil {
+				return nil, nil, postedAnswer{}, err
+			}
+		}
 	}
-
-	return body, nil
+	return run, restorer.RestoreToolCalls(toolCalls), answer, nil
 }
 
-func (s *AIChatbotService) GetFileInformation(ctx context.Context, fileID string) (map[string]interface{}, error) {
-	url := s.getUrl("files/" + fileID)
-	req, err := http.NewRequest("GET", url, nil)
+// pollRun creates the run, polls it until it is over and then posts the
+// assistant replies to Slack in one go.
+func (s *AIChatbotService) pollRun(ctx context.Context, poster slackReplyPoster, channelID *string, threadTs string, threadID string, request dto.AzureAIRunRequest) (*dto.AzureAIRun, []dto.AIToolCall, []assistantReply, error) {
+	run, err := s.llmBackend.CreateRun(ctx, threadID, request)
 	if err != nil {
-		return nil, fmt.Errorf("error creating request: %w", err)
+		return nil, nil, nil, err
 	}
-
-	s.addHeader(req, false)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	run, toolCalls, err := s.waitForRun(ctx, threadID, run.ID)
 	if err != nil {
-		return nil, fmt.Errorf("error sending request: %w", err)
+		return nil, nil, nil, err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	if run.Status != RunStatusCompleted {
+		return run, toolCalls, nil, nil
 	}
 
-	var fileRes map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&fileRes); err != nil {
-		return nil, fmt.Errorf("error decoding response: %w", err)
-	}
-
-	return fileRes, nil
-}
-
-func (s *AIChatbotService) AddAndRunMessage(ctx context.Context, channelID *string, message string, userID string) (string, string, error) {
-	thread, err := s.threadService.GetLatestOpenThreadByChannelAndUserID(*channelID, userID)
-	var threadID string
+	listMessages, err := s.llmBackend.ListMessages(ctx, threadID)
 	if err != nil {
-		if err != gorm.ErrRecordNotFound {
-			return "", "", err
-		} else {
-			threadID, err = s.CreateThread(ctx)
+		return nil, nil, nil, err
+	}
+	replies := []assistantReply{}
+	consecutiveAssistantMessages := s.GetFirstConsecutiveAssistantMessages(listMessages)
+	// Messages are listed newest first, post the replies in the order they were written.
+	for i := len(consecutiveAssistantMessages) - 1; i >= 0; i-- {
+		message := consecutiveAssistantMessages[i]
+		text, fileIDs := s.renderMessage(ctx, message)
+		if text == "" && len(fileIDs) == 0 {
+			continue
+		}
+		reply := assistantReply{
+			messageID: message.ID,
+			text:      text,
+			fileIDs:   fileIDs,
+		}
+		if text != "" {
+			reply.slackTs, err = poster.PostAttachmentMessage(ctx, channelID, threadTs, text)
 			if err != nil {
-				return "", "", err
+				return nil, nil, nil, err
 			}
-			s.threadService.CreateThread(&models.Thread{
-				ID:          threadID,
-				ChannelId:   *channelID,
-				SlackUserId: userID,
-			})
 		}
-	} else {
-		threadID = thread.ID
+		replies = append(replies, reply)
 	}
+	return run, toolCalls, replies, nil
+}
 
-	messageID, err := s.CreateMessage(ctx, threadID, message)
+// streamRun runs the assistant over a server-sent event stream, writing the
+// replies into Slack while they are generated. Tool calls are acknowledged on
+// the same stream and returned for dispatch like in pollRun.
+func (s *AIChatbotService) streamRun(ctx context.Context, backend StreamingLLMBackend, poster slackReplyPoster, channelID *string, threadTs string, threadID string, request dto.AzureAIRunRequest) (*dto.AzureAIRun, []dto.AIToolCall, []assistantReply, error) {
+	render := func(message dto.AzureAIChatbotMessage) (string, []string) {
+		return s.renderMessage(ctx, message)
+	}
+	stream, err := newSlackReplyStream(ctx, poster, channelID, threadTs, s.streamUpdateInterval, render)
 	if err != nil {
-		return "", "", err
+		return nil, nil, nil, err
 	}
-	runID, err := s.CreateRun(ctx, threadID, s.azureOpenAIConfig.AssistantIdDetectAction)
-	if err != nil {
-		return "", "", err
-	}
-	var action string
-	done := make(chan bool)
-	go func() {
-		ticker := time.NewTicker(3 * time.Second)
-		defer ticker.Stop()
+	runCtx, cancel := context.WithTimeout(ctx, s.runTimeout)
+	defer cancel()
 
-		timeout := time.After(5 * time.Minute)
-
-		for {
-			select {
-			case <-ticker.C:
-				runStatus, err := s.GetRun(ctx, threadID, runID)
-				if err != nil {
-					return
-				}
-				if runStatus != "queued" && runStatus != "in_progress" {
-					if runStatus == "completed" {
-						listMessages, err := s.ListMessages(ctx, threadID)
-						if err != nil {
-							done <- true
-							return
-						}
-						consecutiveAssistantMessages := s.GetFirstConsecutiveAssistantMessages(listMessages)
-						for _, message := range consecutiveAssistantMessages {
-							var textContent *dto.AzureAIChatbotMessageContent
-							for _, content := range message.Content {
-								if content.Type == "text" {
-									textContent = &content
-									break
-								}
-							}
-							if textContent == nil {
-								continue
-							}
-							// annotations := textContent.Text.Annotations
-							// if len(annotations) > 0 {
-							// regexPattern := regexp.MustCompile(`assistant-[a-zA-Z0-9]+`)
-							// matches := regexPattern.FindAllString(textContent.Text.Value, -1)
-							// for _, match := range matches {
-							// }
-							// }
-							// for _, annotation := range annotations {
-							if textContent.Text.Value != "" {
-								action = util.DetectAction(textContent.Text.Value)
-								s.slackService.SendMessage(ctx, channelID, textContent.Text.Value)
-							}
-						}
-					}
-					done <- true
-					return
-				}
-			case <-timeout:
-				done <- true
-				return
+	// The run ID is only known once the stream announced the run, keep it to
+	// cancel the run if the stream is cut.
+	runID := ""
+	onEvent := func(event dto.AzureAIRunStreamEvent) error {
+		if runID == "" && event.Event == "thread.run.created" {
+			created := dto.AzureAIRun{}
+			if json.Unmarshal(event.Data, &created) == nil {
+				runID = created.ID
 			}
 		}
-	}()
+		return stream.handleEvent(event)
+	}
+	toolCalls := []dto.AIToolCall{}
+	run, err := backend.CreateRunStream(runCtx, threadID, request, onEvent)
+	for err == nil && run.Status == RunStatusRequiresAction {
+		calls, outputs := s.parseRequiredAction(run)
+		toolCalls = append(toolCalls, calls...)
+		run, err = backend.SubmitToolOutputsStream(runCtx, threadID, run.ID, outputs, onEvent)
+	}
+	replies, finishErr := stream.finish()
+	if err != nil {
+		if errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("run on thread %s did not finish after %s", threadID, s.runTimeout)
+		}
+		if runCtx.Err() != nil && runID != "" {
+			if cancelErr := s.cancelRun(threadID, runID); cancelErr != nil {
+				err = fmt.Errorf("%w, and the run cannot be cancelled: %v", err, cancelErr)
+			}
+		}
+		return nil, nil, nil, err
+	}
+	if finishErr != nil {
+		return nil, nil, nil, finishErr
+	}
+	return run, toolCalls, replies, nil
+}
 
-	<-done
-	// go s.SendMessageCloseThreadAfter5Minutes(ctx, *channelID, threadID)
-	return messageID, action, nil
+func joinToolCallNames(toolCalls []dto.AIToolCall) string {
+	names := make([]string, 0, len(toolCalls))
+	for _, toolCall := range toolCalls {
+		names = append(names, toolCall.Name)
+	}
+	return strings.Join(names, ",")
 }
 
-func (s *AIChatbotService) SendMessageCloseThreadAfter5Minutes(ctx context.Context, channelID string, threadID string) error {
-	timer := time.NewTimer(5 * time.Minute)
-	// eventChan <- struct{}{}
-	eventChan := make(chan struct{})
+// waitForRun polls the run until it reaches a terminal status. Tool calls
+// requested along the way are acknowledged to the assistant and returned so
+// the caller can dispatch them once the run is over. A run still going when
+// the wait ends is cancelled.
+func (s *AIChatbotService) waitForRun(ctx context.Context, threadID string, runID string) (*dto.AzureAIRun, []dto.AIToolCall, error) {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
 
+	timeout := time.After(s.runTimeout)
+	toolCalls := []dto.AIToolCall{}
 	for {
 		select {
-		case <-timer.C:
-			// Timer expired, close the thread
-			// err := s.threadService.CloseThread(threadID)
-			// if err != nil {
-			// 	return fmt.Errorf("failed to close thread: %w", err)
-			// }
-			// message := "This thread has been inactive for 5 minutes and will now be closed."
-			// err = s.slackService.SendMessage(ctx, &channelID, message)
-			// if err != nil {
-			// 	return fmt.Errorf("failed to send closing message: %w", err)
-			// }
-			return nil
-
-		case <-eventChan:
-			// Event received, reset the timer
-			if !timer.Stop() {
-				<-timer.C
+		case <-ctx.Done():
+			if err := s.cancelRun(threadID, runID); err != nil {
+				return nil, nil, fmt.Errorf("%w, and the run cannot be cancelled: %v", ctx.Err(), err)
+			}
+			return nil, nil, ctx.Err()
+		case <-timeout:
+			if err := s.cancelRun(threadID, runID); err != nil {
+				return nil, nil, fmt.Errorf("run %s did not finish after %s and cannot be cancelled: %w", runID, s.runTimeout, err)
+			}
+			return nil, nil, fmt.Errorf("run %s did not finish after %s", runID, s.runTimeout)
+		case <-ticker.C:
+			run, err := s.llmBackend.GetRun(ctx, threadID, runID)
+			if err != nil {
+				return nil, nil, err
+			}
+			switch run.Status {
+			case RunStatusQueued, RunStatusInProgress, RunStatusCancelling:
+				continue
+			case RunStatusRequiresAction:
+				calls, outputs := s.parseRequiredAction(run)
+				toolCalls = append(toolCalls, calls...)
+				err = s.llmBackend.SubmitToolOutputs(ctx, threadID, runID, outputs)
+				if err != nil {
+					return nil, nil, err
+				}
+			default:
+				return run, toolCalls, nil
 			}
-			timer.Reset(5 * time.Minute)
+		}
+	}
+}
 
-		case <-ctx.Done():
-			// Context cancelled, stop the timer and exit
-			timer.Stop()
-			return ctx.Err()
+// cancelRun cancels a run the service stopped waiting for, so it does not go
+// on answering or calling tools unnoticed. The context of the wait is usually
+// over by then, the cancel request gets its own deadline.
+func (s *AIChatbotService) cancelRun(threadID string, runID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), runCancelTimeout)
+	defer cancel()
+	return s.llmBackend.CancelRun(ctx, threadID, runID)
+}
+
+// parseRequiredAction turns the tool calls of a requires_action run into
+// AIToolCall values and the outputs acknowledging them. Calls whose arguments
+// are not valid JSON are rejected back to the assistant and not dispatched.
+func (s *AIChatbotService) parseRequiredAction(run *dto.AzureAIRun) ([]dto.AIToolCall, []dto.AzureAIToolOutput) {
+	calls := []dto.AIToolCall{}
+	outputs := []dto.AzureAIToolOutput{}
+	if run.RequiredAction == nil {
+		return calls, outputs
+	}
+	for _, toolCall := range run.RequiredAction.SubmitToolOutputs.ToolCalls {
+		arguments := toolCall.Function.Arguments
+		if arguments == "" {
+			arguments = "{}"
+		}
+		status := dto.ToolOutputStatusAccepted
+		if json.Valid([]byte(arguments)) {
+			calls = append(calls, dto.AIToolCall{
+				ID:        toolCall.ID,
+				Name:      toolCall.Function.Name,
+				Arguments: json.RawMessage(arguments),
+			})
+		} else {
+			status = dto.ToolOutputStatusRejected
 		}
+		output, _ := json.Marshal(map[string]string{"status": status})
+		outputs = append(outputs, dto.AzureAIToolOutput{
+			ToolCallID: toolCall.ID,
+			Output:     string(output),
+		})
 	}
+	return calls, outputs
 }
 
 func (s *AIChatbotService) GetFirstConsecutiveAssistantMessages(messages []dto.AzureAIChatbotMessage) []dto.AzureAIChatbotMessage {
@@ -392,31 +628,3 @@ func (s *AIChatbotService) GetFirstConsecutiveAssistantMessages(messages []dto.A
 	}
 	return consecutiveAssistantMessages
 }
-
-func (s *AIChatbotService) addHeader(req *http.Request, isContentJson bool) {
-	if isContentJson {
-		req.Header.Add("Content-Type", "application/json")
-	}
-	req.Header.Add("api-key", s.azureOpenAIConfig.Key)
-}
-
-func (s *AIChatbotService) getUrl(path string) string {
-	return fmt.Sprintf(
-		"%s/openai/%s?api-version=%s",
-		s.azureOpenAIConfig.Endpoint,
-		path,
-		s.azureOpenAIConfig.ApiVersion,
-	)
-}
-
-// func (s *AIChatbotService) GetFileStorageUrl(ctx context.Context, fileId string) (string, error) {
-// 	fileInfo, err := s.GetFileInformation(ctx, fileId)
-// 	if err != nil {
-// 		return "", err
-// 	}
-
-// 	fileContent, err := s.GetFileContent(ctx, fileId)
-// 	if err != nil {
-// 		return "", err
-// 	}
-// }
@@ -0,0 +1,605 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/config"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/mocks"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRequiredAction(t *testing.T) {
+	run := &dto.AzureAIRun{
+		Status:         RunStatusRequiresAction,
+		RequiredAction: &dto.AzureAIRunRequiredAction{Type: "submit_tool_outputs"},
+	}
+	validCall := dto.AzureAIToolCall{ID: "call_1", Type: "function"}
+	validCall.Function.Name = dto.ToolTakeLeave
+	validCall.Function.Arguments = `{"request_date_from":"2024-10-01","request_date_to":"2024-10-02"}`
+	invalidCall := dto.AzureAIToolCall{ID: "call_2", Type: "function"}
+	invalidCall.Function.Name = dto.ToolTrainingRequest
+	invalidCall.Function.Arguments = `{"sheet_url":`
+	run.RequiredAction.SubmitToolOutputs.ToolCalls = []dto.AzureAIToolCall{validCall, invalidCall}
+
+	calls, outputs := (&AIChatbotService{}).parseRequiredAction(run)
+
+	assert.Len(t, calls, 1)
+	assert.Equal(t, dto.ToolTakeLeave, calls[0].Name)
+	var args dto.TakeLeaveArguments
+	assert.NoError(t, json.Unmarshal(calls[0].Arguments, &args))
+	assert.Equal(t, "2024-10-01", args.RequestDateFrom)
+	assert.Equal(t, "2024-10-02", args.RequestDateTo)
+
+	assert.Len(t, outputs, 2)
+	assert.Equal(t, "call_1", outputs[0].ToolCallID)
+	assert.JSONEq(t, `{"status":"accepted"}`, outputs[0].Output)
+	assert.Equal(t, "call_2", outputs[1].ToolCallID)
+	assert.JSONEq(t, `{"status":"rejected"}`, outputs[1].Output)
+}
+
+func TestWaitForRun(t *testing.T) {
+	toolCall := dto.AzureAIToolCall{ID: "call_1", Type: "function"}
+	toolCall.Function.Name = dto.ToolTrainingRequest
+	toolCall.Function.Arguments = `{"sheet_name":"Training"}`
+
+	testCases := []struct {
+		name          string
+		run           mocks.FakeLLMRun
+		expectStatus  string
+		expectCalls   int
+		expectOutputs int
+	}{
+		{
+			name:         "Completed",
+			run:          mocks.FakeLLMRun{Statuses: []string{RunStatusQueued, RunStatusInProgress, RunStatusCompleted}},
+			expectStatus: RunStatusCompleted,
+		},
+		{
+			name: "RequiresAction",
+			run: mocks.FakeLLMRun{
+				Statuses:  []string{RunStatusInProgress, RunStatusRequiresAction, RunStatusInProgress, RunStatusCompleted},
+				ToolCalls: []dto.AzureAIToolCall{toolCall},
+			},
+			expectStatus:  RunStatusCompleted,
+			expectCalls:   1,
+			expectOutputs: 1,
+		},
+		{
+			name:         "Failed",
+			run:          mocks.FakeLLMRun{Statuses: []string{RunStatusInProgress, RunStatusFailed}},
+			expectStatus: RunStatusFailed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			backend := mocks.NewFakeLLMBackend(tc.run)
+			service := &AIChatbotService{llmBackend: backend, pollInterval: time.Millisecond, runTimeout: time.Second}
+			threadID, err := backend.CreateThread(context.Background())
+			assert.NoError(t, err)
+			run, err := backend.CreateRun(context.Background(), threadID, dto.AzureAIRunRequest{})
+			assert.NoError(t, err)
+
+			finished, toolCalls, err := service.waitForRun(context.Background(), threadID, run.ID)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectStatus, finished.Status)
+			assert.Len(t, toolCalls, tc.expectCalls)
+			assert.Len(t, backend.SubmittedToolOutputs[run.ID], tc.expectOutputs)
+		})
+	}
+}
+
+func TestWaitForRunCancelsTimedOutRun(t *testing.T) {
+	backend := mocks.NewFakeLLMBackend(mocks.FakeLLMRun{Statuses: []string{RunStatusInProgress}})
+	service := &AIChatbotService{llmBackend: backend, pollInterval: time.Millisecond, runTimeout: 20 * time.Millisecond}
+	threadID, err := backend.CreateThread(context.Background())
+	assert.NoError(t, err)
+	run, err := backend.CreateRun(context.Background(), threadID, dto.AzureAIRunRequest{})
+	assert.NoError(t, err)
+
+	_, _, err = service.waitForRun(context.Background(), threadID, run.ID)
+
+	assert.EqualError(t, err, fmt.Sprintf("run %s did not finish after 20ms", run.ID))
+	assert.Equal(t, []string{run.ID}, backend.CancelledRuns)
+}
+
+type fakeSlackReplyPoster struct {
+	posted  []string
+	updates map[string][]string
+	deleted []string
+}
+
+func (f *fakeSlackReplyPoster) PostAttachmentMessage(ctx context.Context, channelID *string, threadTs string, message string) (string, error) {
+	if threadTs != "thread_ts" {
+		return "", fmt.Errorf("posted outside of the slack thread: %q", threadTs)
+	}
+	ts := fmt.Sprintf("ts_%d", len(f.posted)+1)
+	f.posted = append(f.posted, message)
+	return ts, nil
+}
+
+func (f *fakeSlackReplyPoster) UpdateAttachmentMessage(ctx context.Context, channelID *string, ts string, message string) error {
+	f.updates[ts] = append(f.updates[ts], message)
+	return nil
+}
+
+func (f *fakeSlackReplyPoster) DeleteMessage(ctx context.Context, channelID *string, ts string) error {
+	f.deleted = append(f.deleted, ts)
+	return nil
+}
+
+func messageDeltaEvent(messageID string, text string) dto.AzureAIRunStreamEvent {
+	data, _ := json.Marshal(map[string]interface{}{
+		"id": messageID,
+		"delta": map[string]interface{}{
+			"content": []map[string]interface{}{{"type": "text", "text": map[string]string{"value": text}}},
+		},
+	})
+	return dto.AzureAIRunStreamEvent{Event: "thread.message.delta", Data: data}
+}
+
+func messageCompletedEvent(messageID string, text string) dto.AzureAIRunStreamEvent {
+	data, _ := json.Marshal(map[string]interface{}{
+		"id":      messageID,
+		"role":    "assistant",
+		"content": []map[string]interface{}{{"type": "text", "text": map[string]string{"value": text}}},
+	})
+	return dto.AzureAIRunStreamEvent{Event: "thread.message.completed", Data: data}
+}
+
+func TestSlackReplyStream(t *testing.T) {
+	tests := []struct {
+		name        string
+		events      []dto.AzureAIRunStreamEvent
+		wantPosted  []string
+		wantUpdates map[string][]string
+		wantDeleted []string
+		wantReplies []assistantReply
+	}{
+		{
+			name: "single message reuses the placeholder",
+			events: []dto.AzureAIRunStreamEvent{
+				messageDeltaEvent("msg_1", "Hello"),
+				messageDeltaEvent("msg_1", " there"),
+				messageCompletedEvent("msg_1", "Hello there"),
+			},
+			wantPosted:  []string{replyPlaceholder},
+			wantUpdates: map[string][]string{"ts_1": {"Hello", "Hello there"}},
+			wantReplies: []assistantReply{{messageID: "msg_1", text: "Hello there", slackTs: "ts_1", fileIDs: []string{}}},
+		},
+		{
+			name: "second message gets its own slack message",
+			events: []dto.AzureAIRunStreamEvent{
+				messageCompletedEvent("msg_1", "Let me check"),
+				messageDeltaEvent("msg_2", "Done"),
+				messageCompletedEvent("msg_2", "Done"),
+			},
+			wantPosted:  []string{replyPlaceholder, replyPlaceholder},
+			wantUpdates: map[string][]string{"ts_1": {"Let me check"}, "ts_2": {"Done"}},
+			wantReplies: []assistantReply{
+				{messageID: "msg_1", text: "Let me check", slackTs: "ts_1", fileIDs: []string{}},
+				{messageID: "msg_2", text: "Done", slackTs: "ts_2", fileIDs: []string{}},
+			},
+		},
+		{
+			name: "image only message keeps no slack message",
+			events: []dto.AzureAIRunStreamEvent{
+				{Event: "thread.message.completed", Data: json.RawMessage(`{"id":"msg_1","role":"assistant","content":[{"type":"image_file","image_file":{"file_id":"file_1"}}]}`)},
+			},
+			wantPosted:  []string{replyPlaceholder},
+			wantUpdates: map[string][]string{},
+			wantDeleted: []string{"ts_1"},
+			wantReplies: []assistantReply{{messageID: "msg_1", fileIDs: []string{"file_1"}}},
+		},
+		{
+			name:        "run without text removes the placeholder",
+			events:      []dto.AzureAIRunStreamEvent{{Event: "thread.run.requires_action", Data: json.RawMessage(`{}`)}},
+			wantPosted:  []string{replyPlaceholder},
+			wantUpdates: map[string][]string{},
+			wantDeleted: []string{"ts_1"},
+			wantReplies: []assistantReply{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			poster := &fakeSlackReplyPoster{updates: map[string][]string{}}
+			service := &AIChatbotService{llmBackend: mocks.NewFakeLLMBackend()}
+			render := func(message dto.AzureAIChatbotMessage) (string, []string) {
+				return service.renderMessage(context.Background(), message)
+			}
+			stream, err := newSlackReplyStream(context.Background(), poster, nil, "thread_ts", 0, render)
+			assert.NoError(t, err)
+			for _, event := range tt.events {
+				assert.NoError(t, stream.handleEvent(event))
+			}
+			replies, err := stream.finish()
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantReplies, replies)
+			assert.Equal(t, tt.wantPosted, poster.posted)
+			assert.Equal(t, tt.wantUpdates, poster.updates)
+			assert.Equal(t, tt.wantDeleted, poster.deleted)
+		})
+	}
+}
+
+func TestRenderMessage(t *testing.T) {
+	backend := mocks.NewFakeLLMBackend()
+	backend.FileInformation["file_handbook"] = map[string]interface{}{"filename": "handbook.pdf"}
+	backend.FileInformation["file_report"] = map[string]interface{}{"filename": "/mnt/data/report.csv"}
+	service := &AIChatbotService{llmBackend: backend}
+
+	message := dto.AzureAIChatbotMessage{}
+	err := json.Unmarshal([]byte(`{
+		"id": "msg_1",
+		"role": "assistant",
+		"content": [
+			{"type": "image_file", "image_file": {"file_id": "file_chart"}},
+			{"type": "text", "text": {
+				"value": "Leave is 12 days【4:0†source】, see 【4:1†source】 and 【4:2†source】. Download sandbox:/mnt/data/report.csv",
+				"annotations": [
+					{"type": "file_citation", "text": "【4:0†source】", "file_citation": {"file_id": "file_handbook"}},
+					{"type": "file_citation", "text": "【4:1†source】", "file_citation": {"file_id": "file_policy"}},
+					{"type": "file_citation", "text": "【4:2†source】", "file_citation": {"file_id": "file_handbook"}},
+					{"type": "file_path", "text": "sandbox:/mnt/data/report.csv", "file_path": {"file_id": "file_report"}}
+				]
+			}}
+		]
+	}`), &message)
+	assert.NoError(t, err)
+
+	text, fileIDs := service.renderMessage(context.Background(), message)
+
+	assert.Equal(t, "Leave is 12 days[1], see [2] and [1]. Download report.csv\n\n[1] handbook.pdf\n[2] file_policy", text)
+	assert.Equal(t, []string{"file_chart", "file_report"}, fileIDs)
+}
+
+// chatbotFixture wires an AIChatbotService to the fake Azure and Slack
+// servers and in-memory repositories.
+type chatbotFixture struct {
+	service  *AIChatbotService
+	azure    *mocks.FakeAzureServer
+	slack    *mocks.FakeSlackServer
+	threads  *mocks.FakeThreadRepository
+	messages *mocks.FakeMessageRepository
+	usages   *mocks.FakeRunUsageRepository
+	answers  *mocks.FakeCachedAnswerRepository
+}
+
+func newChatbotFixture(t *testing.T, runs ...mocks.FakeLLMRun) *chatbotFixture {
+	azure := mocks.NewFakeAzureServer(runs...)
+	t.Cleanup(azure.Close)
+	slackServer := mocks.NewFakeSlackServer()
+	t.Cleanup(slackServer.Close)
+	router, err := NewAssistantRouter(testAssistantsConfig)
+	assert.NoError(t, err)
+
+	azureOpenAIConfig := config.AzureOpenAIConfig{Endpoint: azure.URL, Key: "key", ApiVersion: "2024-02-15-preview"}
+	slackClient := slack.New("xoxb-test", slack.OptionAPIURL(slackServer.APIURL()))
+	f := &chatbotFixture{
+		azure:    azure,
+		slack:    slackServer,
+		threads:  mocks.NewFakeThreadRepository(),
+		messages: &mocks.FakeMessageRepository{},
+		usages:   &mocks.FakeRunUsageRepository{},
+		answers:  mocks.NewFakeCachedAnswerRepository(),
+	}
+	f.service = NewAIChatbotService(
+		NewAzureAssistantsBackend(azure.Client(), azureOpenAIConfig),
+		azureOpenAIConfig,
+		NewSlackService(&config.SlackConfig{}, slackClient),
+		NewThreadService(f.threads, config.ThreadConfig{IdleTimeout: time.Hour}),
+		NewMessageService(f.messages),
+		NewUsageService(f.usages, config.UsageConfig{}),
+		router,
+		nil,
+		nil,
+		NewAnswerCacheService(f.answers, config.AnswerCacheConfig{TTL: time.Hour}),
+	)
+	f.service.SetPollInterval(time.Millisecond)
+	return f
+}
+
+// postedTexts returns the text of the messages posted to Slack, attachment
+// messages are posted as their pretext.
+func (f *chatbotFixture) postedTexts() []string {
+	texts := []string{}
+	for _, call := range f.slack.Calls("chat.postMessage") {
+		if len(call.Attachments) > 0 {
+			texts = append(texts, call.Attachments[0].Pretext)
+		} else {
+			texts = append(texts, call.Text)
+		}
+	}
+	return texts
+}
+
+func TestAddAndRunMessage(t *testing.T) {
+	takeLeave := dto.AzureAIToolCall{ID: "call_1", Type: "function"}
+	takeLeave.Function.Name = dto.ToolTakeLeave
+	takeLeave.Function.Arguments = `{"request_date_from":"2024-10-01"}`
+	usage := &dto.AzureAIRunUsage{PromptTokens: 900, CompletionTokens: 100, TotalTokens: 1000}
+
+	tests := []struct {
+		name             string
+		runs             []mocks.FakeLLMRun
+		runTimeout       time.Duration
+		wantErr          string
+		wantPosted       []string
+		wantToolCalls    []string
+		wantUserAction   string
+		wantUsages       int
+		wantCancelled    int
+		wantContextToken int64
+	}{
+		{
+			name: "completed run posts the replies in order",
+			runs: []mocks.FakeLLMRun{{
+				Statuses: []string{RunStatusQueued, RunStatusInProgress, RunStatusCompleted},
+				Replies:  []string{"Let me check.", "You have 12 days of leave left."},
+				Usage:    usage,
+			}},
+			wantPosted:       []string{"Let me check.", "You have 12 days of leave left."},
+			wantToolCalls:    []string{},
+			wantUsages:       1,
+			wantContextToken: 1000,
+		},
+		{
+			name: "slow queue is waited for",
+			runs: []mocks.FakeLLMRun{{
+				Statuses: []string{RunStatusQueued, RunStatusQueued, RunStatusQueued, RunStatusQueued, RunStatusQueued, RunStatusInProgress, RunStatusCompleted},
+				Replies:  []string{"Hello!"},
+			}},
+			wantPosted:    []string{"Hello!"},
+			wantToolCalls: []string{},
+		},
+		{
+			name: "requires action returns the tool calls",
+			runs: []mocks.FakeLLMRun{{
+				Statuses:  []string{RunStatusInProgress, RunStatusRequiresAction, RunStatusInProgress, RunStatusCompleted},
+				ToolCalls: []dto.AzureAIToolCall{takeLeave},
+				Replies:   []string{"I prepared the leave request form."},
+				Usage:     usage,
+			}},
+			wantPosted:       []string{"I prepared the leave request form."},
+			wantToolCalls:    []string{dto.ToolTakeLeave},
+			wantUserAction:   dto.ToolTakeLeave,
+			wantUsages:       1,
+			wantContextToken: 1000,
+		},
+		{
+			name: "failed run",
+			runs: []mocks.FakeLLMRun{{
+				Statuses:  []string{RunStatusInProgress, RunStatusFailed},
+				LastError: &dto.AzureAIRunError{Code: "server_error", Message: "Sorry, something went wrong."},
+			}},
+			wantErr:    "failed: Sorry, something went wrong.",
+			wantPosted: []string{},
+		},
+		{
+			name:       "expired run",
+			runs:       []mocks.FakeLLMRun{{Statuses: []string{RunStatusQueued, RunStatusExpired}}},
+			wantErr:    "expired",
+			wantPosted: []string{},
+		},
+		{
+			name:          "run stuck in the queue is cancelled",
+			runs:          []mocks.FakeLLMRun{{Statuses: []string{RunStatusQueued}}},
+			runTimeout:    30 * time.Millisecond,
+			wantErr:       "did not finish after 30ms",
+			wantPosted:    []string{},
+			wantCancelled: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newChatbotFixture(t, tt.runs...)
+			if tt.runTimeout > 0 {
+				f.service.runTimeout = tt.runTimeout
+			}
+			channelID := "C1"
+
+			messageID, toolCalls, err := f.service.AddAndRunMessage(context.Background(), dto.AddMessageRequest{
+				ChannelID:     &channelID,
+				Message:       "How many days of leave do I have?",
+				UserID:        "U1",
+				SlackTs:       "1700000000.000100",
+				SlackThreadTs: "1700000000.000100",
+			})
+
+			assert.Equal(t, tt.wantPosted, f.postedTexts())
+			for _, call := range f.slack.Calls("chat.postMessage") {
+				assert.Equal(t, "C1", call.Channel)
+				assert.Equal(t, "1700000000.000100", call.ThreadTs)
+			}
+			assert.Len(t, f.azure.Backend.CancelledRuns, tt.wantCancelled)
+			assert.Len(t, f.usages.Usages, tt.wantUsages)
+			thread, threadErr := f.threads.GetThreadBySlackThread("C1", "1700000000.000100")
+			assert.NoError(t, threadErr)
+			assert.Equal(t, config.AssistantIntentDetection, thread.AssistantName)
+			assert.Equal(t, "1", thread.AssistantVersion)
+			assert.Equal(t, tt.wantContextToken, thread.ContextTokens)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantToolCalls, toolCallNames(toolCalls))
+
+			messages, _ := f.messages.GetMessagesByThreadID(thread.ID)
+			assert.Len(t, messages, 1+len(tt.wantPosted))
+			assert.Equal(t, messageID, messages[0].ID)
+			assert.Equal(t, models.MessageRoleUser, messages[0].Role)
+			assert.Equal(t, tt.wantUserAction, messages[0].Action)
+			assert.NotEmpty(t, messages[0].RunID)
+			for i, message := range messages[1:] {
+				assert.Equal(t, models.MessageRoleAssistant, message.Role)
+				assert.Equal(t, tt.wantPosted[i], message.Content)
+				assert.Equal(t, fmt.Sprintf("1700000000.%06d", i+1), message.SlackTs)
+			}
+		})
+	}
+}
+
+func TestAddAndRunMessageExistingThread(t *testing.T) {
+	tests := []struct {
+		name            string
+		status          string
+		wantNewThread   bool
+		wantAssistantID string
+		wantVersion     string
+	}{
+		{name: "open thread continues", status: models.ThreadStatusOpen, wantAssistantID: "asst_hr", wantVersion: "3"},
+		{name: "reply after expiry starts a fresh conversation", status: models.ThreadStatusClosed, wantNewThread: true, wantAssistantID: "asst_intent", wantVersion: "2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newChatbotFixture(t, mocks.FakeLLMRun{Replies: []string{"Sure."}})
+			backendThreadID, err := f.azure.Backend.CreateThread(context.Background())
+			assert.NoError(t, err)
+			f.threads.Threads["thread_row"] = &models.Thread{
+				ID:               "thread_row",
+				BackendThreadID:  backendThreadID,
+				ChannelId:        "C1",
+				SlackUserId:      "U1",
+				SlackThreadTs:    "1700000000.000100",
+				Status:           tt.status,
+				AssistantName:    "hr_policy_qa",
+				AssistantVersion: "2",
+			}
+			channelID := "C1"
+
+			_, _, err = f.service.AddAndRunMessage(context.Background(), dto.AddMessageRequest{
+				ChannelID:     &channelID,
+				Message:       "And tomorrow?",
+				UserID:        "U1",
+				SlackTs:       "1700000000.000200",
+				SlackThreadTs: "1700000000.000100",
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"Sure."}, f.postedTexts())
+			assert.Len(t, f.azure.Backend.RunRequests, 1)
+			assert.Equal(t, tt.wantAssistantID, f.azure.Backend.RunRequests[0].AssistantID)
+			assert.Equal(t, "And tomorrow?", f.azure.Backend.MessageInputs[0].Text)
+			// Open threads record the version of the assistant answering them.
+			assert.Equal(t, tt.wantVersion, f.threads.Threads["thread_row"].AssistantVersion)
+			thread, err := f.threads.GetThreadBySlackThread("C1", "1700000000.000100")
+			assert.NoError(t, err)
+			assert.Equal(t, models.ThreadStatusOpen, thread.Status)
+			if tt.wantNewThread {
+				assert.Contains(t, f.azure.Requests(), "POST /openai/threads")
+				assert.NotEqual(t, "thread_row", thread.ID)
+				assert.NotEqual(t, backendThreadID, thread.BackendThreadID)
+				assert.Equal(t, models.ThreadStatusClosed, f.threads.Threads["thread_row"].Status)
+			} else {
+				assert.NotContains(t, f.azure.Requests(), "POST /openai/threads")
+				assert.Equal(t, "thread_row", thread.ID)
+			}
+		})
+	}
+}
+
+func TestAddAndRunMessageOverQuota(t *testing.T) {
+	tests := []struct {
+		name       string
+		openThread bool
+	}{
+		{name: "refused question is recorded in the open conversation", openThread: true},
+		{name: "no conversation is started for a refused question"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newChatbotFixture(t)
+			f.service.usageService = NewUsageService(f.usages, config.UsageConfig{DailyTokenQuota: 1000})
+			assert.NoError(t, f.usages.CreateRunUsage(&models.RunUsage{RunID: "run_1", SlackUserID: "U1", TotalTokens: 1000}))
+			if tt.openThread {
+				f.threads.Threads["thread_row"] = &models.Thread{ID: "thread_row", BackendThreadID: "thread_backend", ChannelId: "C1", SlackUserId: "U1", SlackThreadTs: "1700000000.000100", Status: models.ThreadStatusOpen}
+			}
+			channelID := "C1"
+
+			messageID, toolCalls, err := f.service.AddAndRunMessage(context.Background(), dto.AddMessageRequest{
+				ChannelID:     &channelID,
+				Message:       "How many days of leave do I have?",
+				UserID:        "U1",
+				SlackTs:       "1700000000.000200",
+				SlackThreadTs: "1700000000.000100",
+			})
+
+			assert.NoError(t, err)
+			assert.Empty(t, toolCalls)
+			reply := "Sorry, you have used up your assistant quota for today. Please try again tomorrow."
+			assert.Equal(t, []string{reply}, f.postedTexts())
+			assert.NotContains(t, f.azure.Requests(), "POST /openai/threads")
+			assert.Empty(t, f.azure.Backend.RunRequests)
+			assert.Empty(t, f.azure.Backend.MessageInputs)
+			if !tt.openThread {
+				assert.Empty(t, messageID)
+				assert.Empty(t, f.threads.Threads)
+				return
+			}
+			// The refused question shows in the transcript of the conversation.
+			messages, err := f.messages.GetMessagesByThreadID("thread_row")
+			assert.NoError(t, err)
+			assert.Len(t, messages, 2)
+			assert.Equal(t, messageID, messages[0].ID)
+			assert.Equal(t, models.MessageRoleUser, messages[0].Role)
+			assert.Equal(t, "How many days of leave do I have?", messages[0].Content)
+			assert.Equal(t, "1700000000.000200", messages[0].SlackTs)
+			assert.Equal(t, models.MessageRoleAssistant, messages[1].Role)
+			assert.Equal(t, reply, messages[1].Content)
+			assert.Equal(t, "1700000000.000001", messages[1].SlackTs)
+		})
+	}
+}
+
+func TestGetFirstConsecutiveAssistantMessages(t *testing.T) {
+	message := func(id string, role string) dto.AzureAIChatbotMessage {
+		return dto.AzureAIChatbotMessage{ID: id, Role: role}
+	}
+	tests := []struct {
+		name     string
+		messages []dto.AzureAIChatbotMessage
+		wantIDs  []string
+	}{
+		{name: "no messages", wantIDs: []string{}},
+		{
+			name:     "latest replies until the user message",
+			messages: []dto.AzureAIChatbotMessage{message("msg_4", "assistant"), message("msg_3", "assistant"), message("msg_2", "user"), message("msg_1", "assistant")},
+			wantIDs:  []string{"msg_4", "msg_3"},
+		},
+		{
+			name:     "replies after a newer user message",
+			messages: []dto.AzureAIChatbotMessage{message("msg_3", "user"), message("msg_2", "assistant"), message("msg_1", "user")},
+			wantIDs:  []string{"msg_2"},
+		},
+		{
+			name:     "only user messages",
+			messages: []dto.AzureAIChatbotMessage{message("msg_2", "user"), message("msg_1", "user")},
+			wantIDs:  []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages := (&AIChatbotService{}).GetFirstConsecutiveAssistantMessages(tt.messages)
+
+			ids := []string{}
+			for _, message := range messages {
+				ids = append(ids, message.ID)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+)
+
+// replyPlaceholder is shown in Slack until the first words of a streamed reply
+// arrive.
+const replyPlaceholder = "..."
+
+// assistantReply is an assistant message of a run together with the Slack
+// message it was posted as.
+type assistantReply struct {
+	messageID string
+	text      string
+	slackTs   string
+	fileIDs   []string
+}
+
+type slackReplyPoster interface {
+	PostAttachmentMessage(ctx context.Context, channelID *string, threadTs string, message string) (string, error)
+	UpdateAttachmentMessage(ctx context.Context, channelID *string, ts string, message string) error
+	DeleteMessage(ctx context.Context, channelID *string, ts string) error
+}
+
+// slackReplyStream posts the assistant messages of a streamed run to Slack.
+// A placeholder is posted before the run starts and reused by the first
+// message; every message then grows through chat.update, at most once per
+// updateInterval to stay under the Slack rate limits.
+type slackReplyStream struct {
+	ctx            context.Context
+	slack          slackReplyPoster
+	channelID      *string
+	threadTs       string
+	updateInterval time.Duration
+	render         func(message dto.AzureAIChatbotMessage) (string, []string)
+	placeholderTs  string
+	replies        []*assistantReply
+	flushedText    string
+	lastUpdate     time.Time
+}
+
+func newSlackReplyStream(ctx context.Context, slack slackReplyPoster, channelID *string, threadTs string, updateInterval time.Duration, render func(message dto.AzureAIChatbotMessage) (string, []string)) (*slackReplyStream, error) {
+	ts, err := slack.PostAttachmentMessage(ctx, channelID, threadTs, replyPlaceholder)
+	if err != nil {
+		return nil, err
+	}
+	return &slackReplyStream{
+		ctx:            ctx,
+		slack:          slack,
+		channelID:      channelID,
+		threadTs:       threadTs,
+		updateInterval: updateInterval,
+		render:         render,
+		placeholderTs:  ts,
+	}, nil
+}
+
+func (w *slackReplyStream) handleEvent(event dto.AzureAIRunStreamEvent) error {
+	switch event.Event {
+	case "thread.message.delta":
+		var delta dto.AzureAIChatbotMessageDelta
+		err := json.Unmarshal(event.Data, &delta)
+		if err != nil {
+			return fmt.Errorf("error decoding message delta: %w", err)
+		}
+		reply, err := w.startMessage(delta.ID)
+		if err != nil {
+			return err
+		}
+		for _, content := range delta.Delta.Content {
+			if content.Type == "text" {
+				reply.text += content.Text.Value
+			}
+		}
+		if time.Since(w.lastUpdate) < w.updateInterval {
+			return nil
+		}
+		return w.flush()
+	case "thread.message.completed":
+		var message dto.AzureAIChatbotMessage
+		err := json.Unmarshal(event.Data, &message)
+		if err != nil {
+			return fmt.Errorf("error decoding message: %w", err)
+		}
+		if message.Role != "assistant" {
+			return nil
+		}
+		reply, err := w.startMessage(message.ID)
+		if err != nil {
+			return err
+		}
+		// The completed message carries the annotations, its rendering replaces
+		// the raw text of the deltas.
+		reply.text, reply.fileIDs = w.render(message)
+		return w.flush()
+	}
+	return nil
+}
+
+// finish writes the final text of the last message and removes the Slack
+// messages left without text. The replies are returned in the order they were
+// written; a reply made only of files keeps no Slack message.
+func (w *slackReplyStream) finish() ([]assistantReply, error) {
+	errs := []error{w.flush()}
+	if w.placeholderTs != "" {
+		errs = append(errs, w.slack.DeleteMessage(w.ctx, w.channelID, w.placeholderTs))
+		w.placeholderTs = ""
+	}
+	replies := []assistantReply{}
+	for _, reply := range w.replies {
+		if reply.text == "" {
+			errs = append(errs, w.slack.DeleteMessage(w.ctx, w.channelID, reply.slackTs))
+			if len(reply.fileIDs) == 0 {
+				continue
+			}
+			reply.slackTs = ""
+		}
+		replies = append(replies, *reply)
+	}
+	return replies, errors.Join(errs...)
+}
+
+// startMessage returns the reply being written for messageID. A new message
+// takes over the placeholder, or posts its own once the placeholder is used.
+func (w *slackReplyStream) startMessage(messageID string) (*assistantReply, error) {
+	if current := w.current(); current != nil && current.messageID == messageID {
+		return current, nil
+	}
+	err := w.flush()
+	if err != nil {
+		return nil, err
+	}
+	ts := w.placeholderTs
+	w.placeholderTs = ""
+	if ts == "" {
+		ts, err = w.slack.PostAttachmentMessage(w.ctx, w.channelID, w.threadTs, replyPlaceholder)
+		if err != nil {
+			return nil, err
+		}
+	}
+	reply := &assistantReply{messageID: messageID, slackTs: ts}
+	w.replies = append(w.replies, reply)
+	w.flushedText = ""
+	return reply, nil
+}
+
+func (w *slackReplyStream) current() *assistantReply {
+	if len(w.replies) == 0 {
+		return nil
+	}
+	return w.replies[len(w.replies)-1]
+}
+
+func (w *slackReplyStream) flush() error {
+	current := w.current()
+	if current == nil || current.text == "" || current.text == w.flushedText {
+		return nil
+	}
+	err := w.slack.UpdateAttachmentMessage(w.ctx, w.channelID, current.slackTs, current.text)
+	if err != nil {
+		return err
+	}
+	w.flushedText = current.text
+	w.lastUpdate = time.Now()
+	return nil
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+)
+
+func stringProperty(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "string",
+		"description": description,
+	}
+}
+
+func functionTool(name string, description string, properties map[string]interface{}) dto.AzureAITool {
+	return dto.AzureAITool{
+		Type: "function",
+		Function: &dto.AzureAIFunctionDefinition{
+			Name:        name,
+			Description: description,
+			Parameters: map[string]interface{}{
+				"type":       "object",
+				"properties": properties,
+				"required":   []string{},
+			},
+		},
+	}
+}
+
+// ChatbotTools are the functions the detect action assistant may call. Every
+// argument is optional: whatever the user already gave is used to prefill the
+// matching Slack form, the rest is asked for in the form itself.
+var ChatbotTools = []dto.AzureAITool{
+	functionTool(
+		dto.ToolOnboardNhanVien,
+		"Onboard new employees from a candidate offer google sheet.",
+		map[string]interface{}{
+			"candidate_sheet_url": stringProperty("Link of the candidate offer google sheet"),
+			"skill_sheet_url":     stringProperty("Link of the skill google sheet to fill, if the user has one"),
+		},
+	),
+	functionTool(
+		dto.ToolWelcomeNewEmployee,
+		"Send a welcome message to a new employee.",
+		map[string]interface{}{
+			"skill_file_url": stringProperty("Link of the new employee skill google sheet"),
+			"personal_email": stringProperty("Personal email of the new employee"),
+		},
+	),
+	functionTool(
+		dto.ToolCreateBuddyFormFile,
+		"Create the buddy form file from a transformation input sheet.",
+		map[string]interface{}{
+			"input_sheet_url":  stringProperty("Link of the transformation input google sheet"),
+			"output_sheet_url": stringProperty("Link of the transformation output google sheet"),
+		},
+	),
+	functionTool(
+		dto.ToolTakeLeave,
+		"Create a leave request for the user.",
+		map[string]interface{}{
+			"request_date_from": stringProperty("First day of leave, format YYYY-MM-DD"),
+			"request_date_to":   stringProperty("Last day of leave, format YYYY-MM-DD"),
+			"hour_from":         stringProperty("Start hour of leave, format HH:MM"),
+			"hour_to":           stringProperty("End hour of leave, format HH:MM"),
+			"description":       stringProperty("Reason of the leave"),
+		},
+	),
+	functionTool(
+		dto.ToolTrainingRequest,
+		"Create an integrate training request from a google sheet.",
+		map[string]interface{}{
+			"sheet_url":  stringProperty("Link of the training google sheet"),
+			"sheet_name": stringProperty("Name of the sheet tab containing the training data"),
+		},
+	),
+}
+
+// AssistantFileTools are the built-in tools reading the files users attach to
+// their messages.
+var AssistantFileTools = []dto.AzureAITool{
+	{Type: "code_interpreter"},
+	{Type: "file_search"},
+}
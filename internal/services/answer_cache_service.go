@@ -0,0 +1,103 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/config"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/models"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/repository"
+	"gorm.io/gorm"
+)
+
+// AnswerCacheService caches the answers of the assistants to the questions
+// starting a conversation, so that frequently asked questions are answered
+// without a run. Answers are keyed by assistant name and version, a new
+// version of the assistant starts with an empty cache, and by the question
+// normalized by normalizeQuestion.
+type AnswerCacheService struct {
+	cachedAnswerRepo repository.CachedAnswerRepositoryInterface
+	ttl              time.Duration
+}
+
+func NewAnswerCacheService(cachedAnswerRepo repository.CachedAnswerRepositoryInterface, answerCacheConfig config.AnswerCacheConfig) *AnswerCacheService {
+	return &AnswerCacheService{
+		cachedAnswerRepo: cachedAnswerRepo,
+		ttl:              answerCacheConfig.TTL,
+	}
+}
+
+// Enabled reports whether answers are cached, it is false for a nil service.
+func (s *AnswerCacheService) Enabled() bool {
+	return s != nil && s.ttl > 0
+}
+
+// Lookup returns the answer cached for the question asked to the assistant,
+// nil when there is none.
+func (s *AnswerCacheService) Lookup(assistant config.AssistantDefinition, question string) (*models.CachedAnswer, error) {
+	normalized := normalizeQuestion(question)
+	if normalized == "" {
+		return nil, nil
+	}
+	answer, err := s.cachedAnswerRepo.GetCachedAnswer(cachedAnswerKey(assistant, normalized), time.Now())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return answer, nil
+}
+
+// Store caches the replies of the assistant to the question, with the
+// sources they cite.
+func (s *AnswerCacheService) Store(assistant config.AssistantDefinition, question string, replies []string, sources string) error {
+	normalized := normalizeQuestion(question)
+	if normalized == "" || len(replies) == 0 {
+		return nil
+	}
+	return s.cachedAnswerRepo.SaveCachedAnswer(&models.CachedAnswer{
+		Key:              cachedAnswerKey(assistant, normalized),
+		AssistantName:    assistant.Name,
+		AssistantVersion: assistant.Version,
+		Question:         normalized,
+		Replies:          replies,
+		Sources:          sources,
+		ExpiresAt:        time.Now().Add(s.ttl),
+	})
+}
+
+// RecordHit counts one more replay of the cached answer.
+func (s *AnswerCacheService) RecordHit(key string) error {
+	return s.cachedAnswerRepo.IncrementCachedAnswerHits(key)
+}
+
+// Evict drops the cached answer, it reports false when it was not cached
+// anymore.
+func (s *AnswerCacheService) Evict(key string) (bool, error) {
+	return s.cachedAnswerRepo.DeleteCachedAnswer(key)
+}
+
+// Invalidate drops the answers cached for the assistant, for all of them when
+// assistantName is empty, and returns how many were dropped.
+func (s *AnswerCacheService) Invalidate(assistantName string) (int64, error) {
+	return s.cachedAnswerRepo.DeleteCachedAnswers(assistantName)
+}
+
+// normalizeQuestion lowercases the question and keeps its words, so that
+// questions differing only by case, punctuation or spacing share an answer.
+func normalizeQuestion(question string) string {
+	words := strings.FieldsFunc(strings.ToLower(question), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, " ")
+}
+
+func cachedAnswerKey(assistant config.AssistantDefinition, normalizedQuestion string) string {
+	hash := sha256.Sum256([]byte(assistant.Name + "\x00" + assistant.Version + "\x00" + normalizedQuestion))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/config"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeQuestion(t *testing.T) {
+	tests := []struct {
+		question string
+		expected string
+	}{
+		{question: "How many leave days do I have?", expected: "how many leave days do i have"},
+		{question: "  how many LEAVE days,  do I have ", expected: "how many leave days do i have"},
+		{question: "Tôi có bao nhiêu ngày phép?", expected: "tôi có bao nhiêu ngày phép"},
+		{question: "?!", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.question, func(t *testing.T) {
+			assert.Equal(t, tt.expected, normalizeQuestion(tt.question))
+		})
+	}
+}
+
+func TestAnswerCacheService(t *testing.T) {
+	repo := mocks.NewFakeCachedAnswerRepository()
+	service := NewAnswerCacheService(repo, config.AnswerCacheConfig{TTL: time.Hour})
+	hrPolicy := config.AssistantDefinition{Name: "hr_policy_qa", Version: "1"}
+
+	assert.True(t, service.Enabled())
+	assert.NoError(t, service.Store(hrPolicy, "How many leave days do I have?", []string{"12 days."}, "Sources: [S1]"))
+	assert.NoError(t, service.Store(config.AssistantDefinition{Name: config.AssistantIntentDetection, Version: "1"}, "Hello", []string{"Hi!"}, ""))
+
+	cached, err := service.Lookup(hrPolicy, "how many leave days do I have")
+	assert.NoError(t, err)
+	if assert.NotNil(t, cached) {
+		assert.Equal(t, []string{"12 days."}, cached.Replies)
+		assert.Equal(t, "Sources: [S1]", cached.Sources)
+		assert.NoError(t, service.RecordHit(cached.Key))
+		assert.Equal(t, int64(1), repo.Answers[cached.Key].Hits)
+	}
+
+	// A new version of the assistant starts with an empty cache.
+	cached, err = service.Lookup(config.AssistantDefinition{Name: "hr_policy_qa", Version: "2"}, "How many leave days do I have?")
+	assert.NoError(t, err)
+	assert.Nil(t, cached)
+
+	deleted, err := service.Invalidate("hr_policy_qa")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	cached, err = service.Lookup(hrPolicy, "How many leave days do I have?")
+	assert.NoError(t, err)
+	assert.Nil(t, cached)
+	assert.Len(t, repo.Answers, 1)
+
+	assert.False(t, NewAnswerCacheService(repo, config.AnswerCacheConfig{TTL: -time.Second}).Enabled())
+	assert.False(t, (*AnswerCacheService)(nil).Enabled())
+}
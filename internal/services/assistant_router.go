@@ -0,0 +1,166 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/config"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+)
+
+// maxAssistantHandoffs bounds the assistants a single user message goes
+// through, so two assistants routing to each other cannot loop.
+const maxAssistantHandoffs = 2
+
+// AssistantRouter picks the assistant running each step of a conversation.
+// A conversation starts with the default assistant; an assistant hands it over
+// to another one by calling route_to_assistant with one of the configured
+// intents, the next run on the same thread then uses the target assistant.
+type AssistantRouter struct {
+	defaultAssistant string
+	assistants       map[string]config.AssistantDefinition
+	intents          map[string]config.IntentRoute
+	tools            map[string][]dto.AzureAITool
+}
+
+func NewAssistantRouter(assistantsConfig config.AssistantsConfig) (*AssistantRouter, error) {
+	err := assistantsConfig.Validate()
+	if err != nil {
+		return nil, err
+	}
+	chatbotTools := map[string]dto.AzureAITool{}
+	for _, tool := range ChatbotTools {
+		chatbotTools[tool.Function.Name] = tool
+	}
+
+	router := &AssistantRouter{
+		defaultAssistant: assistantsConfig.Default,
+		assistants:       map[string]config.AssistantDefinition{},
+		intents:          map[string]config.IntentRoute{},
+		tools:            map[string][]dto.AzureAITool{},
+	}
+	for _, route := range assistantsConfig.Intents {
+		router.intents[route.Intent] = route
+	}
+	for _, assistant := range assistantsConfig.Assistants {
+		tools := []dto.AzureAITool{}
+		for _, name := range assistant.Tools {
+			if name == "*" {
+				tools = append(tools, ChatbotTools...)
+				continue
+			}
+			tool, ok := chatbotTools[name]
+			if !ok {
+				return nil, fmt.Errorf("assistant %s uses unknown tool %q", assistant.Name, name)
+			}
+			tools = append(tools, tool)
+		}
+		// Only the assistants holding conversations may hand them over, the
+		// ones run for a single step of a flow may not.
+		if router.holdsConversations(assistant.Name) {
+			if routeTool, ok := routeToAssistantTool(assistant.Name, assistantsConfig.Intents); ok {
+				tools = append(tools, routeTool)
+			}
+		}
+		router.assistants[assistant.Name] = assistant
+		router.tools[assistant.Name] = append(tools, AssistantFileTools...)
+	}
+	return router, nil
+}
+
+// Assistant returns the named assistant, or the default one when name is
+// empty or no longer configured.
+func (r *AssistantRouter) Assistant(name string) config.AssistantDefinition {
+	assistant, ok := r.assistants[name]
+	if !ok {
+		return r.assistants[r.defaultAssistant]
+	}
+	return assistant
+}
+
+// NamedAssistant returns the named assistant, without falling back to the
+// default one.
+func (r *AssistantRouter) NamedAssistant(name string) (config.AssistantDefinition, bool) {
+	assistant, ok := r.assistants[name]
+	return assistant, ok
+}
+
+// RunRequest returns the request running the assistant on a thread.
+func (r *AssistantRouter) RunRequest(assistant config.AssistantDefinition) dto.AzureAIRunRequest {
+	return dto.AzureAIRunRequest{
+		AssistantID:  assistant.AssistantID,
+		Instructions: assistant.Instructions,
+		Tools:        append([]dto.AzureAITool{}, r.tools[assistant.Name]...),
+	}
+}
+
+// Route looks for a route_to_assistant call among the tool calls of a run. It
+// returns the assistant to hand the conversation over to, if any, and the
+// other tool calls, left for the Slack handlers.
+func (r *AssistantRouter) Route(toolCalls []dto.AIToolCall) (config.AssistantDefinition, []dto.AIToolCall, bool) {
+	var next config.AssistantDefinition
+	routed := false
+	remaining := []dto.AIToolCall{}
+	for _, toolCall := range toolCalls {
+		if toolCall.Name != dto.ToolRouteToAssistant {
+			remaining = append(remaining, toolCall)
+			continue
+		}
+		var arguments dto.RouteToAssistantArguments
+		if json.Unmarshal(toolCall.Arguments, &arguments) != nil {
+			continue
+		}
+		route, ok := r.intents[arguments.Intent]
+		if !ok || routed {
+			continue
+		}
+		next, routed = r.assistants[route.Assistant], true
+	}
+	return next, remaining, routed
+}
+
+// holdsConversations reports whether the assistant is the default one or the
+// target of an intent.
+func (r *AssistantRouter) holdsConversations(name string) bool {
+	if name == r.defaultAssistant {
+		return true
+	}
+	for _, route := range r.intents {
+		if route.Assistant == name {
+			return true
+		}
+	}
+	return false
+}
+
+// routeToAssistantTool is the function handing the conversation over to the
+// intents served by other assistants than current.
+func routeToAssistantTool(current string, intents []config.IntentRoute) (dto.AzureAITool, bool) {
+	names := []string{}
+	descriptions := []string{}
+	for _, route := range intents {
+		if route.Assistant == current {
+			continue
+		}
+		names = append(names, route.Intent)
+		descriptions = append(descriptions, fmt.Sprintf("%s: %s", route.Intent, route.Description))
+	}
+	if len(names) == 0 {
+		return dto.AzureAITool{}, false
+	}
+	tool := functionTool(
+		dto.ToolRouteToAssistant,
+		"Hand the conversation over to the assistant serving another intent. Intents: "+strings.Join(descriptions, "; "),
+		map[string]interface{}{
+			"intent": map[string]interface{}{
+				"type":        "string",
+				"description": "Intent of the user message",
+				"enum":        names,
+			},
+			"reason": stringProperty("Why the conversation is handed over"),
+		},
+	)
+	tool.Function.Parameters["required"] = []string{"intent"}
+	return tool, true
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/config"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+var testAssistantsConfig = config.AssistantsConfig{
+	Default: config.AssistantIntentDetection,
+	Assistants: []config.AssistantDefinition{
+		{Name: config.AssistantIntentDetection, AssistantID: "asst_intent", Version: "1", Tools: []string{"*"}},
+		{Name: config.AssistantHeaderMapping, AssistantID: "asst_header", Instructions: "Map the headers."},
+		{Name: "hr_policy_qa", AssistantID: "asst_hr", Version: "3", Tools: []string{dto.ToolTakeLeave}},
+	},
+	Intents: []config.IntentRoute{
+		{Intent: "action", Assistant: config.AssistantIntentDetection, Description: "The user wants something done."},
+		{Intent: "hr_policy_question", Assistant: "hr_policy_qa", Description: "The user asks about HR policies."},
+	},
+}
+
+func toolNames(tools []dto.AzureAITool) []string {
+	names := []string{}
+	for _, tool := range tools {
+		if tool.Function != nil {
+			names = append(names, tool.Function.Name)
+		} else {
+			names = append(names, tool.Type)
+		}
+	}
+	return names
+}
+
+func TestNewAssistantRouter(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  config.AssistantsConfig
+		wantErr string
+	}{
+		{name: "valid", config: testAssistantsConfig},
+		{
+			name: "unknown default",
+			config: config.AssistantsConfig{
+				Default:    "missing",
+				Assistants: []config.AssistantDefinition{{Name: config.AssistantIntentDetection}},
+			},
+			wantErr: `unknown default assistant "missing"`,
+		},
+		{
+			name: "unknown tool",
+			config: config.AssistantsConfig{
+				Default:    config.AssistantIntentDetection,
+				Assistants: []config.AssistantDefinition{{Name: config.AssistantIntentDetection, Tools: []string{"send_email"}}},
+			},
+			wantErr: `assistant intent_detection uses unknown tool "send_email"`,
+		},
+		{
+			name: "intent to unknown assistant",
+			config: config.AssistantsConfig{
+				Default:    config.AssistantIntentDetection,
+				Assistants: []config.AssistantDefinition{{Name: config.AssistantIntentDetection}},
+				Intents:    []config.IntentRoute{{Intent: "hr_policy_question", Assistant: "hr_policy_qa"}},
+			},
+			wantErr: `intent hr_policy_question routes to unknown assistant "hr_policy_qa"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewAssistantRouter(tt.config)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAssistantRouterRunRequest(t *testing.T) {
+	router, err := NewAssistantRouter(testAssistantsConfig)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		assistant string
+		wantID    string
+		wantTools []string
+	}{
+		{
+			assistant: config.AssistantIntentDetection,
+			wantID:    "asst_intent",
+			wantTools: []string{
+				dto.ToolOnboardNhanVien, dto.ToolWelcomeNewEmployee, dto.ToolCreateBuddyFormFile, dto.ToolTakeLeave, dto.ToolTrainingRequest,
+				dto.ToolRouteToAssistant, "code_interpreter", "file_search",
+			},
+		},
+		{
+			assistant: "hr_policy_qa",
+			wantID:    "asst_hr",
+			wantTools: []string{dto.ToolTakeLeave, dto.ToolRouteToAssistant, "code_interpreter", "file_search"},
+		},
+		{
+			// Run for single steps, it cannot hand anything over.
+			assistant: config.AssistantHeaderMapping,
+			wantID:    "asst_header",
+			wantTools: []string{"code_interpreter", "file_search"},
+		},
+		{
+			assistant: "",
+			wantID:    "asst_intent",
+			wantTools: []string{
+				dto.ToolOnboardNhanVien, dto.ToolWelcomeNewEmployee, dto.ToolCreateBuddyFormFile, dto.ToolTakeLeave, dto.ToolTrainingRequest,
+				dto.ToolRouteToAssistant, "code_interpreter", "file_search",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.assistant, func(t *testing.T) {
+			request := router.RunRequest(router.Assistant(tt.assistant))
+			assert.Equal(t, tt.wantID, request.AssistantID)
+			assert.Equal(t, tt.wantTools, toolNames(request.Tools))
+		})
+	}
+
+	request := router.RunRequest(router.Assistant("hr_policy_qa"))
+	routeTool := request.Tools[1].Function.Parameters["properties"].(map[string]interface{})["intent"].(map[string]interface{})
+	assert.Equal(t, []string{"action"}, routeTool["enum"])
+}
+
+func TestAssistantRouterRoute(t *testing.T) {
+	router, err := NewAssistantRouter(testAssistantsConfig)
+	assert.NoError(t, err)
+	takeLeave := dto.AIToolCall{ID: "call_1", Name: dto.ToolTakeLeave, Arguments: json.RawMessage(`{}`)}
+
+	tests := []struct {
+		name          string
+		toolCalls     []dto.AIToolCall
+		wantRouted    bool
+		wantAssistant string
+		wantRemaining []dto.AIToolCall
+	}{
+		{
+			name:          "no route",
+			toolCalls:     []dto.AIToolCall{takeLeave},
+			wantRemaining: []dto.AIToolCall{takeLeave},
+		},
+		{
+			name: "route to hr policy",
+			toolCalls: []dto.AIToolCall{
+				{ID: "call_2", Name: dto.ToolRouteToAssistant, Arguments: json.RawMessage(`{"intent":"hr_policy_question"}`)},
+				takeLeave,
+			},
+			wantRouted:    true,
+			wantAssistant: "hr_policy_qa",
+			wantRemaining: []dto.AIToolCall{takeLeave},
+		},
+		{
+			name: "unknown intent",
+			toolCalls: []dto.AIToolCall{
+				{ID: "call_3", Name: dto.ToolRouteToAssistant, Arguments: json.RawMessage(`{"intent":"weather"}`)},
+			},
+			wantRemaining: []dto.AIToolCall{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, remaining, routed := router.Route(tt.toolCalls)
+			assert.Equal(t, tt.wantRouted, routed)
+			assert.Equal(t, tt.wantAssistant, next.Name)
+			assert.Equal(t, tt.wantRemaining, remaining)
+		})
+	}
+}
+
+func TestSuggestColumnMappings(t *testing.T) {
+	router, err := NewAssistantRouter(testAssistantsConfig)
+	assert.NoError(t, err)
+	backend := mocks.NewFakeLLMBackend(mocks.FakeLLMRun{
+		Replies: []string{"```json\n{\"Full Name\": \"Họ tên\", \"Email\": \"Email cá nhân\", \"Level\": \"Cấp bậc không có\"}\n```"},
+		Usage:   &dto.AzureAIRunUsage{PromptTokens: 80, CompletionTokens: 20, TotalTokens: 100},
+	})
+	usages := &mocks.FakeRunUsageRepository{}
+	service := &AIChatbotService{llmBackend: backend, assistantRouter: router, usageService: NewUsageService(usages, config.UsageConfig{}), pollInterval: time.Millisecond, runTimeout: time.Second}
+
+	mappings, err := service.SuggestColumnMappings(context.Background(), []string{"Họ tên", "Email cá nhân"}, []string{"Full Name", "Email", "Level"}, "U1", "C1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"Full Name": "Họ tên", "Email": "Email cá nhân"}, mappings)
+	assert.Equal(t, "asst_header", backend.RunRequests[0].AssistantID)
+	assert.Equal(t, "Map the headers.", backend.RunRequests[0].Instructions)
+	// The run is billed to the user asking for the mapping, its thread is not kept.
+	assert.Len(t, usages.Usages, 1)
+	assert.Equal(t, "U1", usages.Usages[0].SlackUserID)
+	assert.Equal(t, "C1", usages.Usages[0].ChannelID)
+	assert.Equal(t, int64(100), usages.Usages[0].TotalTokens)
+	assert.Len(t, backend.DeletedThreads, 1)
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/config"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+)
+
+const (
+	AssistantSyncCreate    = "create"
+	AssistantSyncUpdate    = "update"
+	AssistantSyncUnchanged = "unchanged"
+)
+
+// AssistantVersionMetadata is the metadata key of an Azure assistant holding
+// the version of the definition it was last synced from.
+const AssistantVersionMetadata = "chatbot_version"
+
+// AssistantChange is what syncing does to the assistant of a definition:
+// create it, update the Fields of Current that differ from Desired, or leave
+// it unchanged.
+type AssistantChange struct {
+	Action  string
+	Name    string
+	Current *dto.AzureAIAssistant
+	Desired dto.AzureAIAssistant
+	Fields  []string
+}
+
+// AssistantSyncService creates and updates the assistants on Azure OpenAI
+// from the assistant definitions of the repository. Assistants are matched by
+// their assistant_id, or by name when it is not set. The fields a definition
+// leaves empty keep the value they have on Azure.
+type AssistantSyncService struct {
+	backend          *AzureAssistantsBackend
+	assistantRouter  *AssistantRouter
+	assistantsConfig config.AssistantsConfig
+}
+
+func NewAssistantSyncService(backend *AzureAssistantsBackend, assistantRouter *AssistantRouter, assistantsConfig config.AssistantsConfig) *AssistantSyncService {
+	return &AssistantSyncService{
+		backend:          backend,
+		assistantRouter:  assistantRouter,
+		assistantsConfig: assistantsConfig,
+	}
+}
+
+// Plan compares the definitions with the assistants on Azure and returns the
+// change of every definition, in the order of the assistants file.
+func (s *AssistantSyncService) Plan(ctx context.Context) ([]AssistantChange, error) {
+	assistants, err := s.backend.ListAssistants(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list assistants: %w", err)
+	}
+	byID := map[string]*dto.AzureAIAssistant{}
+	byName := map[string]*dto.AzureAIAssistant{}
+	for i := range assistants {
+		byID[assistants[i].ID] = &assistants[i]
+		byName[assistants[i].Name] = &assistants[i]
+	}
+
+	changes := []AssistantChange{}
+	for _, definition := range s.assistantsConfig.Assistants {
+		if definition.Version == "" {
+			return nil, fmt.Errorf("assistant %s has no version", definition.Name)
+		}
+		current := byName[definition.Name]
+		if definition.AssistantID != "" {
+			current = byID[definition.AssistantID]
+			if current == nil {
+				return nil, fmt.Errorf("assistant %s: %s does not exist on Azure", definition.Name, definition.AssistantID)
+			}
+		}
+		desired := s.desiredAssistant(definition, current)
+		if current == nil {
+			if desired.Model == "" {
+				return nil, fmt.Errorf("assistant %s has no model to be created with", definition.Name)
+			}
+			changes = append(changes, AssistantChange{Action: AssistantSyncCreate, Name: definition.Name, Desired: desired})
+			continue
+		}
+		change := AssistantChange{Action: AssistantSyncUnchanged, Name: definition.Name, Current: current, Desired: desired}
+		change.Fields = assistantDiff(*current, desired)
+		if len(change.Fields) > 0 {
+			change.Action = AssistantSyncUpdate
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// Apply makes the change on Azure. The ID of a created assistant is set on
+// change.Desired.
+func (s *AssistantSyncService) Apply(ctx context.Context, change *AssistantChange) error {
+	switch change.Action {
+	case AssistantSyncCreate:
+		created, err := s.backend.CreateAssistant(ctx, change.Desired)
+		if err != nil {
+			return fmt.Errorf("failed to create assistant %s: %w", change.Name, err)
+		}
+		change.Desired.ID = created.ID
+	case AssistantSyncUpdate:
+		_, err := s.backend.UpdateAssistant(ctx, change.Desired)
+		if err != nil {
+			return fmt.Errorf("failed to update assistant %s: %w", change.Name, err)
+		}
+	}
+	return nil
+}
+
+// desiredAssistant is the assistant declared by the definition, with the tools
+// its runs are given. The fields the definition leaves empty are taken from
+// current, when it exists.
+func (s *AssistantSyncService) desiredAssistant(definition config.AssistantDefinition, current *dto.AzureAIAssistant) dto.AzureAIAssistant {
+	desired := dto.AzureAIAssistant{
+		Name:         definition.Name,
+		Model:        definition.Model,
+		Instructions: definition.Instructions,
+		Tools:        s.assistantRouter.RunRequest(definition).Tools,
+		Metadata:     map[string]string{},
+	}
+	if len(definition.FileSearchStores) > 0 {
+		desired.ToolResources.FileSearch = &dto.AzureAIFileSearchResources{VectorStoreIDs: definition.FileSearchStores}
+	}
+	if current != nil {
+		desired.ID = current.ID
+		if desired.Model == "" {
+			desired.Model = current.Model
+		}
+		if desired.Instructions == "" {
+			desired.Instructions = current.Instructions
+		}
+		if desired.ToolResources.FileSearch == nil {
+			desired.ToolResources.FileSearch = current.ToolResources.FileSearch
+		}
+		for key, value := range current.Metadata {
+			desired.Metadata[key] = value
+		}
+	}
+	desired.Metadata[AssistantVersionMetadata] = definition.Version
+	return desired
+}
+
+// assistantDiff names the fields of desired differing from current.
+func assistantDiff(current dto.AzureAIAssistant, desired dto.AzureAIAssistant) []string {
+	fields := []string{}
+	if current.Name != desired.Name {
+		fields = append(fields, "name")
+	}
+	if current.Model != desired.Model {
+		fields = append(fields, "model")
+	}
+	if current.Instructions != desired.Instructions {
+		fields = append(fields, "instructions")
+	}
+	if !sameJSON(current.Tools, desired.Tools) {
+		fields = append(fields, "tools")
+	}
+	if !sameJSON(current.ToolResources, desired.ToolResources) {
+		fields = append(fields, "file_search_stores")
+	}
+	if current.Metadata[AssistantVersionMetadata] != desired.Metadata[AssistantVersionMetadata] {
+		fields = append(fields, "version")
+	}
+	return fields
+}
+
+// sameJSON reports whether a and b encode to the same JSON, once decoded back
+// into generic values so that key order and number formatting do not matter.
+func sameJSON(a interface{}, b interface{}) bool {
+	var decodedA, decodedB interface{}
+	encodedA, errA := json.Marshal(a)
+	encodedB, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	if json.Unmarshal(encodedA, &decodedA) != nil || json.Unmarshal(encodedB, &decodedB) != nil {
+		return false
+	}
+	return reflect.DeepEqual(decodedA, decodedB)
+}
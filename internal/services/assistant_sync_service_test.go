@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/config"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssistantSyncServicePlan(t *testing.T) {
+	hrPolicy := config.AssistantDefinition{
+		Name:             "hr_policy_qa",
+		Version:          "2",
+		Model:            "gpt-4o",
+		Instructions:     "Answer HR questions.",
+		FileSearchStores: []string{"vs_policies"},
+	}
+
+	tests := []struct {
+		name       string
+		existing   []dto.AzureAIAssistant
+		definition config.AssistantDefinition
+		wantAction string
+		wantFields []string
+		wantErr    string
+	}{
+		{name: "create", definition: hrPolicy, wantAction: AssistantSyncCreate},
+		{
+			name: "unchanged",
+			existing: []dto.AzureAIAssistant{{
+				ID:            "asst_hr",
+				Name:          "hr_policy_qa",
+				Model:         "gpt-4o",
+				Instructions:  "Answer HR questions.",
+				Tools:         AssistantFileTools,
+				ToolResources: dto.AzureAIToolResources{FileSearch: &dto.AzureAIFileSearchResources{VectorStoreIDs: []string{"vs_policies"}}},
+				Metadata:      map[string]string{AssistantVersionMetadata: "2"},
+			}},
+			definition: hrPolicy,
+			wantAction: AssistantSyncUnchanged,
+		},
+		{
+			name: "update matched by name",
+			existing: []dto.AzureAIAssistant{{
+				ID:           "asst_hr",
+				Name:         "hr_policy_qa",
+				Model:        "gpt-4o",
+				Instructions: "Answer questions.",
+				Metadata:     map[string]string{AssistantVersionMetadata: "1"},
+			}},
+			definition: hrPolicy,
+			wantAction: AssistantSyncUpdate,
+			wantFields: []string{"instructions", "tools", "file_search_stores", "version"},
+		},
+		{
+			name:     "update matched by assistant_id keeps the model",
+			existing: []dto.AzureAIAssistant{{ID: "asst_hr", Name: "HR bot", Model: "gpt-4o-mini", Tools: AssistantFileTools}},
+			definition: config.AssistantDefinition{
+				Name:        "hr_policy_qa",
+				AssistantID: "asst_hr",
+				Version:     "1",
+			},
+			wantAction: AssistantSyncUpdate,
+			wantFields: []string{"name", "version"},
+		},
+		{
+			name:       "no version",
+			definition: config.AssistantDefinition{Name: "hr_policy_qa", Model: "gpt-4o"},
+			wantErr:    "assistant hr_policy_qa has no version",
+		},
+		{
+			name:       "unknown assistant_id",
+			definition: config.AssistantDefinition{Name: "hr_policy_qa", AssistantID: "asst_missing", Version: "1"},
+			wantErr:    "assistant hr_policy_qa: asst_missing does not exist on Azure",
+		},
+		{
+			name:       "no model to create with",
+			definition: config.AssistantDefinition{Name: "hr_policy_qa", Version: "1"},
+			wantErr:    "assistant hr_policy_qa has no model to be created with",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			azure := mocks.NewFakeAzureServer()
+			defer azure.Close()
+			for _, assistant := range tt.existing {
+				azure.AddAssistant(assistant)
+			}
+			assistantsConfig := config.AssistantsConfig{
+				Default:    tt.definition.Name,
+				Assistants: []config.AssistantDefinition{tt.definition},
+			}
+			router, err := NewAssistantRouter(assistantsConfig)
+			assert.NoError(t, err)
+			service := NewAssistantSyncService(
+				NewAzureAssistantsBackend(azure.Client(), config.AzureOpenAIConfig{Endpoint: azure.URL}),
+				router,
+				assistantsConfig,
+			)
+
+			changes, err := service.Plan(context.Background())
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, changes, 1)
+			assert.Equal(t, tt.wantAction, changes[0].Action)
+			assert.Equal(t, tt.wantFields, append([]string(nil), changes[0].Fields...))
+		})
+	}
+}
+
+func TestAssistantSyncServiceApply(t *testing.T) {
+	azure := mocks.NewFakeAzureServer()
+	defer azure.Close()
+	azure.AddAssistant(dto.AzureAIAssistant{
+		ID:           "asst_header",
+		Name:         config.AssistantHeaderMapping,
+		Model:        "gpt-4o",
+		Instructions: "Map the columns.",
+		Metadata:     map[string]string{"owner": "onboarding"},
+	})
+	assistantsConfig := config.AssistantsConfig{
+		Default: config.AssistantIntentDetection,
+		Assistants: []config.AssistantDefinition{
+			{Name: config.AssistantIntentDetection, Version: "1", Model: "gpt-4o", Tools: []string{dto.ToolTakeLeave}},
+			{Name: config.AssistantHeaderMapping, AssistantID: "asst_header", Version: "2", Instructions: "Map the headers."},
+		},
+	}
+	router, err := NewAssistantRouter(assistantsConfig)
+	assert.NoError(t, err)
+	service := NewAssistantSyncService(
+		NewAzureAssistantsBackend(azure.Client(), config.AzureOpenAIConfig{Endpoint: azure.URL}),
+		router,
+		assistantsConfig,
+	)
+
+	changes, err := service.Plan(context.Background())
+	assert.NoError(t, err)
+	for i := range changes {
+		assert.NoError(t, service.Apply(context.Background(), &changes[i]))
+	}
+	assert.Equal(t, "asst_2", changes[0].Desired.ID)
+
+	assistants := azure.Assistants()
+	assert.Len(t, assistants, 2)
+	assert.Equal(t, "Map the headers.", assistants[0].Instructions)
+	assert.Equal(t, "gpt-4o", assistants[0].Model)
+	assert.Equal(t, map[string]string{"owner": "onboarding", AssistantVersionMetadata: "2"}, assistants[0].Metadata)
+	assert.Equal(t, config.AssistantIntentDetection, assistants[1].Name)
+	assert.Equal(t, []string{dto.ToolTakeLeave, "code_interpreter", "file_search"}, toolNames(assistants[1].Tools))
+	assert.Equal(t, "1", assistants[1].Metadata[AssistantVersionMetadata])
+
+	// Once applied, nothing is left to sync.
+	changes, err = service.Plan(context.Background())
+	assert.NoError(t, err)
+	for _, change := range changes {
+		assert.Equal(t, AssistantSyncUnchanged, change.Action, change.Name)
+	}
+}
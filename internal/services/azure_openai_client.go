@@ -0,0 +1,271 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/config"
+)
+
+// AzureOpenAIError is an error answered by the Azure OpenAI API, decoded from
+// its {"error": {...}} body.
+type AzureOpenAIError struct {
+	StatusCode int
+	Code       string
+	Type       string
+	Message    string
+}
+
+func (e *AzureOpenAIError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("azure openai: status %d: %s: %s", e.StatusCode, e.Code, e.Message)
+	}
+	return fmt.Sprintf("azure openai: status %d: %s", e.StatusCode, e.Message)
+}
+
+// Retryable reports whether a request sent with method may succeed if sent
+// again without being applied twice: the API was rate limited, which it
+// answers before doing anything, or failed on its side on an idempotent
+// request. A POST failing with a 5xx may have created its thread, message or
+// run already.
+func (e *AzureOpenAIError) Retryable(method string) bool {
+	if e.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	return e.StatusCode >= http.StatusInternalServerError && idempotentMethod(method)
+}
+
+// RetryPolicy is how long the Azure OpenAI client waits before sending a
+// request again. The delay doubles on every attempt up to MaxDelay and is
+// jittered.
+type RetryPolicy struct {
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+var defaultRetryPolicy = RetryPolicy{MaxRetries: 3, BaseDelay: 500 * time.Millisecond, MaxDelay: 30 * time.Second}
+
+// azureOpenAIClient sends requests to the Azure OpenAI API over one shared
+// http.Client. Requests follow the deadline of their context. 429 answers,
+// and 5xx answers to idempotent requests, are retried after the delay asked
+// by Retry-After, or the backoff of the retry policy. Requests that could not
+// connect are retried with the backoff.
+type azureOpenAIClient struct {
+	client            *http.Client
+	azureOpenAIConfig config.AzureOpenAIConfig
+	retryPolicy       RetryPolicy
+}
+
+func newAzureOpenAIClient(client *http.Client, azureOpenAIConfig config.AzureOpenAIConfig) *azureOpenAIClient {
+	retryPolicy := defaultRetryPolicy
+	retryPolicy.MaxRetries = azureOpenAIConfig.MaxRetries
+	return &azureOpenAIClient{client: client, azureOpenAIConfig: azureOpenAIConfig, retryPolicy: retryPolicy}
+}
+
+// newAzureOpenAIHTTPClient returns the http.Client shared by the Azure OpenAI
+// calls. Only the wait for the response headers is bounded so that streamed
+// runs are not cut, the rest is up to the request context.
+func newAzureOpenAIHTTPClient(azureOpenAIConfig config.AzureOpenAIConfig) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = azureOpenAIConfig.RequestTimeout
+	return &http.Client{Transport: transport}
+}
+
+// azureOpenAIRequest describes a request to the API, path is relative to
+// /openai. The body is kept as bytes so it can be sent again.
+type azureOpenAIRequest struct {
+	method      string
+	path        string
+	contentType string
+	accept      string
+	body        []byte
+}
+
+// do sends the request until it succeeds, fails with an error that is not
+// retryable, or runs out of retries. A non 2xx answer is returned as an
+// *AzureOpenAIError. The caller closes the body of the returned response.
+func (c *azureOpenAIClient) do(ctx context.Context, request azureOpenAIRequest) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, request.method, c.url(request.path), bytes.NewReader(request.body))
+		if err != nil {
+			return nil, fmt.Errorf("error creating request: %w", err)
+		}
+		req.Header.Set("api-key", c.azureOpenAIConfig.Key)
+		if request.contentType != "" {
+			req.Header.Set("Content-Type", request.contentType)
+		}
+		if request.accept != "" {
+			req.Header.Set("Accept", request.accept)
+		}
+		resp, err := c.client.Do(req)
+		if err != nil {
+			sendErr := fmt.Errorf("error sending request: %w", err)
+			if ctx.Err() != nil || !(notSent(err) || idempotentMethod(request.method)) || attempt >= c.retryPolicy.MaxRetries {
+				return nil, sendErr
+			}
+			if err := sleepContext(ctx, c.backoff(attempt)); err != nil {
+				return nil, fmt.Errorf("%w (retry interrupted: %v)", sendErr, err)
+			}
+			continue
+		}
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return resp, nil
+		}
+
+		apiErr := decodeAzureOpenAIError(resp)
+		if !apiErr.Retryable(request.method) || attempt >= c.retryPolicy.MaxRetries {
+			return nil, apiErr
+		}
+		delay, ok := retryAfter(resp.Header, time.Now())
+		if !ok {
+			delay = c.backoff(attempt)
+		}
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, fmt.Errorf("%w (retry interrupted: %v)", apiErr, err)
+		}
+	}
+}
+
+// doJSON sends requestBody, when not nil, as JSON and decodes the answer into
+// responseBody, when not nil.
+func (c *azureOpenAIClient) doJSON(ctx context.Context, method string, path string, requestBody interface{}, responseBody interface{}) error {
+	request := azureOpenAIRequest{method: method, path: path, contentType: "application/json"}
+	if requestBody != nil {
+		body, err := json.Marshal(requestBody)
+		if err != nil {
+			return err
+		}
+		request.body = body
+	}
+	resp, err := c.do(ctx, request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if responseBody == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(responseBody); err != nil {
+		return fmt.Errorf("error decoding response: %w", err)
+	}
+	return nil
+}
+
+// idempotentMethod reports whether sending a request with method twice has
+// the effect of sending it once.
+func idempotentMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// notSent reports whether the request failed before reaching the API: the
+// connection could not be opened.
+func notSent(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// backoff returns the jittered delay before retry number attempt+1: a random
+// duration between half and all of BaseDelay*2^attempt, capped at MaxDelay.
+func (c *azureOpenAIClient) backoff(attempt int) time.Duration {
+	delay := c.retryPolicy.MaxDelay
+	if attempt < 30 && c.retryPolicy.BaseDelay<<attempt < delay {
+		delay = c.retryPolicy.BaseDelay << attempt
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// url returns the URL of path, which may carry a query string.
+func (c *azureOpenAIClient) url(path string) string {
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	return fmt.Sprintf(
+		"%s/openai/%s%sapi-version=%s",
+		c.azureOpenAIConfig.Endpoint,
+		path,
+		separator,
+		c.azureOpenAIConfig.ApiVersion,
+	)
+}
+
+// decodeAzureOpenAIError reads the error answered in resp and closes its body.
+// Bodies that are not in the API error format become the message.
+func decodeAzureOpenAIError(resp *http.Response) *AzureOpenAIError {
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	apiErr := &AzureOpenAIError{StatusCode: resp.StatusCode}
+	errorBody := struct {
+		Error *struct {
+			Code    interface{} `json:"code"`
+			Type    string      `json:"type"`
+			Message string      `json:"message"`
+		} `json:"error"`
+	}{}
+	if json.Unmarshal(body, &errorBody) == nil && errorBody.Error != nil {
+		if errorBody.Error.Code != nil {
+			apiErr.Code = fmt.Sprint(errorBody.Error.Code)
+		}
+		apiErr.Type = errorBody.Error.Type
+		apiErr.Message = errorBody.Error.Message
+		return apiErr
+	}
+	apiErr.Message = string(bytes.TrimSpace(body))
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+	}
+	return apiErr
+}
+
+// retryAfter returns the delay asked by the retry-after-ms or Retry-After
+// headers. Retry-After holds either seconds or an HTTP date.
+func retryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	if value := header.Get("retry-after-ms"); value != "" {
+		if milliseconds, err := strconv.ParseFloat(value, 64); err == nil && milliseconds >= 0 {
+			return time.Duration(milliseconds * float64(time.Millisecond)), true
+		}
+	}
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := date.Sub(now); delay > 0 {
+			return delay, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
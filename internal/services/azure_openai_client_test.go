@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAzureOpenAIClientDo(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		statuses     []int
+		header       http.Header
+		body         string
+		wantAttempts int
+		wantErr      *AzureOpenAIError
+	}{
+		{
+			name:         "success",
+			statuses:     []int{http.StatusOK},
+			wantAttempts: 1,
+		},
+		{
+			name:         "rate limited then success",
+			statuses:     []int{http.StatusTooManyRequests, http.StatusOK},
+			header:       http.Header{"Retry-After": []string{"0"}},
+			body:         `{"error":{"code":"429","message":"Rate limit is exceeded."}}`,
+			wantAttempts: 2,
+		},
+		{
+			name:         "server errors until retries run out",
+			method:       "GET",
+			statuses:     []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable},
+			body:         "upstream unavailable",
+			wantAttempts: 3,
+			wantErr:      &AzureOpenAIError{StatusCode: http.StatusServiceUnavailable, Message: "upstream unavailable"},
+		},
+		{
+			name:         "server error on a POST is not retried",
+			statuses:     []int{http.StatusInternalServerError, http.StatusOK},
+			body:         "upstream unavailable",
+			wantAttempts: 1,
+			wantErr:      &AzureOpenAIError{StatusCode: http.StatusInternalServerError, Message: "upstream unavailable"},
+		},
+		{
+			name:         "client error is not retried",
+			statuses:     []int{http.StatusBadRequest, http.StatusOK},
+			body:         `{"error":{"code":"invalid_request_error","type":"invalid_request_error","message":"Thread not found."}}`,
+			wantAttempts: 1,
+			wantErr:      &AzureOpenAIError{StatusCode: http.StatusBadRequest, Code: "invalid_request_error", Type: "invalid_request_error", Message: "Thread not found."},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "key", r.Header.Get("api-key"))
+				status := tt.statuses[attempts]
+				attempts++
+				if status != http.StatusOK {
+					for key, values := range tt.header {
+						w.Header()[key] = values
+					}
+					w.WriteHeader(status)
+					w.Write([]byte(tt.body))
+					return
+				}
+				w.Write([]byte(`{"id":"thread_1"}`))
+			}))
+			defer server.Close()
+
+			client := newAzureOpenAIClient(server.Client(), config.AzureOpenAIConfig{Endpoint: server.URL, Key: "key", MaxRetries: 2})
+			client.retryPolicy.BaseDelay = time.Millisecond
+			client.retryPolicy.MaxDelay = time.Millisecond
+			thread := struct {
+				ID string `json:"id"`
+			}{}
+			method := tt.method
+			if method == "" {
+				method = "POST"
+			}
+			err := client.doJSON(context.Background(), method, "threads", nil, &thread)
+
+			assert.Equal(t, tt.wantAttempts, attempts)
+			if tt.wantErr != nil {
+				var apiErr *AzureOpenAIError
+				assert.True(t, errors.As(err, &apiErr))
+				assert.Equal(t, tt.wantErr, apiErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "thread_1", thread.ID)
+		})
+	}
+}
+
+// failingDialTransport fails to connect the first dials times, then sends
+// the requests through http.DefaultTransport.
+type failingDialTransport struct {
+	dials    int
+	attempts int
+}
+
+func (f *failingDialTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.attempts++
+	if f.attempts <= f.dials {
+		return nil, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestAzureOpenAIClientDoRetriesConnectionErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"thread_1"}`))
+	}))
+	defer server.Close()
+
+	transport := &failingDialTransport{dials: 2}
+	client := newAzureOpenAIClient(&http.Client{Transport: transport}, config.AzureOpenAIConfig{Endpoint: server.URL, MaxRetries: 2})
+	client.retryPolicy.BaseDelay = time.Millisecond
+	client.retryPolicy.MaxDelay = time.Millisecond
+	err := client.doJSON(context.Background(), "POST", "threads", nil, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, transport.attempts)
+}
+
+func TestAzureOpenAIClientDoStopsRetryingWithContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := newAzureOpenAIClient(server.Client(), config.AzureOpenAIConfig{Endpoint: server.URL, MaxRetries: 3})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := client.doJSON(ctx, "GET", "threads/thread_1", nil, nil)
+
+	var apiErr *AzureOpenAIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusTooManyRequests, apiErr.StatusCode)
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 6, 3, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		header    http.Header
+		wantDelay time.Duration
+		wantOK    bool
+	}{
+		{name: "missing", header: http.Header{}, wantOK: false},
+		{name: "seconds", header: http.Header{"Retry-After": []string{"7"}}, wantDelay: 7 * time.Second, wantOK: true},
+		{name: "http date", header: http.Header{"Retry-After": []string{"Mon, 03 Jun 2024 10:00:30 GMT"}}, wantDelay: 30 * time.Second, wantOK: true},
+		{name: "past http date", header: http.Header{"Retry-After": []string{"Mon, 03 Jun 2024 09:00:00 GMT"}}, wantDelay: 0, wantOK: true},
+		{name: "milliseconds first", header: http.Header{"Retry-After-Ms": []string{"1500"}, "Retry-After": []string{"2"}}, wantDelay: 1500 * time.Millisecond, wantOK: true},
+		{name: "invalid", header: http.Header{"Retry-After": []string{"soon"}}, wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delay, ok := retryAfter(tt.header, now)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantDelay, delay)
+		})
+	}
+}
+
+func TestAzureOpenAIClientBackoff(t *testing.T) {
+	client := newAzureOpenAIClient(http.DefaultClient, config.AzureOpenAIConfig{})
+	client.retryPolicy = RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for attempt, want := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second} {
+		delay := client.backoff(attempt)
+		assert.GreaterOrEqual(t, delay, want/2)
+		assert.LessOrEqual(t, delay, want)
+	}
+}
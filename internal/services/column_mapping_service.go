@@ -0,0 +1,272 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/google_internal"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/models"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/repository"
+	"github.com/sotatek-dev/hyper-automation-chatbot/pkg/util"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrColumnMappingNotOwner = errors.New("only the user who submitted the sheet can answer this column mapping")
+	ErrColumnMappingAnswered = errors.New("this column mapping was already applied or cancelled")
+	// ErrColumnMappingHeadersChanged is returned when the headers of the skill
+	// sheet are no longer the ones the mapping was proposed for.
+	ErrColumnMappingHeadersChanged = errors.New("the skill sheet headers changed since the column mapping was proposed")
+)
+
+type columnMappingSheets interface {
+	ReadSheetData(sheetUrl string) ([]string, [][]interface{}, error)
+	WriteSheetData(spreadsheetID string, writeRange string, rows [][]interface{}) error
+	AppendSheetData(spreadsheetID string, rows [][]interface{}) error
+	CreateNewSheetInSharedDrive(sheetName string, sharedDriveFolderId string) (*dto.CreateNewSheetResponse, error)
+}
+
+type columnMappingSuggester interface {
+	SuggestColumnMappings(ctx context.Context, sourceHeaders []string, targetHeaders []string, slackUserID string, channelID string) (map[string]string, error)
+}
+
+// ColumnMappingService fills a skill sheet from a candidate sheet whose
+// columns are matched by the header mapping assistant. A suggested mapping is
+// applied once confirmed in Slack, and reused without asking again for sheets
+// with the same headers.
+type ColumnMappingService struct {
+	columnMappingRepo repository.ColumnMappingRepositoryInterface
+	sheets            columnMappingSheets
+	suggester         columnMappingSuggester
+}
+
+func NewColumnMappingService(columnMappingRepo repository.ColumnMappingRepositoryInterface, ggSheetService *GSheetService, aiChatbotService *AIChatbotService) *ColumnMappingService {
+	return &ColumnMappingService{
+		columnMappingRepo: columnMappingRepo,
+		sheets:            ggSheetService,
+		suggester:         aiChatbotService,
+	}
+}
+
+// ProposeColumnMapping reads the headers of both sheets and records the
+// mapping to apply. It reports true when the mapping was confirmed before for
+// the same headers, the proposal can then be confirmed without asking.
+func (s *ColumnMappingService) ProposeColumnMapping(ctx context.Context, request dto.ColumnMappingRequest) (*models.ColumnMappingProposal, bool, error) {
+	sourceHeaders, _, err := s.sheets.ReadSheetData(request.SourceSheetURL)
+	if err != nil {
+		return nil, false, fmt.Errorf("error reading the candidate sheet: %w", err)
+	}
+	targetHeaders := defaultSkillSheetHeaders()
+	if request.TargetSheetURL != "" {
+		targetHeaders, _, err = s.sheets.ReadSheetData(request.TargetSheetURL)
+		if err != nil {
+			return nil, false, fmt.Errorf("error reading the skill sheet: %w", err)
+		}
+	}
+
+	signature := columnMappingSignature(sourceHeaders, targetHeaders)
+	cached := true
+	mappings := map[string]string{}
+	confirmed, err := s.columnMappingRepo.GetColumnMapping(signature)
+	switch {
+	case err == nil:
+		mappings = confirmed.Mappings
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		cached = false
+		mappings, err = s.suggester.SuggestColumnMappings(ctx, sourceHeaders, targetHeaders, request.SlackUserID, request.ChannelID)
+		if err != nil {
+			return nil, false, fmt.Errorf("error suggesting column mappings: %w", err)
+		}
+	default:
+		return nil, false, err
+	}
+
+	proposal := &models.ColumnMappingProposal{
+		ID:             uuid.NewString(),
+		Signature:      signature,
+		SourceSheetURL: request.SourceSheetURL,
+		TargetSheetURL: request.TargetSheetURL,
+		SourceHeaders:  sourceHeaders,
+		TargetHeaders:  targetHeaders,
+		Mappings:       mappings,
+		SlackUserID:    request.SlackUserID,
+		ChannelID:      request.ChannelID,
+		SlackThreadTs:  request.SlackThreadTs,
+	}
+	err = s.columnMappingRepo.CreateProposal(proposal)
+	if err != nil {
+		return nil, false, err
+	}
+	return proposal, cached, nil
+}
+
+// ConfirmColumnMapping caches the proposed mapping for its header signature
+// and copies the source rows into the target sheet. The rows are added below
+// the data of the skill sheet given, whose headers must still be the ones of
+// the proposal, or written with the headers into a new sheet.
+func (s *ColumnMappingService) ConfirmColumnMapping(ctx context.Context, proposalID string, slackUserID string) (*dto.CreateNewSheetResponse, error) {
+	proposal, err := s.answerProposal(proposalID, slackUserID, models.ColumnMappingProposalApplied)
+	if err != nil {
+		return nil, err
+	}
+	if proposal.TargetSheetURL != "" {
+		targetHeaders, _, err := s.sheets.ReadSheetData(proposal.TargetSheetURL)
+		if err != nil {
+			return nil, fmt.Errorf("error reading the skill sheet: %w", err)
+		}
+		if !sameHeaders(targetHeaders, proposal.TargetHeaders) {
+			return nil, ErrColumnMappingHeadersChanged
+		}
+	}
+	err = s.columnMappingRepo.SaveColumnMapping(&models.ColumnMapping{
+		Signature:     proposal.Signature,
+		SourceHeaders: proposal.SourceHeaders,
+		TargetHeaders: proposal.TargetHeaders,
+		Mappings:      proposal.Mappings,
+		ConfirmedBy:   slackUserID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sourceHeaders, rows, err := s.sheets.ReadSheetData(proposal.SourceSheetURL)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the candidate sheet: %w", err)
+	}
+	table := applyColumnMappings(sourceHeaders, rows, proposal.TargetHeaders, proposal.Mappings)
+	if proposal.TargetSheetURL != "" {
+		spreadsheetID, err := google_internal.ExtractSheetIdFromUrl(proposal.TargetSheetURL)
+		if err != nil {
+			return nil, err
+		}
+		if len(table) > 1 {
+			err = s.sheets.AppendSheetData(spreadsheetID, table[1:])
+			if err != nil {
+				return nil, err
+			}
+		}
+		return &dto.CreateNewSheetResponse{SpreadsheetId: spreadsheetID, SpreadsheetUrl: proposal.TargetSheetURL}, nil
+	}
+	newSheetName := fmt.Sprintf("New Employee Skill - %s", time.Now().Format("2006-01-02"))
+	target, err := s.sheets.CreateNewSheetInSharedDrive(newSheetName, SharedDriveFolderId)
+	if err != nil {
+		return nil, err
+	}
+	err = s.sheets.WriteSheetData(target.SpreadsheetId, "A1", table)
+	if err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+// CancelColumnMapping drops the proposal, nothing is cached.
+func (s *ColumnMappingService) CancelColumnMapping(proposalID string, slackUserID string) error {
+	_, err := s.answerProposal(proposalID, slackUserID, models.ColumnMappingProposalCancelled)
+	return err
+}
+
+func (s *ColumnMappingService) answerProposal(proposalID string, slackUserID string, status string) (*models.ColumnMappingProposal, error) {
+	proposal, err := s.columnMappingRepo.GetProposal(proposalID)
+	if err != nil {
+		return nil, err
+	}
+	if proposal.SlackUserID != slackUserID {
+		return nil, ErrColumnMappingNotOwner
+	}
+	updated, err := s.columnMappingRepo.UpdateProposalStatus(proposalID, models.ColumnMappingProposalPending, status)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, ErrColumnMappingAnswered
+	}
+	return proposal, nil
+}
+
+// defaultSkillSheetHeaders are the headers of the skill sheets created from
+// candidate sheets.
+func defaultSkillSheetHeaders() []string {
+	headers := []string{}
+	for _, header := range util.ParseStructToSheetTable([]dto.NewEmployeesSkills{{}})[0] {
+		headers = append(headers, fmt.Sprint(header))
+	}
+	return headers
+}
+
+// normalizeHeader lets headers differing only by case and surrounding spaces
+// match.
+func normalizeHeader(header string) string {
+	return strings.ToLower(strings.TrimSpace(header))
+}
+
+// sameHeaders reports whether the header lists match, up to normalizeHeader.
+func sameHeaders(headers []string, other []string) bool {
+	if len(headers) != len(other) {
+		return false
+	}
+	for i := range headers {
+		if normalizeHeader(headers[i]) != normalizeHeader(other[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// columnMappingSignature identifies a pair of header lists, up to
+// normalizeHeader.
+func columnMappingSignature(sourceHeaders []string, targetHeaders []string) string {
+	normalize := func(headers []string) string {
+		normalized := make([]string, len(headers))
+		for i, header := range headers {
+			normalized[i] = normalizeHeader(header)
+		}
+		return strings.Join(normalized, "\x1f")
+	}
+	sum := sha256.Sum256([]byte(normalize(sourceHeaders) + "\x1e" + normalize(targetHeaders)))
+	return hex.EncodeToString(sum[:])
+}
+
+// applyColumnMappings builds the target table: the target headers, then one
+// row per non blank source row with the cells of the mapped source columns.
+// Source headers are matched up to normalizeHeader, like the signature.
+func applyColumnMappings(sourceHeaders []string, rows [][]interface{}, targetHeaders []string, mappings map[string]string) [][]interface{} {
+	sourceIndex := map[string]int{}
+	for i, header := range sourceHeaders {
+		sourceIndex[normalizeHeader(header)] = i
+	}
+	headerRow := make([]interface{}, len(targetHeaders))
+	for i, header := range targetHeaders {
+		headerRow[i] = header
+	}
+	table := [][]interface{}{headerRow}
+	for _, row := range rows {
+		targetRow := make([]interface{}, len(targetHeaders))
+		blank := true
+		for i, header := range targetHeaders {
+			targetRow[i] = ""
+			source, mapped := mappings[header]
+			if !mapped {
+				continue
+			}
+			index, ok := sourceIndex[normalizeHeader(source)]
+			if !ok || index >= len(row) {
+				continue
+			}
+			targetRow[i] = row[index]
+			if strings.TrimSpace(fmt.Sprint(row[index])) != "" {
+				blank = false
+			}
+		}
+		if !blank {
+			table = append(table, targetRow)
+		}
+	}
+	return table
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/models"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type fakeColumnMappingRepository struct {
+	mappings  map[string]models.ColumnMapping
+	proposals map[string]models.ColumnMappingProposal
+}
+
+func (f *fakeColumnMappingRepository) GetColumnMapping(signature string) (*models.ColumnMapping, error) {
+	mapping, ok := f.mappings[signature]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &mapping, nil
+}
+
+func (f *fakeColumnMappingRepository) SaveColumnMapping(mapping *models.ColumnMapping) error {
+	f.mappings[mapping.Signature] = *mapping
+	return nil
+}
+
+func (f *fakeColumnMappingRepository) CreateProposal(proposal *models.ColumnMappingProposal) error {
+	proposal.Status = models.ColumnMappingProposalPending
+	f.proposals[proposal.ID] = *proposal
+	return nil
+}
+
+func (f *fakeColumnMappingRepository) GetProposal(proposalID string) (*models.ColumnMappingProposal, error) {
+	proposal, ok := f.proposals[proposalID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &proposal, nil
+}
+
+func (f *fakeColumnMappingRepository) UpdateProposalStatus(proposalID string, fromStatus string, toStatus string) (bool, error) {
+	proposal, ok := f.proposals[proposalID]
+	if !ok || proposal.Status != fromStatus {
+		return false, nil
+	}
+	proposal.Status = toStatus
+	f.proposals[proposalID] = proposal
+	return true, nil
+}
+
+type fakeColumnMappingSheets struct {
+	headers  map[string][]string
+	rows     map[string][][]interface{}
+	written  map[string][][]interface{}
+	appended map[string][][]interface{}
+}
+
+func (f *fakeColumnMappingSheets) ReadSheetData(sheetUrl string) ([]string, [][]interface{}, error) {
+	return f.headers[sheetUrl], f.rows[sheetUrl], nil
+}
+
+func (f *fakeColumnMappingSheets) WriteSheetData(spreadsheetID string, writeRange string, rows [][]interface{}) error {
+	f.written[spreadsheetID] = rows
+	return nil
+}
+
+func (f *fakeColumnMappingSheets) AppendSheetData(spreadsheetID string, rows [][]interface{}) error {
+	f.appended[spreadsheetID] = append(f.appended[spreadsheetID], rows...)
+	return nil
+}
+
+func (f *fakeColumnMappingSheets) CreateNewSheetInSharedDrive(sheetName string, sharedDriveFolderId string) (*dto.CreateNewSheetResponse, error) {
+	return &dto.CreateNewSheetResponse{SpreadsheetId: "new_sheet", SpreadsheetUrl: "https://docs.google.com/spreadsheets/d/new_sheet"}, nil
+}
+
+type fakeColumnMappingSuggester struct {
+	mappings map[string]string
+	calls    int
+}
+
+func (f *fakeColumnMappingSuggester) SuggestColumnMappings(ctx context.Context, sourceHeaders []string, targetHeaders []string, slackUserID string, channelID string) (map[string]string, error) {
+	f.calls++
+	return f.mappings, nil
+}
+
+func TestColumnMappingService(t *testing.T) {
+	const candidateSheet = "https://docs.google.com/spreadsheets/d/candidates"
+	const skillSheet = "https://docs.google.com/spreadsheets/d/skills"
+	repo := &fakeColumnMappingRepository{mappings: map[string]models.ColumnMapping{}, proposals: map[string]models.ColumnMappingProposal{}}
+	sheets := &fakeColumnMappingSheets{
+		headers: map[string][]string{
+			candidateSheet: {"STT", "Họ tên", "Email cá nhân"},
+			skillSheet:     {"Full Name", "Email", "Level"},
+		},
+		rows: map[string][][]interface{}{
+			candidateSheet: {{"1", "Nguyễn Văn A", "a@example.com"}, {"", "", ""}, {"2", "Trần Thị B"}},
+			skillSheet:     {{"Phạm Văn D", "d@example.com", "Senior"}},
+		},
+		written:  map[string][][]interface{}{},
+		appended: map[string][][]interface{}{},
+	}
+	suggester := &fakeColumnMappingSuggester{mappings: map[string]string{"Full Name": "Họ tên", "Email": "Email cá nhân"}}
+	service := &ColumnMappingService{columnMappingRepo: repo, sheets: sheets, suggester: suggester}
+	request := dto.ColumnMappingRequest{SourceSheetURL: candidateSheet, TargetSheetURL: skillSheet, SlackUserID: "U1"}
+	ctx := context.Background()
+
+	proposal, cached, err := service.ProposeColumnMapping(ctx, request)
+	assert.NoError(t, err)
+	assert.False(t, cached)
+	assert.Equal(t, suggester.mappings, proposal.Mappings)
+
+	_, err = service.ConfirmColumnMapping(ctx, proposal.ID, "U2")
+	assert.ErrorIs(t, err, ErrColumnMappingNotOwner)
+
+	skillFile, err := service.ConfirmColumnMapping(ctx, proposal.ID, "U1")
+	assert.NoError(t, err)
+	assert.Equal(t, skillSheet, skillFile.SpreadsheetUrl)
+	// The rows already in the skill sheet are kept, the new ones go below.
+	assert.Empty(t, sheets.written)
+	assert.Equal(t, [][]interface{}{
+		{"Nguyễn Văn A", "a@example.com", ""},
+		{"Trần Thị B", "", ""},
+	}, sheets.appended["skills"])
+
+	_, err = service.ConfirmColumnMapping(ctx, proposal.ID, "U1")
+	assert.ErrorIs(t, err, ErrColumnMappingAnswered)
+
+	// Same headers, up to case and spaces: the confirmed mapping is reused.
+	sheets.headers[candidateSheet] = []string{"stt", " Họ tên", "Email cá nhân "}
+	proposal, cached, err = service.ProposeColumnMapping(ctx, request)
+	assert.NoError(t, err)
+	assert.True(t, cached)
+	assert.Equal(t, suggester.mappings, proposal.Mappings)
+	assert.Equal(t, 1, suggester.calls)
+	sheets.rows[candidateSheet] = [][]interface{}{{"3", "Lê Văn C", "c@example.com"}}
+	_, err = service.ConfirmColumnMapping(ctx, proposal.ID, "U1")
+	assert.NoError(t, err)
+	assert.Len(t, sheets.appended["skills"], 3)
+	assert.Equal(t, []interface{}{"Lê Văn C", "c@example.com", ""}, sheets.appended["skills"][2])
+
+	// Nothing is written when the skill sheet headers changed meanwhile.
+	proposal, _, err = service.ProposeColumnMapping(ctx, request)
+	assert.NoError(t, err)
+	sheets.headers[skillSheet] = []string{"Full Name", "Level"}
+	_, err = service.ConfirmColumnMapping(ctx, proposal.ID, "U1")
+	assert.ErrorIs(t, err, ErrColumnMappingHeadersChanged)
+	assert.Len(t, sheets.appended["skills"], 3)
+	sheets.headers[skillSheet] = []string{"Full Name", "Email", "Level"}
+
+	// Without a skill sheet a new one is filled with the default headers.
+	request.TargetSheetURL = ""
+	proposal, cached, err = service.ProposeColumnMapping(ctx, request)
+	assert.NoError(t, err)
+	assert.False(t, cached)
+	assert.Equal(t, defaultSkillSheetHeaders(), proposal.TargetHeaders)
+	assert.NoError(t, service.CancelColumnMapping(proposal.ID, "U1"))
+	_, err = service.ConfirmColumnMapping(ctx, proposal.ID, "U1")
+	assert.ErrorIs(t, err, ErrColumnMappingAnswered)
+	proposal, _, err = service.ProposeColumnMapping(ctx, request)
+	assert.NoError(t, err)
+	skillFile, err = service.ConfirmColumnMapping(ctx, proposal.ID, "U1")
+	assert.NoError(t, err)
+	assert.Equal(t, "new_sheet", skillFile.SpreadsheetId)
+	assert.Equal(t, defaultSkillSheetHeaders()[0], sheets.written["new_sheet"][0][0])
+}
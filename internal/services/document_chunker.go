@@ -0,0 +1,226 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+)
+
+// maxChunkRunes is the size of the passages given to the assistant, large
+// enough to hold a policy rule with its conditions.
+const maxChunkRunes = 1200
+
+// documentSection is a titled part of a document. Anchor is the URL fragment
+// pointing to it, empty when the section cannot be linked.
+type documentSection struct {
+	Title  string
+	Anchor string
+	Text   string
+}
+
+var (
+	markdownHeading    = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*(?:\{#([^}]+)\})?\s*#*\s*$`)
+	paragraphSeparator = regexp.MustCompile(`\n\s*\n`)
+	htmlWhitespace     = regexp.MustCompile(`\s+`)
+)
+
+// parseDocument splits a policy document into sections. Markdown and HTML,
+// as exported by Google Docs, are split on their headings; text, e.g.
+// extracted from a PDF, on its form feeds, one section per page. The title is
+// the first level one heading, or the file name.
+func parseDocument(filename string, content []byte) (string, []documentSection, error) {
+	fallbackTitle := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".md", ".markdown":
+		title, sections := markdownSections(string(content), fallbackTitle)
+		return title, sections, nil
+	case ".html", ".htm":
+		text, err := htmlToMarkdown(content)
+		if err != nil {
+			return "", nil, err
+		}
+		title, sections := markdownSections(text, fallbackTitle)
+		return title, sections, nil
+	case ".txt":
+		return fallbackTitle, pageSections(string(content)), nil
+	default:
+		return "", nil, fmt.Errorf("unsupported document format: %s", filename)
+	}
+}
+
+func markdownSections(text string, fallbackTitle string) (string, []documentSection) {
+	title := ""
+	sections := []documentSection{}
+	current := documentSection{}
+	inCode := false
+	body := []string{}
+	flush := func() {
+		current.Text = strings.TrimSpace(strings.Join(body, "\n"))
+		if current.Text != "" {
+			sections = append(sections, current)
+		}
+		body = nil
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+		}
+		match := markdownHeading.FindStringSubmatch(line)
+		if inCode || match == nil {
+			body = append(body, line)
+			continue
+		}
+		flush()
+		heading, anchor := match[2], match[3]
+		if anchor == "" {
+			anchor = anchorSlug(heading)
+		}
+		if len(match[1]) == 1 && title == "" {
+			title = heading
+		}
+		current = documentSection{Title: heading, Anchor: anchor}
+	}
+	flush()
+	if title == "" {
+		title = fallbackTitle
+	}
+	return title, sections
+}
+
+func pageSections(text string) []documentSection {
+	pages := strings.Split(text, "\f")
+	sections := []documentSection{}
+	for i, page := range pages {
+		page = strings.TrimSpace(page)
+		if page == "" {
+			continue
+		}
+		section := documentSection{Text: page}
+		if len(pages) > 1 {
+			section.Title = fmt.Sprintf("Page %d", i+1)
+			section.Anchor = fmt.Sprintf("page=%d", i+1)
+		}
+		sections = append(sections, section)
+	}
+	return sections
+}
+
+// htmlToMarkdown keeps the text and the headings of an HTML document. The id
+// of a heading is kept as its anchor, Google Docs exports link sections that
+// way.
+func htmlToMarkdown(content []byte) (string, error) {
+	var out strings.Builder
+	tokenizer := html.NewTokenizer(bytes.NewReader(content))
+	skip := 0
+	headingID := ""
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			if tokenizer.Err() == io.EOF {
+				lines := strings.Split(out.String(), "\n")
+				for i, line := range lines {
+					lines[i] = strings.TrimSpace(line)
+				}
+				return strings.Join(lines, "\n"), nil
+			}
+			return "", tokenizer.Err()
+		case html.StartTagToken:
+			token := tokenizer.Token()
+			switch token.Data {
+			case "script", "style", "head":
+				skip++
+			case "h1", "h2", "h3", "h4", "h5", "h6":
+				out.WriteString("\n\n" + strings.Repeat("#", int(token.Data[1]-'0')) + " ")
+				for _, attr := range token.Attr {
+					if attr.Key == "id" {
+						headingID = attr.Val
+					}
+				}
+			case "li":
+				out.WriteString("\n- ")
+			case "br":
+				out.WriteString("\n")
+			}
+		case html.EndTagToken:
+			token := tokenizer.Token()
+			switch token.Data {
+			case "script", "style", "head":
+				if skip > 0 {
+					skip--
+				}
+			case "h1", "h2", "h3", "h4", "h5", "h6":
+				if headingID != "" {
+					out.WriteString(" {#" + headingID + "}")
+					headingID = ""
+				}
+				out.WriteString("\n\n")
+			case "p", "div", "tr", "ul", "ol", "table":
+				out.WriteString("\n\n")
+			}
+		case html.TextToken:
+			if skip == 0 {
+				out.WriteString(htmlWhitespace.ReplaceAllString(string(tokenizer.Text()), " "))
+			}
+		}
+	}
+}
+
+// anchorSlug builds the anchor Markdown renderers give a heading: lower case,
+// punctuation removed and spaces turned into dashes.
+func anchorSlug(heading string) string {
+	var slug strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(heading)) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsNumber(r), unicode.Is(unicode.Mn, r), r == '-', r == '_':
+			slug.WriteRune(r)
+		case unicode.IsSpace(r):
+			slug.WriteRune('-')
+		}
+	}
+	return slug.String()
+}
+
+// splitChunks cuts a section into passages of at most maxRunes, on paragraph
+// boundaries when possible and on words otherwise.
+func splitChunks(text string, maxRunes int) []string {
+	chunks := []string{}
+	current := ""
+	add := func(part string, separator string) {
+		if current != "" && len([]rune(current))+len([]rune(separator))+len([]rune(part)) > maxRunes {
+			chunks = append(chunks, current)
+			current = ""
+		}
+		if current == "" {
+			current = part
+		} else {
+			current += separator + part
+		}
+	}
+	for _, paragraph := range paragraphSeparator.Split(text, -1) {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		if len([]rune(paragraph)) <= maxRunes {
+			add(paragraph, "\n\n")
+			continue
+		}
+		for i, word := range strings.Fields(paragraph) {
+			if i == 0 {
+				add(word, "\n\n")
+			} else {
+				add(word, " ")
+			}
+		}
+	}
+	if current != "" {
+		chunks = append(chunks, current)
+	}
+	return chunks
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDocument(t *testing.T) {
+	tests := []struct {
+		name          string
+		filename      string
+		content       string
+		expectedTitle string
+		expected      []documentSection
+		expectedErr   bool
+	}{
+		{
+			name:          "markdown",
+			filename:      "docs/leave-policy.md",
+			content:       "# Leave policy\n\nApplies to all employees.\n\n## Annual leave\n\nTwelve days per year.\n\n```\n# not a heading\n```\n\n## Nghỉ ốm {#sick}\n\nUp to 30 days.\n",
+			expectedTitle: "Leave policy",
+			expected: []documentSection{
+				{Title: "Leave policy", Anchor: "leave-policy", Text: "Applies to all employees."},
+				{Title: "Annual leave", Anchor: "annual-leave", Text: "Twelve days per year.\n\n```\n# not a heading\n```"},
+				{Title: "Nghỉ ốm", Anchor: "sick", Text: "Up to 30 days."},
+			},
+		},
+		{
+			name:          "google docs html export",
+			filename:      "benefits.html",
+			content:       `<html><head><style>p{}</style></head><body><h1 id="h.1">Benefits</h1><p>Health <b>insurance</b> for all.</p><h2 id="h.2">Gym</h2><ul><li>One club</li><li>Yoga</li></ul></body></html>`,
+			expectedTitle: "Benefits",
+			expected: []documentSection{
+				{Title: "Benefits", Anchor: "h.1", Text: "Health insurance for all."},
+				{Title: "Gym", Anchor: "h.2", Text: "- One club\n- Yoga"},
+			},
+		},
+		{
+			name:          "pdf text",
+			filename:      "handbook.txt",
+			content:       "Working hours are 9 to 6.\fRemote work needs approval.\f",
+			expectedTitle: "handbook",
+			expected: []documentSection{
+				{Title: "Page 1", Anchor: "page=1", Text: "Working hours are 9 to 6."},
+				{Title: "Page 2", Anchor: "page=2", Text: "Remote work needs approval."},
+			},
+		},
+		{
+			name:        "unsupported format",
+			filename:    "handbook.pdf",
+			content:     "%PDF",
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			title, sections, err := parseDocument(tt.filename, []byte(tt.content))
+			if tt.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedTitle, title)
+			assert.Equal(t, tt.expected, sections)
+		})
+	}
+}
+
+func TestSplitChunks(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		maxRunes int
+		expected []string
+	}{
+		{
+			name:     "short paragraphs are kept together",
+			text:     "First rule.\n\nSecond rule.",
+			maxRunes: 100,
+			expected: []string{"First rule.\n\nSecond rule."},
+		},
+		{
+			name:     "split on paragraphs",
+			text:     "First rule.\n\nSecond rule.\n\n\nThird rule.",
+			maxRunes: 25,
+			expected: []string{"First rule.\n\nSecond rule.", "Third rule."},
+		},
+		{
+			name:     "long paragraph split on words",
+			text:     strings.Repeat("word ", 5),
+			maxRunes: 10,
+			expected: []string{"word word", "word word", "word"},
+		},
+		{
+			name:     "empty",
+			text:     "\n\n",
+			maxRunes: 10,
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, splitChunks(tt.text, tt.maxRunes))
+		})
+	}
+}
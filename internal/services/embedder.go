@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/config"
+)
+
+// Embedder turns texts into vectors, close for texts of close meaning.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// NewEmbedder returns the embedder of RAG_EMBEDDING_DEPLOYMENT, or nil when no
+// deployment is configured.
+func NewEmbedder(ragConfig config.RAGConfig, azureOpenAIConfig config.AzureOpenAIConfig) Embedder {
+	if ragConfig.EmbeddingDeployment == "" {
+		return nil
+	}
+	return NewAzureEmbedder(newAzureOpenAIHTTPClient(azureOpenAIConfig), azureOpenAIConfig, ragConfig.EmbeddingDeployment)
+}
+
+// AzureEmbedder embeds texts with an Azure OpenAI embeddings deployment.
+type AzureEmbedder struct {
+	client     *azureOpenAIClient
+	deployment string
+}
+
+func NewAzureEmbedder(client *http.Client, azureOpenAIConfig config.AzureOpenAIConfig, deployment string) *AzureEmbedder {
+	return &AzureEmbedder{
+		client:     newAzureOpenAIClient(client, azureOpenAIConfig),
+		deployment: deployment,
+	}
+}
+
+func (e *AzureEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	response := struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}{}
+	err := e.client.doJSON(ctx, "POST", fmt.Sprintf("deployments/%s/embeddings", e.deployment), map[string]interface{}{"input": texts}, &response)
+	if err != nil {
+		return nil, err
+	}
+	embeddings := make([][]float32, len(texts))
+	for _, data := range response.Data {
+		if data.Index < 0 || data.Index >= len(texts) {
+			return nil, fmt.Errorf("embedding index %d out of range", data.Index)
+		}
+		embeddings[data.Index] = data.Embedding
+	}
+	return embeddings, nil
+}
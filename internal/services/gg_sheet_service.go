@@ -3,6 +3,7 @@ package services
 import (
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
@@ -27,6 +28,8 @@ type IGSheetService interface {
 	CreateNewSheetInSharedDrive(sheetName string, sharedDriveFolderId string) (*dto.CreateNewSheetResponse, error)
 	InsertDataToSheet(spreadsheetID string, sheetName string, data []dto.SheetCandidateOffer) error
 	HandleFileCandidateOffer(sheetUrl string) (*dto.CreateNewSheetResponse, error)
+	ReadSheetData(sheetUrl string) ([]string, [][]interface{}, error)
+	WriteSheetData(spreadsheetID string, writeRange string, rows [][]interface{}) error
 }
 
 func NewGSheetService(service *sheets.Service, driveService *drive.Service) *GSheetService {
@@ -149,97 +152,45 @@ func (s *GSheetService) HandleFileCandidateOffer(sheetUrl string) (*dto.CreateNe
 	return newEmployeeSkillFile, nil
 }
 
-// func (s *GSheetService) ReadSheetData(sheetUrl string) ([][]interface{}, []string, error) {
-// 	sheetID, err := google_internal.ExtractSheetIdFromUrl(sheetUrl)
-// 	if err != nil {
-// 		return nil, nil, err
-// 	}
-
-// 	resp, err := s.SheetService.Spreadsheets.Values.Get(sheetID, "A1:ZZ").Do()
-// 	if err != nil {
-// 		return nil, nil, err
-// 	}
-
-// 	if len(resp.Values) == 0 {
-// 		return nil, nil, fmt.Errorf("no data found")
-// 	}
-
-// 	headers := make([]string, len(resp.Values[0]))
-// 	for i, v := range resp.Values[0] {
-// 		headers[i] = fmt.Sprint(v)
-// 	}
-
-// 	return resp.Values[1:], headers, nil
-// }
-
-// func (s *GSheetService) applyMappings(candidateData [][]interface{}, mappings map[string]string) [][]interface{} {
-// 	var skillData [][]interface{}
-
-// 	// Create header row
-// 	headerRow := make([]interface{}, len(mappings))
-// 	for skillCol, _ := range mappings {
-// 		headerRow = append(headerRow, skillCol)
-// 	}
-// 	skillData = append(skillData, headerRow)
-
-// 	// Map data
-// 	for _, row := range candidateData {
-// 		newRow := make([]interface{}, len(mappings))
-// 		for skillCol, candidateCol := range mappings {
-// 			index := s.findIndex(candidateData[0], candidateCol)
-// 			if index != -1 && index < len(row) {
-// 				newRow = append(newRow, row[index])
-// 			} else {
-// 				newRow = append(newRow, "")
-// 			}
-// 		}
-// 		skillData = append(skillData, newRow)
-// 	}
-
-// 	return skillData
-// }
-
-// func (s *GSheetService) findIndex(slice []interface{}, value string) int {
-// 	for i, v := range slice {
-// 		if fmt.Sprint(v) == value {
-// 			return i
-// 		}
-// 	}
-// 	return -1
-// }
-
-// func (s *GSheetService) AIAssistedFillSkillFile(candidateSheetUrl, skillSheetUrl string) error {
-// 	// Read candidate data
-// 	candidateData, candidateHeaders, err := s.ReadSheetData(candidateSheetUrl)
-// 	if err != nil {
-// 		return fmt.Errorf("error reading candidate data: %v", err)
-// 	}
-
-// 	// Read skill sheet headers
-// 	_, skillHeaders, err := s.ReadSheetData(skillSheetUrl)
-// 	if err != nil {
-// 		return fmt.Errorf("error reading skill sheet headers: %v", err)
-// 	}
-
-// 	// Use AI to suggest mappings
-// 	mappings, err := ai_service.SuggestColumnMappings(candidateHeaders, skillHeaders)
-// 	if err != nil {
-// 		return fmt.Errorf("error suggesting column mappings: %v", err)
-// 	}
-
-// 	// Apply mappings to create skill data
-// 	skillData := s.applyMappings(candidateData, mappings)
-
-// 	// Insert data into skill sheet
-// 	skillSheetID, err := google_internal.ExtractSheetIdFromUrl(skillSheetUrl)
-// 	if err != nil {
-// 		return fmt.Errorf("error extracting skill sheet ID: %v", err)
-// 	}
-
-// 	err = s.InsertDataToSheet(skillSheetID, "A1", skillData)
-// 	if err != nil {
-// 		return fmt.Errorf("error inserting data into skill sheet: %v", err)
-// 	}
-
-// 	return nil
-// }
+// ReadSheetData reads the first tab of a sheet. The first row holds the
+// headers, the other ones are returned as they are, short rows included.
+func (s *GSheetService) ReadSheetData(sheetUrl string) ([]string, [][]interface{}, error) {
+	spreadsheetID, err := google_internal.ExtractSheetIdFromUrl(sheetUrl)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := s.SheetService.Spreadsheets.Values.Get(spreadsheetID, "A1:ZZ").Do()
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to read sheet: %w", err)
+	}
+	if len(resp.Values) == 0 {
+		return nil, nil, fmt.Errorf("no data found in sheet %s", sheetUrl)
+	}
+
+	headers := make([]string, len(resp.Values[0]))
+	for i, value := range resp.Values[0] {
+		headers[i] = strings.TrimSpace(fmt.Sprint(value))
+	}
+	return headers, resp.Values[1:], nil
+}
+
+// AppendSheetData adds the rows below the data of the first tab, the rows
+// already there are kept.
+func (s *GSheetService) AppendSheetData(spreadsheetID string, rows [][]interface{}) error {
+	vr := sheets.ValueRange{Values: rows}
+	_, err := s.SheetService.Spreadsheets.Values.Append(spreadsheetID, "A1", &vr).ValueInputOption("RAW").InsertDataOption("INSERT_ROWS").Do()
+	if err != nil {
+		return fmt.Errorf("unable to append to sheet: %w", err)
+	}
+	return nil
+}
+
+// WriteSheetData writes the rows starting at writeRange, e.g. "A1".
+func (s *GSheetService) WriteSheetData(spreadsheetID string, writeRange string, rows [][]interface{}) error {
+	vr := sheets.ValueRange{Values: rows}
+	_, err := s.SheetService.Spreadsheets.Values.Update(spreadsheetID, writeRange, &vr).ValueInputOption("RAW").Do()
+	if err != nil {
+		return fmt.Errorf("unable to write sheet: %w", err)
+	}
+	return nil
+}
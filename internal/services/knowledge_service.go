@@ -0,0 +1,265 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/config"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/i18n"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/models"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/repository"
+)
+
+const (
+	// embeddingBatchSize is the number of chunks embedded per request.
+	embeddingBatchSize = 16
+	// rankFusionOffset damps the weight of the first ranks when the keyword
+	// and embedding rankings are merged, 60 is the usual choice.
+	rankFusionOffset = 60
+)
+
+var passageCitation = regexp.MustCompile(`\[S(\d+)\]`)
+
+// KnowledgeService indexes the company policy documents and finds the
+// passages answering a question. Passages are matched by keywords with the
+// Postgres full text search and, when an embedder is set, by meaning; both
+// rankings are merged.
+type KnowledgeService struct {
+	documentChunkRepo repository.DocumentChunkRepositoryInterface
+	embedder          Embedder
+	topK              int
+	// The embedded chunks are ranked in memory. They are listed again only
+	// when their version changed, e.g. after an ingestion by cmd/ingest.
+	mu              sync.Mutex
+	embedded        []models.DocumentChunk
+	embeddedVersion *repository.DocumentChunksVersion
+}
+
+// NewKnowledgeService creates the service; embedder may be nil for keyword
+// search only.
+func NewKnowledgeService(documentChunkRepo repository.DocumentChunkRepositoryInterface, embedder Embedder, ragConfig config.RAGConfig) *KnowledgeService {
+	return &KnowledgeService{
+		documentChunkRepo: documentChunkRepo,
+		embedder:          embedder,
+		topK:              ragConfig.TopK,
+	}
+}
+
+// IngestDocument splits a document into passages and replaces the passages
+// previously ingested from the same source. url is where the document is
+// published, the passages link to their section in it. It returns the number
+// of passages indexed.
+func (s *KnowledgeService) IngestDocument(ctx context.Context, source string, url string, filename string, content []byte) (int, error) {
+	title, sections, err := parseDocument(filename, content)
+	if err != nil {
+		return 0, err
+	}
+	chunks := []models.DocumentChunk{}
+	for _, section := range sections {
+		sectionURL := url
+		if url != "" && section.Anchor != "" {
+			sectionURL = url + "#" + section.Anchor
+		}
+		for _, text := range splitChunks(section.Text, maxChunkRunes) {
+			chunks = append(chunks, models.DocumentChunk{
+				Source:   source,
+				Title:    title,
+				Section:  section.Title,
+				URL:      sectionURL,
+				Position: len(chunks),
+				Content:  text,
+			})
+		}
+	}
+
+	if s.embedder != nil {
+		for start := 0; start < len(chunks); start += embeddingBatchSize {
+			end := min(start+embeddingBatchSize, len(chunks))
+			texts := []string{}
+			for _, chunk := range chunks[start:end] {
+				texts = append(texts, chunkEmbeddingText(chunk))
+			}
+			embeddings, err := s.embedder.Embed(ctx, texts)
+			if err != nil {
+				return 0, fmt.Errorf("cannot embed %s: %w", source, err)
+			}
+			for i, embedding := range embeddings {
+				chunks[start+i].Embedding = embedding
+			}
+		}
+	}
+
+	err = s.documentChunkRepo.ReplaceSourceChunks(source, chunks)
+	if err != nil {
+		return 0, err
+	}
+	return len(chunks), nil
+}
+
+// Search returns the passages best matching the question, at most RAG_TOP_K.
+func (s *KnowledgeService) Search(ctx context.Context, question string) ([]models.DocumentChunk, error) {
+	if s.topK <= 0 || strings.TrimSpace(question) == "" {
+		return []models.DocumentChunk{}, nil
+	}
+	rankings := [][]models.DocumentChunk{}
+	keywordMatches, err := s.documentChunkRepo.SearchChunks(question, 2*s.topK)
+	if err != nil {
+		return nil, err
+	}
+	rankings = append(rankings, keywordMatches)
+
+	if s.embedder != nil {
+		embeddings, err := s.embedder.Embed(ctx, []string{question})
+		if err != nil {
+			return nil, err
+		}
+		chunks, err := s.embeddedChunks()
+		if err != nil {
+			return nil, err
+		}
+		if len(embeddings) == 1 {
+			rankings = append(rankings, nearestChunks(embeddings[0], chunks, 2*s.topK))
+		}
+	}
+	return fuseRankings(rankings, s.topK), nil
+}
+
+// embeddedChunks returns the chunks having an embedding, from memory unless
+// the chunks changed since they were listed.
+func (s *KnowledgeService) embeddedChunks() ([]models.DocumentChunk, error) {
+	version, err := s.documentChunkRepo.GetChunksVersion()
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.embeddedVersion != nil && *s.embeddedVersion == version {
+		return s.embedded, nil
+	}
+	chunks, err := s.documentChunkRepo.ListChunksWithEmbedding()
+	if err != nil {
+		return nil, err
+	}
+	s.embedded, s.embeddedVersion = chunks, &version
+	return chunks, nil
+}
+
+// chunkEmbeddingText is the text embedded for a chunk, its headings give the
+// context the passage alone may lack.
+func chunkEmbeddingText(chunk models.DocumentChunk) string {
+	return strings.TrimSpace(chunk.Title + "\n" + chunk.Section + "\n" + chunk.Content)
+}
+
+// nearestChunks returns the limit chunks the most similar to the query
+// embedding, by cosine similarity.
+func nearestChunks(query []float32, chunks []models.DocumentChunk, limit int) []models.DocumentChunk {
+	similarities := make(map[uint]float64, len(chunks))
+	for _, chunk := range chunks {
+		similarities[chunk.ID] = cosineSimilarity(query, chunk.Embedding)
+	}
+	nearest := append([]models.DocumentChunk{}, chunks...)
+	sort.SliceStable(nearest, func(i, j int) bool {
+		return similarities[nearest[i].ID] > similarities[nearest[j].ID]
+	})
+	if len(nearest) > limit {
+		nearest = nearest[:limit]
+	}
+	return nearest
+}
+
+func cosineSimilarity(a []float32, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// fuseRankings merges rankings with reciprocal rank fusion: a chunk scores
+// 1/(rankFusionOffset+rank) in every ranking it appears in. Ties keep the
+// order of the first ranking.
+func fuseRankings(rankings [][]models.DocumentChunk, limit int) []models.DocumentChunk {
+	scores := map[uint]float64{}
+	fused := []models.DocumentChunk{}
+	for _, ranking := range rankings {
+		for rank, chunk := range ranking {
+			if _, ok := scores[chunk.ID]; !ok {
+				fused = append(fused, chunk)
+			}
+			scores[chunk.ID] += 1 / float64(rankFusionOffset+rank+1)
+		}
+	}
+	sort.SliceStable(fused, func(i, j int) bool {
+		return scores[fused[i].ID] > scores[fused[j].ID]
+	})
+	if len(fused) > limit {
+		fused = fused[:limit]
+	}
+	return fused
+}
+
+// knowledgeInstructions are the run instructions giving the passages to the
+// assistant, numbered [S1], [S2]... for the citations.
+func knowledgeInstructions(passages []models.DocumentChunk) string {
+	if len(passages) == 0 {
+		return ""
+	}
+	var instructions strings.Builder
+	instructions.WriteString("Answer questions about company policies from the passages below only. ")
+	instructions.WriteString("Cite the passages you use as [S1], [S2]. ")
+	instructions.WriteString("If they do not answer the question, say so instead of guessing.\n")
+	for i, passage := range passages {
+		fmt.Fprintf(&instructions, "\n[S%d] %s\n%s\n", i+1, passageHeading(passage, " — "), passage.Content)
+	}
+	return instructions.String()
+}
+
+// knowledgeSources is the Slack message linking to the sections of the
+// passages cited in the replies, empty when none is cited.
+func knowledgeSources(passages []models.DocumentChunk, replies []string, locale i18n.Locale) string {
+	cited := map[int]bool{}
+	for _, reply := range replies {
+		for _, match := range passageCitation.FindAllStringSubmatch(reply, -1) {
+			index, err := strconv.Atoi(match[1])
+			if err == nil && index >= 1 && index <= len(passages) {
+				cited[index] = true
+			}
+		}
+	}
+	if len(cited) == 0 {
+		return ""
+	}
+	lines := []string{i18n.T(locale, "chatbot.sources")}
+	for i, passage := range passages {
+		if !cited[i+1] {
+			continue
+		}
+		heading := passageHeading(passage, " › ")
+		if passage.URL != "" {
+			heading = fmt.Sprintf("<%s|%s>", passage.URL, heading)
+		}
+		lines = append(lines, fmt.Sprintf("[S%d] %s", i+1, heading))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func passageHeading(passage models.DocumentChunk, separator string) string {
+	if passage.Section == "" || passage.Section == passage.Title {
+		return passage.Title
+	}
+	return passage.Title + separator + passage.Section
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/config"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/i18n"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/models"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeDocumentChunkRepository struct {
+	chunks         []models.DocumentChunk
+	keywordMatches []models.DocumentChunk
+	version        repository.DocumentChunksVersion
+	listed         int
+}
+
+func (f *fakeDocumentChunkRepository) ReplaceSourceChunks(source string, chunks []models.DocumentChunk) error {
+	kept := []models.DocumentChunk{}
+	for _, chunk := range f.chunks {
+		if chunk.Source != source {
+			kept = append(kept, chunk)
+		}
+	}
+	f.chunks = append(kept, chunks...)
+	return nil
+}
+
+func (f *fakeDocumentChunkRepository) SearchChunks(query string, limit int) ([]models.DocumentChunk, error) {
+	return f.keywordMatches, nil
+}
+
+func (f *fakeDocumentChunkRepository) ListChunksWithEmbedding() ([]models.DocumentChunk, error) {
+	f.listed++
+	return f.chunks, nil
+}
+
+func (f *fakeDocumentChunkRepository) GetChunksVersion() (repository.DocumentChunksVersion, error) {
+	return f.version, nil
+}
+
+// fakeEmbedder embeds a text as the counts of the words "leave" and "salary".
+type fakeEmbedder struct{}
+
+func (fakeEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings := [][]float32{}
+	for _, text := range texts {
+		text = strings.ToLower(text)
+		embeddings = append(embeddings, []float32{
+			float32(strings.Count(text, "leave")),
+			float32(strings.Count(text, "salary")),
+		})
+	}
+	return embeddings, nil
+}
+
+func TestKnowledgeServiceIngestDocument(t *testing.T) {
+	repo := &fakeDocumentChunkRepository{chunks: []models.DocumentChunk{
+		{Source: "leave.md", Content: "outdated"},
+		{Source: "salary.md", Content: "kept"},
+	}}
+	service := NewKnowledgeService(repo, fakeEmbedder{}, config.RAGConfig{TopK: 2})
+
+	count, err := service.IngestDocument(context.Background(), "leave.md", "https://wiki/leave", "leave.md", []byte("# Leave\n\n## Annual leave\n\nTwelve days of leave."))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, []models.DocumentChunk{
+		{Source: "salary.md", Content: "kept"},
+		{
+			Source:    "leave.md",
+			Title:     "Leave",
+			Section:   "Annual leave",
+			URL:       "https://wiki/leave#annual-leave",
+			Content:   "Twelve days of leave.",
+			Embedding: []float32{3, 0},
+		},
+	}, repo.chunks)
+}
+
+func TestKnowledgeServiceSearch(t *testing.T) {
+	leave := models.DocumentChunk{ID: 1, Content: "leave", Embedding: []float32{1, 0}}
+	salary := models.DocumentChunk{ID: 2, Content: "salary", Embedding: []float32{0, 1}}
+	both := models.DocumentChunk{ID: 3, Content: "leave and salary", Embedding: []float32{1, 1}}
+
+	tests := []struct {
+		name           string
+		embedder       Embedder
+		keywordMatches []models.DocumentChunk
+		question       string
+		expected       []uint
+	}{
+		{
+			name:           "keywords only",
+			keywordMatches: []models.DocumentChunk{salary, both},
+			question:       "salary",
+			expected:       []uint{2, 3},
+		},
+		{
+			name:           "found by keywords and embeddings first",
+			embedder:       fakeEmbedder{},
+			keywordMatches: []models.DocumentChunk{salary, both},
+			question:       "leave leave salary",
+			expected:       []uint{3, 2},
+		},
+		{
+			name:           "found by embeddings only",
+			embedder:       fakeEmbedder{},
+			keywordMatches: []models.DocumentChunk{},
+			question:       "leave",
+			expected:       []uint{1, 3},
+		},
+		{
+			name:     "blank question",
+			embedder: fakeEmbedder{},
+			question: " ",
+			expected: []uint{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeDocumentChunkRepository{
+				chunks:         []models.DocumentChunk{leave, salary, both},
+				keywordMatches: tt.keywordMatches,
+			}
+			service := NewKnowledgeService(repo, tt.embedder, config.RAGConfig{TopK: 2})
+
+			passages, err := service.Search(context.Background(), tt.question)
+
+			assert.NoError(t, err)
+			ids := []uint{}
+			for _, passage := range passages {
+				ids = append(ids, passage.ID)
+			}
+			assert.Equal(t, tt.expected, ids)
+		})
+	}
+}
+
+func TestKnowledgeServiceSearchCachesEmbeddings(t *testing.T) {
+	leave := models.DocumentChunk{ID: 1, Content: "leave", Embedding: []float32{1, 0}}
+	salary := models.DocumentChunk{ID: 2, Content: "salary", Embedding: []float32{0, 1}}
+	repo := &fakeDocumentChunkRepository{chunks: []models.DocumentChunk{leave}, version: repository.DocumentChunksVersion{Count: 1, MaxID: 1}}
+	service := NewKnowledgeService(repo, fakeEmbedder{}, config.RAGConfig{TopK: 2})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		passages, err := service.Search(ctx, "salary")
+		assert.NoError(t, err)
+		assert.Len(t, passages, 1)
+	}
+	assert.Equal(t, 1, repo.listed)
+
+	// Chunks ingested by another process are picked up on the next search.
+	repo.chunks = append(repo.chunks, salary)
+	repo.version = repository.DocumentChunksVersion{Count: 2, MaxID: 2}
+	passages, err := service.Search(ctx, "salary")
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), passages[0].ID)
+	assert.Equal(t, 2, repo.listed)
+}
+
+func TestKnowledgeSources(t *testing.T) {
+	passages := []models.DocumentChunk{
+		{Title: "Leave", Section: "Annual leave", URL: "https://wiki/leave#annual-leave"},
+		{Title: "Handbook", Section: "Page 2"},
+		{Title: "Benefits", Section: "Benefits", URL: "https://wiki/benefits"},
+	}
+
+	tests := []struct {
+		name     string
+		replies  []string
+		expected string
+	}{
+		{
+			name:     "cited passages are linked",
+			replies:  []string{"You get twelve days [S1].", "See also [S3] and [S1]."},
+			expected: "Sources:\n[S1] <https://wiki/leave#annual-leave|Leave › Annual leave>\n[S3] <https://wiki/benefits|Benefits>",
+		},
+		{
+			name:     "passage without url",
+			replies:  []string{"Working hours are 9 to 6 [S2]."},
+			expected: "Sources:\n[S2] Handbook › Page 2",
+		},
+		{
+			name:     "unknown citations are ignored",
+			replies:  []string{"I do not know [S4]."},
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, knowledgeSources(passages, tt.replies, i18n.English))
+		})
+	}
+}
@@ -0,0 +1,302 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/config"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+	"github.com/sotatek-dev/hyper-automation-chatbot/pkg/util"
+)
+
+// azureStreamingApiVersion is the first Azure OpenAI API version able to
+// stream assistant runs.
+const azureStreamingApiVersion = "2024-05-01-preview"
+
+// AzureAssistantsBackend implements LLMBackend on top of the Azure OpenAI
+// Assistants API.
+type AzureAssistantsBackend struct {
+	client            *azureOpenAIClient
+	azureOpenAIConfig config.AzureOpenAIConfig
+}
+
+func NewAzureAssistantsBackend(client *http.Client, azureOpenAIConfig config.AzureOpenAIConfig) *AzureAssistantsBackend {
+	return &AzureAssistantsBackend{
+		client:            newAzureOpenAIClient(client, azureOpenAIConfig),
+		azureOpenAIConfig: azureOpenAIConfig,
+	}
+}
+
+func (b *AzureAssistantsBackend) CreateThread(ctx context.Context) (string, error) {
+	thread := struct {
+		ID string `json:"id"`
+	}{}
+	err := b.client.doJSON(ctx, "POST", "threads", nil, &thread)
+	if err != nil {
+		return "", err
+	}
+	return thread.ID, nil
+}
+
+// DeleteThread deletes the thread with its messages, the runs of the thread
+// are over.
+func (b *AzureAssistantsBackend) DeleteThread(ctx context.Context, threadID string) error {
+	return b.client.doJSON(ctx, "DELETE", "threads/"+threadID, nil, nil)
+}
+
+func (b *AzureAssistantsBackend) CreateMessage(ctx context.Context, threadID string, message dto.AzureAIMessageInput) (string, error) {
+	// Content is a plain string unless images are attached, they are only
+	// accepted as content parts.
+	var content interface{} = message.Text
+	if len(message.ImageFileIDs) > 0 {
+		parts := []map[string]interface{}{{"type": "text", "text": message.Text}}
+		for _, fileID := range message.ImageFileIDs {
+			parts = append(parts, map[string]interface{}{
+				"type":       "image_file",
+				"image_file": map[string]string{"file_id": fileID},
+			})
+		}
+		content = parts
+	}
+	requestBody := struct {
+		Role        string                         `json:"role"`
+		Content     interface{}                    `json:"content"`
+		Attachments []dto.AzureAIMessageAttachment `json:"attachments,omitempty"`
+	}{
+		Role:        messageRole(message),
+		Content:     content,
+		Attachments: message.Attachments,
+	}
+	newMessage := struct {
+		ID string `json:"id"`
+	}{}
+	err := b.client.doJSON(ctx, "POST", "threads/"+threadID+"/messages", requestBody, &newMessage)
+	if err != nil {
+		return "", err
+	}
+	return newMessage.ID, nil
+}
+
+func (b *AzureAssistantsBackend) CreateRun(ctx context.Context, threadID string, request dto.AzureAIRunRequest) (*dto.AzureAIRun, error) {
+	var run dto.AzureAIRun
+	err := b.client.doJSON(ctx, "POST", "threads/"+threadID+"/runs", request, &run)
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+func (b *AzureAssistantsBackend) GetRun(ctx context.Context, threadID string, runID string) (*dto.AzureAIRun, error) {
+	var run dto.AzureAIRun
+	err := b.client.doJSON(ctx, "GET", "threads/"+threadID+"/runs/"+runID, nil, &run)
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+func (b *AzureAssistantsBackend) SubmitToolOutputs(ctx context.Context, threadID string, runID string, outputs []dto.AzureAIToolOutput) error {
+	requestBody := struct {
+		ToolOutputs []dto.AzureAIToolOutput `json:"tool_outputs"`
+	}{
+		ToolOutputs: outputs,
+	}
+	return b.client.doJSON(ctx, "POST", "threads/"+threadID+"/runs/"+runID+"/submit_tool_outputs", requestBody, nil)
+}
+
+// ListAssistants returns all the assistants of the Azure OpenAI resource,
+// going through the pages of the list.
+func (b *AzureAssistantsBackend) ListAssistants(ctx context.Context) ([]dto.AzureAIAssistant, error) {
+	assistants := []dto.AzureAIAssistant{}
+	path := "assistants?limit=100&order=asc"
+	for {
+		response := struct {
+			Data    []dto.AzureAIAssistant `json:"data"`
+			LastID  string                 `json:"last_id"`
+			HasMore bool                   `json:"has_more"`
+		}{}
+		err := b.client.doJSON(ctx, "GET", path, nil, &response)
+		if err != nil {
+			return nil, err
+		}
+		assistants = append(assistants, response.Data...)
+		if !response.HasMore || response.LastID == "" {
+			return assistants, nil
+		}
+		path = "assistants?limit=100&order=asc&after=" + url.QueryEscape(response.LastID)
+	}
+}
+
+func (b *AzureAssistantsBackend) CreateAssistant(ctx context.Context, assistant dto.AzureAIAssistant) (*dto.AzureAIAssistant, error) {
+	var created dto.AzureAIAssistant
+	err := b.client.doJSON(ctx, "POST", "assistants", assistant, &created)
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// UpdateAssistant replaces the fields of the assistant assistant.ID.
+func (b *AzureAssistantsBackend) UpdateAssistant(ctx context.Context, assistant dto.AzureAIAssistant) (*dto.AzureAIAssistant, error) {
+	var updated dto.AzureAIAssistant
+	assistantID := assistant.ID
+	assistant.ID = ""
+	err := b.client.doJSON(ctx, "POST", "assistants/"+assistantID, assistant, &updated)
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// CancelRun asks the API to stop the run. The run goes through cancelling
+// before it is cancelled, callers do not wait for it.
+func (b *AzureAssistantsBackend) CancelRun(ctx context.Context, threadID string, runID string) error {
+	return b.client.doJSON(ctx, "POST", "threads/"+threadID+"/runs/"+runID+"/cancel", nil, nil)
+}
+
+func (b *AzureAssistantsBackend) ListMessages(ctx context.Context, threadID string) ([]dto.AzureAIChatbotMessage, error) {
+	response := struct {
+		Data   []dto.AzureAIChatbotMessage `json:"data"`
+		Object string                      `json:"object"`
+	}{}
+	err := b.client.doJSON(ctx, "GET", "threads/"+threadID+"/messages", nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+func (b *AzureAssistantsBackend) UploadFile(ctx context.Context, filename string, content []byte) (string, error) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	err := writer.WriteField("purpose", "assistants")
+	if err != nil {
+		return "", err
+	}
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		return "", err
+	}
+	_, err = part.Write(content)
+	if err != nil {
+		return "", err
+	}
+	err = writer.Close()
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := b.client.do(ctx, azureOpenAIRequest{
+		method:      "POST",
+		path:        "files",
+		contentType: writer.FormDataContentType(),
+		body:        body.Bytes(),
+	})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	file := struct {
+		ID string `json:"id"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&file); err != nil {
+		return "", fmt.Errorf("error decoding response: %w", err)
+	}
+	return file.ID, nil
+}
+
+func (b *AzureAssistantsBackend) GetFileContent(ctx context.Context, fileID string) ([]byte, error) {
+	resp, err := b.client.do(ctx, azureOpenAIRequest{method: "GET", path: "files/" + fileID + "/content"})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+func (b *AzureAssistantsBackend) GetFileInformation(ctx context.Context, fileID string) (map[string]interface{}, error) {
+	var fileRes map[string]interface{}
+	err := b.client.doJSON(ctx, "GET", "files/"+fileID, nil, &fileRes)
+	if err != nil {
+		return nil, err
+	}
+	return fileRes, nil
+}
+
+// SupportsStreaming reports whether runs are streamed: streaming must be
+// enabled and the API version recent enough. API versions are dates, so they
+// compare as strings.
+func (b *AzureAssistantsBackend) SupportsStreaming() bool {
+	apiVersion := b.azureOpenAIConfig.ApiVersion
+	if !b.azureOpenAIConfig.Streaming || len(apiVersion) < len("2006-01-02") {
+		return false
+	}
+	return apiVersion[:10] >= azureStreamingApiVersion[:10]
+}
+
+func (b *AzureAssistantsBackend) CreateRunStream(ctx context.Context, threadID string, request dto.AzureAIRunRequest, onEvent func(dto.AzureAIRunStreamEvent) error) (*dto.AzureAIRun, error) {
+	request.Stream = true
+	return b.stream(ctx, "threads/"+threadID+"/runs", request, onEvent)
+}
+
+func (b *AzureAssistantsBackend) SubmitToolOutputsStream(ctx context.Context, threadID string, runID string, outputs []dto.AzureAIToolOutput, onEvent func(dto.AzureAIRunStreamEvent) error) (*dto.AzureAIRun, error) {
+	requestBody := struct {
+		ToolOutputs []dto.AzureAIToolOutput `json:"tool_outputs"`
+		Stream      bool                    `json:"stream"`
+	}{
+		ToolOutputs: outputs,
+		Stream:      true,
+	}
+	return b.stream(ctx, "threads/"+threadID+"/runs/"+runID+"/submit_tool_outputs", requestBody, onEvent)
+}
+
+// stream posts requestBody and forwards the server-sent events of the response
+// to onEvent. The run carried by the last thread.run.* event is returned.
+func (b *AzureAssistantsBackend) stream(ctx context.Context, path string, requestBody interface{}, onEvent func(dto.AzureAIRunStreamEvent) error) (*dto.AzureAIRun, error) {
+	requestBodyBytes, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := b.client.do(ctx, azureOpenAIRequest{
+		method:      "POST",
+		path:        path,
+		contentType: "application/json",
+		accept:      "text/event-stream",
+		body:        requestBodyBytes,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var run *dto.AzureAIRun
+	err = util.ReadServerSentEvents(resp.Body, func(event string, data []byte) error {
+		switch {
+		case event == "done":
+			return nil
+		case event == "error":
+			return fmt.Errorf("run stream error: %s", data)
+		case strings.HasPrefix(event, "thread.run.") && !strings.HasPrefix(event, "thread.run.step."):
+			run = &dto.AzureAIRun{}
+			if err := json.Unmarshal(data, run); err != nil {
+				return fmt.Errorf("error decoding %s event: %w", event, err)
+			}
+		}
+		return onEvent(dto.AzureAIRunStreamEvent{Event: event, Data: data})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if run == nil {
+		return nil, fmt.Errorf("run stream ended without a run event")
+	}
+	return run, nil
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/config"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAzureAssistantsBackendSupportsStreaming(t *testing.T) {
+	tests := []struct {
+		name       string
+		streaming  bool
+		apiVersion string
+		want       bool
+	}{
+		{name: "disabled", streaming: false, apiVersion: "2024-05-01-preview", want: false},
+		{name: "old api version", streaming: true, apiVersion: "2024-02-15-preview", want: false},
+		{name: "first streaming api version", streaming: true, apiVersion: "2024-05-01-preview", want: true},
+		{name: "newer api version", streaming: true, apiVersion: "2024-10-21", want: true},
+		{name: "missing api version", streaming: true, apiVersion: "", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := NewAzureAssistantsBackend(http.DefaultClient, config.AzureOpenAIConfig{Streaming: tt.streaming, ApiVersion: tt.apiVersion})
+			assert.Equal(t, tt.want, backend.SupportsStreaming())
+		})
+	}
+}
+
+func TestAzureAssistantsBackendCreateRunStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/openai/threads/thread_1/runs", r.URL.Path)
+		assert.Equal(t, "text/event-stream", r.Header.Get("Accept"))
+		var body dto.AzureAIRunRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.True(t, body.Stream)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("event: thread.run.created\ndata: {\"id\":\"run_1\",\"status\":\"queued\"}\n\n"))
+		w.Write([]byte("event: thread.run.step.created\ndata: {\"id\":\"step_1\",\"status\":\"in_progress\"}\n\n"))
+		w.Write([]byte("event: thread.message.delta\ndata: {\"id\":\"msg_1\",\"delta\":{\"content\":[{\"index\":0,\"type\":\"text\",\"text\":{\"value\":\"Hi\"}}]}}\n\n"))
+		w.Write([]byte("event: thread.run.completed\ndata: {\"id\":\"run_1\",\"status\":\"completed\"}\n\n"))
+		w.Write([]byte("event: done\ndata: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	backend := NewAzureAssistantsBackend(server.Client(), config.AzureOpenAIConfig{Endpoint: server.URL, ApiVersion: "2024-05-01-preview", Streaming: true})
+	events := []string{}
+	run, err := backend.CreateRunStream(context.Background(), "thread_1", dto.AzureAIRunRequest{AssistantID: "asst_1"}, func(event dto.AzureAIRunStreamEvent) error {
+		events = append(events, event.Event)
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "run_1", run.ID)
+	assert.Equal(t, RunStatusCompleted, run.Status)
+	assert.Equal(t, []string{"thread.run.created", "thread.run.step.created", "thread.message.delta", "thread.run.completed"}, events)
+}
+
+func TestAzureAssistantsBackendCreateMessageWithFiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  dto.AzureAIMessageInput
+		wantBody string
+	}{
+		{
+			name:     "text only",
+			message:  dto.AzureAIMessageInput{Text: "hello"},
+			wantBody: `{"role":"user","content":"hello"}`,
+		},
+		{
+			name: "image and attachment",
+			message: dto.AzureAIMessageInput{
+				Text:         "summarize this CV",
+				ImageFileIDs: []string{"file_image"},
+				Attachments:  []dto.AzureAIMessageAttachment{{FileID: "file_cv", Tools: []dto.AzureAITool{{Type: "file_search"}}}},
+			},
+			wantBody: `{"role":"user","content":[{"type":"text","text":"summarize this CV"},{"type":"image_file","image_file":{"file_id":"file_image"}}],"attachments":[{"file_id":"file_cv","tools":[{"type":"file_search"}]}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/openai/threads/thread_1/messages", r.URL.Path)
+				body, _ := io.ReadAll(r.Body)
+				assert.JSONEq(t, tt.wantBody, string(body))
+				w.Write([]byte(`{"id":"msg_1"}`))
+			}))
+			defer server.Close()
+
+			backend := NewAzureAssistantsBackend(server.Client(), config.AzureOpenAIConfig{Endpoint: server.URL})
+			messageID, err := backend.CreateMessage(context.Background(), "thread_1", tt.message)
+
+			assert.NoError(t, err)
+			assert.Equal(t, "msg_1", messageID)
+		})
+	}
+}
+
+func TestAzureAssistantsBackendUploadFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/openai/files", r.URL.Path)
+		assert.NoError(t, r.ParseMultipartForm(1<<20))
+		assert.Equal(t, "assistants", r.FormValue("purpose"))
+		file, header, err := r.FormFile("file")
+		assert.NoError(t, err)
+		content, _ := io.ReadAll(file)
+		assert.Equal(t, "cv.pdf", header.Filename)
+		assert.Equal(t, "%PDF", string(content))
+		w.Write([]byte(`{"id":"file_1"}`))
+	}))
+	defer server.Close()
+
+	backend := NewAzureAssistantsBackend(server.Client(), config.AzureOpenAIConfig{Endpoint: server.URL})
+	fileID, err := backend.UploadFile(context.Background(), "cv.pdf", []byte("%PDF"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "file_1", fileID)
+}
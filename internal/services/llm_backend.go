@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/config"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/repository"
+)
+
+var ErrLLMBackendUnsupported = errors.New("operation not supported by the llm backend")
+
+// LLMBackend is the assistant style API the chatbot talks to: threads hold the
+// conversation, runs let the model answer or request tool calls on a thread.
+type LLMBackend interface {
+	CreateThread(ctx context.Context) (string, error)
+	CreateMessage(ctx context.Context, threadID string, message dto.AzureAIMessageInput) (string, error)
+	// ListMessages returns the thread messages, newest first.
+	ListMessages(ctx context.Context, threadID string) ([]dto.AzureAIChatbotMessage, error)
+	CreateRun(ctx context.Context, threadID string, request dto.AzureAIRunRequest) (*dto.AzureAIRun, error)
+	GetRun(ctx context.Context, threadID string, runID string) (*dto.AzureAIRun, error)
+	SubmitToolOutputs(ctx context.Context, threadID string, runID string, outputs []dto.AzureAIToolOutput) error
+	// CancelRun stops a run that is still queued or in progress.
+	CancelRun(ctx context.Context, threadID string, runID string) error
+	// UploadFile stores a file the assistant can read and returns its ID.
+	UploadFile(ctx context.Context, filename string, content []byte) (string, error)
+	GetFileContent(ctx context.Context, fileID string) ([]byte, error)
+	GetFileInformation(ctx context.Context, fileID string) (map[string]interface{}, error)
+}
+
+// StreamingLLMBackend is implemented by backends able to stream a run as
+// server-sent events. The stream methods call onEvent for every event, in
+// order, and return the run as it was when the stream ended: completed,
+// failed, or waiting for tool outputs.
+type StreamingLLMBackend interface {
+	LLMBackend
+	// SupportsStreaming reports whether the configured API can stream runs.
+	SupportsStreaming() bool
+	CreateRunStream(ctx context.Context, threadID string, request dto.AzureAIRunRequest, onEvent func(dto.AzureAIRunStreamEvent) error) (*dto.AzureAIRun, error)
+	SubmitToolOutputsStream(ctx context.Context, threadID string, runID string, outputs []dto.AzureAIToolOutput, onEvent func(dto.AzureAIRunStreamEvent) error) (*dto.AzureAIRun, error)
+}
+
+// ThreadDeletingLLMBackend is implemented by backends able to delete threads.
+// The threads of closed conversations and the threads no conversation uses
+// are deleted, they would pile up otherwise.
+type ThreadDeletingLLMBackend interface {
+	LLMBackend
+	DeleteThread(ctx context.Context, threadID string) error
+}
+
+// NewLLMBackend returns the configured backend. The chat completions backend
+// stores its threads in chatCompletion.
+func NewLLMBackend(llmConfig config.LLMConfig, azureOpenAIConfig config.AzureOpenAIConfig, chatCompletion repository.ChatCompletionRepositoryInterface) LLMBackend {
+	switch llmConfig.Backend {
+	case config.LLMBackendOpenAIChat:
+		return NewChatCompletionsBackend(&http.Client{Timeout: llmConfig.ChatRequestTimeout}, llmConfig, chatCompletion)
+	default:
+		return NewAzureAssistantsBackend(newAzureOpenAIHTTPClient(azureOpenAIConfig), azureOpenAIConfig)
+	}
+}
+
+// NewLLMBackendWithTransport is NewLLMBackend sending its requests through
+// wrap(transport), e.g. to record or replay them. It runs without a
+// database: the chat completions threads are kept in memory.
+func NewLLMBackendWithTransport(llmConfig config.LLMConfig, azureOpenAIConfig config.AzureOpenAIConfig, wrap func(http.RoundTripper) http.RoundTripper) LLMBackend {
+	switch llmConfig.Backend {
+	case config.LLMBackendOpenAIChat:
+		client := &http.Client{Transport: wrap(http.DefaultTransport), Timeout: llmConfig.ChatRequestTimeout}
+		return NewChatCompletionsBackend(client, llmConfig, newMemoryChatCompletionRepository())
+	default:
+		client := newAzureOpenAIHTTPClient(azureOpenAIConfig)
+		client.Transport = wrap(client.Transport)
+		return NewAzureAssistantsBackend(client, azureOpenAIConfig)
+	}
+}
+
+// messageRole is the role of a message added to a thread, user unless the
+// input says otherwise.
+func messageRole(message dto.AzureAIMessageInput) string {
+	if message.Role == "" {
+		return "user"
+	}
+	return message.Role
+}
@@ -0,0 +1,394 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/config"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/models"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/repository"
+	"gorm.io/gorm"
+)
+
+type chatCompletionMessage struct {
+	Role       string                `json:"role"`
+	Content    string                `json:"content"`
+	ToolCalls  []dto.AzureAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string                `json:"tool_call_id,omitempty"`
+}
+
+// ChatCompletionsBackend implements LLMBackend on top of a plain OpenAI
+// compatible /chat/completions endpoint, as served by self-hosted models.
+// Threads and runs are stored by the repository: a run calls the endpoint
+// synchronously and is already finished, or waiting for tool outputs, when
+// CreateRun returns.
+type ChatCompletionsBackend struct {
+	client         *http.Client
+	llmConfig      config.LLMConfig
+	chatCompletion repository.ChatCompletionRepositoryInterface
+}
+
+func NewChatCompletionsBackend(client *http.Client, llmConfig config.LLMConfig, chatCompletion repository.ChatCompletionRepositoryInterface) *ChatCompletionsBackend {
+	return &ChatCompletionsBackend{
+		client:         client,
+		llmConfig:      llmConfig,
+		chatCompletion: chatCompletion,
+	}
+}
+
+func (b *ChatCompletionsBackend) CreateThread(ctx context.Context) (string, error) {
+	thread := &models.ChatCompletionThread{ID: "thread_" + uuid.NewString()}
+	if err := b.chatCompletion.CreateThread(thread); err != nil {
+		return "", err
+	}
+	return thread.ID, nil
+}
+
+// DeleteThread deletes the thread with its messages and runs, once its
+// conversation is closed.
+func (b *ChatCompletionsBackend) DeleteThread(ctx context.Context, threadID string) error {
+	return b.chatCompletion.DeleteThread(threadID)
+}
+
+// CreateMessage keeps the text of the message only, files are never uploaded
+// to this backend.
+func (b *ChatCompletionsBackend) CreateMessage(ctx context.Context, threadID string, message dto.AzureAIMessageInput) (string, error) {
+	if err := b.checkThread(threadID); err != nil {
+		return "", err
+	}
+	record := &models.ChatCompletionMessage{
+		MessageID: "msg_" + uuid.NewString(),
+		ThreadID:  threadID,
+		Role:      messageRole(message),
+		Content:   message.Text,
+	}
+	if err := b.chatCompletion.CreateMessage(record); err != nil {
+		return "", err
+	}
+	return record.MessageID, nil
+}
+
+func (b *ChatCompletionsBackend) ListMessages(ctx context.Context, threadID string) ([]dto.AzureAIChatbotMessage, error) {
+	if err := b.checkThread(threadID); err != nil {
+		return nil, err
+	}
+	records, err := b.chatCompletion.ListMessages(threadID)
+	if err != nil {
+		return nil, err
+	}
+	messages := []dto.AzureAIChatbotMessage{}
+	for i := len(records) - 1; i >= 0; i-- {
+		record := records[i]
+		if record.Role != "user" && record.Role != "assistant" {
+			continue
+		}
+		if record.Content == "" {
+			continue
+		}
+		content := dto.AzureAIChatbotMessageContent{Type: "text"}
+		content.Text.Value = record.Content
+		messages = append(messages, dto.AzureAIChatbotMessage{
+			ID:      record.MessageID,
+			Role:    record.Role,
+			Content: []dto.AzureAIChatbotMessageContent{content},
+		})
+	}
+	return messages, nil
+}
+
+func (b *ChatCompletionsBackend) CreateRun(ctx context.Context, threadID string, request dto.AzureAIRunRequest) (*dto.AzureAIRun, error) {
+	if err := b.checkThread(threadID); err != nil {
+		return nil, err
+	}
+	run := &dto.AzureAIRun{
+		ID:          "run_" + uuid.NewString(),
+		ThreadID:    threadID,
+		AssistantID: request.AssistantID,
+		Status:      RunStatusInProgress,
+		Model:       b.llmConfig.ChatModel,
+		Usage:       &dto.AzureAIRunUsage{},
+	}
+	record, err := newChatCompletionRun(run, request)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.chatCompletion.CreateRun(record); err != nil {
+		return nil, err
+	}
+
+	if err := b.complete(ctx, run, request); err != nil {
+		return nil, err
+	}
+	return b.GetRun(ctx, threadID, run.ID)
+}
+
+func (b *ChatCompletionsBackend) GetRun(ctx context.Context, threadID string, runID string) (*dto.AzureAIRun, error) {
+	run, _, err := b.getRun(threadID, runID)
+	return run, err
+}
+
+// SubmitToolOutputs moves the run back in progress before completing it, so
+// the outputs are only added once when several instances submit them.
+func (b *ChatCompletionsBackend) SubmitToolOutputs(ctx context.Context, threadID string, runID string, outputs []dto.AzureAIToolOutput) error {
+	run, request, err := b.getRun(threadID, runID)
+	if err != nil {
+		return err
+	}
+	if run.Status != RunStatusRequiresAction {
+		return fmt.Errorf("run %s is %s, not waiting for tool outputs", runID, run.Status)
+	}
+	messages := []models.ChatCompletionMessage{}
+	for _, output := range outputs {
+		messages = append(messages, models.ChatCompletionMessage{
+			ThreadID:   threadID,
+			Role:       "tool",
+			Content:    output.Output,
+			ToolCallID: output.ToolCallID,
+		})
+	}
+	run.Status = RunStatusInProgress
+	run.RequiredAction = nil
+	if err := b.updateRun(run, RunStatusRequiresAction, messages); err != nil {
+		return err
+	}
+
+	return b.complete(ctx, run, request)
+}
+
+// CancelRun marks the run cancelled. Runs complete within CreateRun and
+// SubmitToolOutputs, so only a run waiting for tool outputs is affected.
+func (b *ChatCompletionsBackend) CancelRun(ctx context.Context, threadID string, runID string) error {
+	run, _, err := b.getRun(threadID, runID)
+	if err != nil {
+		return err
+	}
+	if run.Status == RunStatusCompleted || run.Status == RunStatusFailed || run.Status == RunStatusCancelled {
+		return fmt.Errorf("cannot cancel run %s with status %s", runID, run.Status)
+	}
+	fromStatus := run.Status
+	run.Status = RunStatusCancelled
+	run.RequiredAction = nil
+	return b.updateRun(run, fromStatus, nil)
+}
+
+func (b *ChatCompletionsBackend) UploadFile(ctx context.Context, filename string, content []byte) (string, error) {
+	return "", ErrLLMBackendUnsupported
+}
+
+func (b *ChatCompletionsBackend) GetFileContent(ctx context.Context, fileID string) ([]byte, error) {
+	return nil, ErrLLMBackendUnsupported
+}
+
+func (b *ChatCompletionsBackend) GetFileInformation(ctx context.Context, fileID string) (map[string]interface{}, error) {
+	return nil, ErrLLMBackendUnsupported
+}
+
+// complete sends the thread history to the chat completions endpoint and
+// moves the run to requires_action or completed depending on the answer. The
+// run instructions replace LLM_CHAT_INSTRUCTIONS when set, the additional ones
+// are appended to them.
+func (b *ChatCompletionsBackend) complete(ctx context.Context, run *dto.AzureAIRun, request dto.AzureAIRunRequest) error {
+	records, err := b.chatCompletion.ListMessages(run.ThreadID)
+	if err != nil {
+		return err
+	}
+	messages := []chatCompletionMessage{}
+	instructions := b.llmConfig.ChatInstructions
+	if request.Instructions != "" {
+		instructions = request.Instructions
+	}
+	if request.AdditionalInstructions != "" {
+		instructions = strings.TrimSpace(instructions + "\n\n" + request.AdditionalInstructions)
+	}
+	if instructions != "" {
+		messages = append(messages, chatCompletionMessage{Role: "system", Content: instructions})
+	}
+	for _, record := range records {
+		message := chatCompletionMessage{Role: record.Role, Content: record.Content, ToolCallID: record.ToolCallID}
+		if len(record.ToolCalls) > 0 {
+			if err := json.Unmarshal(record.ToolCalls, &message.ToolCalls); err != nil {
+				return err
+			}
+		}
+		messages = append(messages, message)
+	}
+
+	requestBody := struct {
+		Model      string                  `json:"model"`
+		Messages   []chatCompletionMessage `json:"messages"`
+		Tools      []dto.AzureAITool       `json:"tools,omitempty"`
+		ToolChoice string                  `json:"tool_choice,omitempty"`
+	}{
+		Model:    b.llmConfig.ChatModel,
+		Messages: messages,
+		Tools:    functionTools(request.Tools),
+	}
+	// tool_choice is rejected without tools.
+	if len(requestBody.Tools) > 0 {
+		requestBody.ToolChoice = request.ToolChoice
+	}
+	requestBodyBytes, err := json.Marshal(requestBody)
+	if err != nil {
+		return err
+	}
+	url := strings.TrimSuffix(b.llmConfig.ChatEndpoint, "/") + "/chat/completions"
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(requestBodyBytes))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if b.llmConfig.ChatApiKey != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", b.llmConfig.ChatApiKey))
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		b.failRun(run, err)
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		b.failRun(run, err)
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		b.failRun(run, err)
+		return err
+	}
+	response := struct {
+		Choices []struct {
+			Message      chatCompletionMessage `json:"message"`
+			FinishReason string                `json:"finish_reason"`
+		} `json:"choices"`
+		Usage dto.AzureAIRunUsage `json:"usage"`
+	}{}
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		b.failRun(run, err)
+		return err
+	}
+	if len(response.Choices) == 0 {
+		err = fmt.Errorf("chat completion returned no choices")
+		b.failRun(run, err)
+		return err
+	}
+
+	answer := response.Choices[0].Message
+	record := models.ChatCompletionMessage{
+		MessageID: "msg_" + uuid.NewString(),
+		ThreadID:  run.ThreadID,
+		Role:      "assistant",
+		Content:   answer.Content,
+	}
+	if len(answer.ToolCalls) > 0 {
+		record.ToolCalls, err = json.Marshal(answer.ToolCalls)
+		if err != nil {
+			return err
+		}
+	}
+
+	// A run may take several completions, one per tool call round trip.
+	run.Usage.PromptTokens += response.Usage.PromptTokens
+	run.Usage.CompletionTokens += response.Usage.CompletionTokens
+	run.Usage.TotalTokens += response.Usage.TotalTokens
+	run.Status = RunStatusCompleted
+	if len(answer.ToolCalls) > 0 {
+		run.Status = RunStatusRequiresAction
+		run.RequiredAction = &dto.AzureAIRunRequiredAction{Type: "submit_tool_outputs"}
+		run.RequiredAction.SubmitToolOutputs.ToolCalls = answer.ToolCalls
+	}
+	return b.updateRun(run, RunStatusInProgress, []models.ChatCompletionMessage{record})
+}
+
+// functionTools drops the built-in assistant tools, such as code_interpreter,
+// chat completions only know about functions.
+func functionTools(tools []dto.AzureAITool) []dto.AzureAITool {
+	functions := []dto.AzureAITool{}
+	for _, tool := range tools {
+		if tool.Type == "function" {
+			functions = append(functions, tool)
+		}
+	}
+	return functions
+}
+
+// failRun records the error on the run. The error of the completion is
+// returned whether the run could be saved or not.
+func (b *ChatCompletionsBackend) failRun(run *dto.AzureAIRun, err error) {
+	run.Status = RunStatusFailed
+	run.LastError = &dto.AzureAIRunError{Code: "server_error", Message: err.Error()}
+	_ = b.updateRun(run, RunStatusInProgress, nil)
+}
+
+func (b *ChatCompletionsBackend) checkThread(threadID string) error {
+	_, err := b.chatCompletion.GetThread(threadID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("thread not found: %s", threadID)
+	}
+	return err
+}
+
+// getRun returns the run with the request it was created with.
+func (b *ChatCompletionsBackend) getRun(threadID string, runID string) (*dto.AzureAIRun, dto.AzureAIRunRequest, error) {
+	var request dto.AzureAIRunRequest
+	record, err := b.chatCompletion.GetRun(threadID, runID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, request, fmt.Errorf("run not found: %s", runID)
+	}
+	if err != nil {
+		return nil, request, err
+	}
+	var run dto.AzureAIRun
+	if err := json.Unmarshal(record.Run, &run); err != nil {
+		return nil, request, err
+	}
+	if err := json.Unmarshal(record.Request, &request); err != nil {
+		return nil, request, err
+	}
+	return &run, request, nil
+}
+
+// updateRun saves the run if it still has the status fromStatus, with the
+// messages it added to the thread. It fails when another instance moved the
+// run on in the meantime.
+func (b *ChatCompletionsBackend) updateRun(run *dto.AzureAIRun, fromStatus string, messages []models.ChatCompletionMessage) error {
+	encoded, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	updated, err := b.chatCompletion.UpdateRun(&models.ChatCompletionRun{ID: run.ID, Status: run.Status, Run: encoded}, fromStatus, messages)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return fmt.Errorf("run %s is no longer %s", run.ID, fromStatus)
+	}
+	return nil
+}
+
+func newChatCompletionRun(run *dto.AzureAIRun, request dto.AzureAIRunRequest) (*models.ChatCompletionRun, error) {
+	encodedRun, err := json.Marshal(run)
+	if err != nil {
+		return nil, err
+	}
+	encodedRequest, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ChatCompletionRun{
+		ID:       run.ID,
+		ThreadID: run.ThreadID,
+		Status:   run.Status,
+		Run:      encodedRun,
+		Request:  encodedRequest,
+	}, nil
+}
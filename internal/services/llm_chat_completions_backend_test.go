@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/config"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChatCompletionsBackendRun(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		var body struct {
+			Model    string `json:"model"`
+			Messages []struct {
+				Role    string `json:"role"`
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "llama3", body.Model)
+		assert.Equal(t, "system", body.Messages[0].Role)
+
+		w.Header().Set("Content-Type", "application/json")
+		if requests == 1 {
+			w.Write([]byte(`{"choices":[{"finish_reason":"tool_calls","message":{"role":"assistant","content":"","tool_calls":[{"id":"call_1","type":"function","function":{"name":"take_leave","arguments":"{}"}}]}}]}`))
+			return
+		}
+		assert.Equal(t, "tool", body.Messages[len(body.Messages)-1].Role)
+		w.Write([]byte(`{"choices":[{"finish_reason":"stop","message":{"role":"assistant","content":"Please fill the form"}}]}`))
+	}))
+	defer server.Close()
+
+	backend := NewChatCompletionsBackend(server.Client(), config.LLMConfig{
+		ChatEndpoint:     server.URL + "/v1",
+		ChatApiKey:       "secret",
+		ChatModel:        "llama3",
+		ChatInstructions: "You are an HR assistant",
+	}, newMemoryChatCompletionRepository())
+	ctx := context.Background()
+	threadID, err := backend.CreateThread(ctx)
+	assert.NoError(t, err)
+	_, err = backend.CreateMessage(ctx, threadID, dto.AzureAIMessageInput{Text: "I want to take leave"})
+	assert.NoError(t, err)
+
+	run, err := backend.CreateRun(ctx, threadID, dto.AzureAIRunRequest{Tools: ChatbotTools})
+	assert.NoError(t, err)
+	assert.Equal(t, RunStatusRequiresAction, run.Status)
+	assert.Equal(t, dto.ToolTakeLeave, run.RequiredAction.SubmitToolOutputs.ToolCalls[0].Function.Name)
+
+	err = backend.SubmitToolOutputs(ctx, threadID, run.ID, []dto.AzureAIToolOutput{{ToolCallID: "call_1", Output: `{"status":"accepted"}`}})
+	assert.NoError(t, err)
+	run, err = backend.GetRun(ctx, threadID, run.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, RunStatusCompleted, run.Status)
+
+	messages, err := backend.ListMessages(ctx, threadID)
+	assert.NoError(t, err)
+	assert.Len(t, messages, 2)
+	assert.Equal(t, "assistant", messages[0].Role)
+	assert.Equal(t, "Please fill the form", messages[0].Content[0].Text.Value)
+	assert.Equal(t, "user", messages[1].Role)
+}
+
+func TestChatCompletionsBackendSharedThreads(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []struct {
+				Role    string `json:"role"`
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(fmt.Sprintf(`{"choices":[{"finish_reason":"stop","message":{"role":"assistant","content":"%d messages"}}]}`, len(body.Messages))))
+	}))
+	defer server.Close()
+
+	chatCompletion := newMemoryChatCompletionRepository()
+	llmConfig := config.LLMConfig{ChatEndpoint: server.URL, ChatModel: "llama3"}
+	ctx := context.Background()
+	first := NewChatCompletionsBackend(server.Client(), llmConfig, chatCompletion)
+	threadID, err := first.CreateThread(ctx)
+	assert.NoError(t, err)
+	_, err = first.CreateMessage(ctx, threadID, dto.AzureAIMessageInput{Text: "Hello"})
+	assert.NoError(t, err)
+	_, err = first.CreateRun(ctx, threadID, dto.AzureAIRunRequest{})
+	assert.NoError(t, err)
+
+	// Another instance, or the same one after a restart, goes on with the
+	// history stored by the first one.
+	second := NewChatCompletionsBackend(server.Client(), llmConfig, chatCompletion)
+	_, err = second.CreateMessage(ctx, threadID, dto.AzureAIMessageInput{Text: "How many days off do I have?"})
+	assert.NoError(t, err)
+	run, err := second.CreateRun(ctx, threadID, dto.AzureAIRunRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, RunStatusCompleted, run.Status)
+	messages, err := second.ListMessages(ctx, threadID)
+	assert.NoError(t, err)
+	assert.Equal(t, "3 messages", messages[0].Content[0].Text.Value)
+
+	assert.NoError(t, second.DeleteThread(ctx, threadID))
+	_, err = first.CreateMessage(ctx, threadID, dto.AzureAIMessageInput{Text: "Hello"})
+	assert.EqualError(t, err, "thread not found: "+threadID)
+	_, err = first.GetRun(ctx, threadID, run.ID)
+	assert.EqualError(t, err, "run not found: "+run.ID)
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"sync"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/models"
+	"gorm.io/gorm"
+)
+
+// memoryChatCompletionRepository keeps the chat completions threads in
+// memory, for the tools running without a database. The threads are lost
+// when the process ends.
+type memoryChatCompletionRepository struct {
+	mu       sync.Mutex
+	threads  map[string]models.ChatCompletionThread
+	messages map[string][]models.ChatCompletionMessage
+	runs     map[string]models.ChatCompletionRun
+	nextID   uint
+}
+
+func newMemoryChatCompletionRepository() *memoryChatCompletionRepository {
+	return &memoryChatCompletionRepository{
+		threads:  map[string]models.ChatCompletionThread{},
+		messages: map[string][]models.ChatCompletionMessage{},
+		runs:     map[string]models.ChatCompletionRun{},
+	}
+}
+
+func (r *memoryChatCompletionRepository) CreateThread(thread *models.ChatCompletionThread) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.threads[thread.ID] = *thread
+	return nil
+}
+
+func (r *memoryChatCompletionRepository) GetThread(threadID string) (*models.ChatCompletionThread, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	thread, ok := r.threads[threadID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &thread, nil
+}
+
+func (r *memoryChatCompletionRepository) DeleteThread(threadID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.threads, threadID)
+	delete(r.messages, threadID)
+	for id, run := range r.runs {
+		if run.ThreadID == threadID {
+			delete(r.runs, id)
+		}
+	}
+	return nil
+}
+
+func (r *memoryChatCompletionRepository) CreateMessage(message *models.ChatCompletionMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.addMessage(message)
+	return nil
+}
+
+func (r *memoryChatCompletionRepository) ListMessages(threadID string) ([]models.ChatCompletionMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]models.ChatCompletionMessage{}, r.messages[threadID]...), nil
+}
+
+func (r *memoryChatCompletionRepository) CreateRun(run *models.ChatCompletionRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.runs[run.ID] = *run
+	return nil
+}
+
+func (r *memoryChatCompletionRepository) GetRun(threadID string, runID string) (*models.ChatCompletionRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	run, ok := r.runs[runID]
+	if !ok || run.ThreadID != threadID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &run, nil
+}
+
+func (r *memoryChatCompletionRepository) UpdateRun(run *models.ChatCompletionRun, fromStatus string, messages []models.ChatCompletionMessage) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.runs[run.ID]
+	if !ok || stored.Status != fromStatus {
+		return false, nil
+	}
+	stored.Status = run.Status
+	stored.Run = run.Run
+	r.runs[run.ID] = stored
+	for i := range messages {
+		r.addMessage(&messages[i])
+	}
+	return true, nil
+}
+
+func (r *memoryChatCompletionRepository) addMessage(message *models.ChatCompletionMessage) {
+	r.nextID++
+	message.ID = r.nextID
+	r.messages[message.ThreadID] = append(r.messages[message.ThreadID], *message)
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/i18n"
+)
+
+// profileLocaleTTL is how long the Slack profile locale of a user is cached.
+const profileLocaleTTL = time.Hour
+
+type cachedLocale struct {
+	locale    i18n.Locale
+	expiresAt time.Time
+}
+
+// LocaleService picks the language the bot answers a Slack user in.
+type LocaleService struct {
+	slackClient    *slack.Client
+	threadService  *ThreadService
+	mu             sync.Mutex
+	profileLocales map[string]cachedLocale
+}
+
+func NewLocaleService(slackClient *slack.Client, threadService *ThreadService) *LocaleService {
+	return &LocaleService{
+		slackClient:    slackClient,
+		threadService:  threadService,
+		profileLocales: map[string]cachedLocale{},
+	}
+}
+
+// Locale returns the language of text when it can be told, the language of
+// the conversation held in the Slack thread otherwise, then the locale of the
+// Slack profile of the user, then the default locale. Text and threadTs are
+// empty for the interactions that carry no message or no thread.
+func (s *LocaleService) Locale(ctx context.Context, channelID string, threadTs string, userID string, text string) i18n.Locale {
+	if locale := i18n.Detect(text); locale != "" {
+		return locale
+	}
+	if threadTs != "" {
+		thread, err := s.threadService.GetThreadBySlackThread(channelID, threadTs)
+		if err == nil && thread.Locale != "" {
+			return i18n.Locale(thread.Locale)
+		}
+	}
+	if locale := s.profileLocale(ctx, userID); locale != "" {
+		return locale
+	}
+	return i18n.DefaultLocale
+}
+
+// profileLocale returns the locale of the Slack profile of the user, empty
+// when the bot does not speak it or the profile cannot be read.
+func (s *LocaleService) profileLocale(ctx context.Context, userID string) i18n.Locale {
+	if userID == "" {
+		return ""
+	}
+	s.mu.Lock()
+	cached, ok := s.profileLocales[userID]
+	s.mu.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.locale
+	}
+
+	user, err := s.slackClient.GetUserInfoContext(ctx, userID)
+	if err != nil {
+		return ""
+	}
+	locale := i18n.ParseSlackLocale(user.Locale)
+	s.mu.Lock()
+	s.profileLocales[userID] = cachedLocale{locale: locale, expiresAt: time.Now().Add(profileLocaleTTL)}
+	s.mu.Unlock()
+	return locale
+}
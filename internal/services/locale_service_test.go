@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/config"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/i18n"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/mocks"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocaleServiceLocale(t *testing.T) {
+	tests := []struct {
+		name        string
+		threadTs    string
+		userID      string
+		text        string
+		expected    i18n.Locale
+		wantLookups int
+	}{
+		{name: "language of the message", threadTs: "1700000000.000100", userID: "U_VI", text: "I want to take leave tomorrow", expected: i18n.English},
+		{name: "language of the thread", threadTs: "1700000000.000100", userID: "U_EN", text: "ok", expected: i18n.Vietnamese},
+		{name: "profile locale", threadTs: "1700000000.000200", userID: "U_VI", text: "ok", expected: i18n.Vietnamese, wantLookups: 1},
+		{name: "profile locale the bot does not speak", userID: "U_JA", expected: i18n.DefaultLocale, wantLookups: 1},
+		{name: "no user", expected: i18n.DefaultLocale},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slackServer := mocks.NewFakeSlackServer()
+			defer slackServer.Close()
+			slackServer.UserLocales["U_VI"] = "vi-VN"
+			slackServer.UserLocales["U_JA"] = "ja-JP"
+			threadService := NewThreadService(mocks.NewFakeThreadRepository(models.Thread{
+				ID:            "thread_1",
+				ChannelId:     "C1",
+				SlackThreadTs: "1700000000.000100",
+				Locale:        string(i18n.Vietnamese),
+			}), config.ThreadConfig{IdleTimeout: time.Hour})
+			service := NewLocaleService(slack.New("xoxb-test", slack.OptionAPIURL(slackServer.APIURL())), threadService)
+
+			assert.Equal(t, tt.expected, service.Locale(context.Background(), "C1", tt.threadTs, tt.userID, tt.text))
+			// The profile locale is cached.
+			assert.Equal(t, tt.expected, service.Locale(context.Background(), "C1", tt.threadTs, tt.userID, tt.text))
+			assert.Len(t, slackServer.Calls("users.info"), tt.wantLookups)
+		})
+	}
+}
@@ -11,7 +11,10 @@ type MessageService struct {
 
 type MessageServiceInterface interface {
 	CreateMessage(message *models.Message) error
+	UpdateMessage(message *models.Message) error
 	GetMessagesByThreadID(threadID string) ([]models.Message, error)
+	GetMessagesBySlackTs(slackTs string) ([]models.Message, error)
+	ListMessagesByThreadID(threadID string, perPage, page int32) ([]models.Message, int64, error)
 }
 
 func NewMessageService(messageRepo repository.MessageRepositoryInterface) *MessageService {
@@ -22,6 +25,20 @@ func (m *MessageService) CreateMessage(message *models.Message) error {
 	return m.messageRepo.CreateMessage(message)
 }
 
+func (m *MessageService) UpdateMessage(message *models.Message) error {
+	return m.messageRepo.UpdateMessage(message)
+}
+
 func (m *MessageService) GetMessagesByThreadID(threadID string) ([]models.Message, error) {
 	return m.messageRepo.GetMessagesByThreadID(threadID)
 }
+
+// GetMessagesBySlackTs returns the messages posted as the Slack message
+// slackTs, of any channel.
+func (m *MessageService) GetMessagesBySlackTs(slackTs string) ([]models.Message, error) {
+	return m.messageRepo.GetMessagesBySlackTs(slackTs)
+}
+
+func (m *MessageService) ListMessagesByThreadID(threadID string, perPage, page int32) ([]models.Message, int64, error) {
+	return m.messageRepo.ListMessagesByThreadID(threadID, perPage, page)
+}
@@ -0,0 +1,276 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/config"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/models"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/repository"
+)
+
+const (
+	PIIKindEmail       = "email"
+	PIIKindPhone       = "phone"
+	PIIKindCitizenID   = "citizen_id"
+	PIIKindBankAccount = "bank_account"
+)
+
+// piiDetector finds one kind of personal data. When the pattern has a group,
+// only the group is replaced and the rest of the match is context, e.g. the
+// words "account number" before a bank account.
+type piiDetector struct {
+	kind    string
+	pattern *regexp.Regexp
+}
+
+// builtinPIIDetectors run in this order: bank accounts are recognized by the
+// words before them before their digits are taken for an ID or a phone
+// number, Vietnamese citizen IDs have 9 (CMND) or 12 (CCCD) digits.
+var builtinPIIDetectors = []piiDetector{
+	{PIIKindEmail, regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)},
+	{PIIKindBankAccount, regexp.MustCompile(`(?i)(?:^|[^\pL\pN])(?:bank account|account|acct|a/c|số tài khoản|tài khoản|stk|tk)(?:\s*(?:number|no\.?|số))?\s*[:#]?\s*(\d(?:[\s-]?\d){5,18})`)},
+	{PIIKindCitizenID, regexp.MustCompile(`\b(?:\d{12}|\d{9})\b`)},
+	{PIIKindPhone, regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?|\b0)\d{2,3}(?:[\s.-]?\d{3}){2}\d?\b`)},
+}
+
+var piiKindName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// PIIRedactionService replaces the personal data of Slack messages with
+// placeholders like [EMAIL_1] before they are sent to the LLM. Placeholders
+// are numbered per thread and stored with their value, so the replies and the
+// tool calls of the thread get the real values back. Every redaction is
+// logged, without the values, for compliance.
+type PIIRedactionService struct {
+	piiPlaceholderRepo repository.PIIPlaceholderRepositoryInterface
+	detectors          []piiDetector
+	logger             *zerolog.Logger
+}
+
+func NewPIIRedactionService(piiPlaceholderRepo repository.PIIPlaceholderRepositoryInterface, redactionConfig config.RedactionConfig, logger *zerolog.Logger) (*PIIRedactionService, error) {
+	detectors, err := parsePIIDetectors(redactionConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &PIIRedactionService{
+		piiPlaceholderRepo: piiPlaceholderRepo,
+		detectors:          detectors,
+		logger:             logger,
+	}, nil
+}
+
+// parsePIIDetectors reads the detectors selected by the RedactionConfig
+// format.
+func parsePIIDetectors(redactionConfig config.RedactionConfig) ([]piiDetector, error) {
+	detectors := []piiDetector{}
+	selected := strings.TrimSpace(redactionConfig.Detectors)
+	switch selected {
+	case "off":
+	case "":
+		detectors = append(detectors, builtinPIIDetectors...)
+	default:
+		kinds := map[string]bool{}
+		for _, kind := range strings.Split(selected, ",") {
+			kinds[strings.TrimSpace(kind)] = true
+		}
+		for _, detector := range builtinPIIDetectors {
+			if kinds[detector.kind] {
+				detectors = append(detectors, detector)
+				delete(kinds, detector.kind)
+			}
+		}
+		for kind := range kinds {
+			return nil, fmt.Errorf("unknown PII_REDACTION detector: %s", kind)
+		}
+	}
+
+	for _, entry := range strings.Fields(redactionConfig.Patterns) {
+		kind, pattern, ok := strings.Cut(entry, "=")
+		if !ok || !piiKindName.MatchString(kind) {
+			return nil, fmt.Errorf("invalid PII_REDACTION_PATTERNS entry: %s", entry)
+		}
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PII_REDACTION_PATTERNS entry %s: %w", entry, err)
+		}
+		detectors = append(detectors, piiDetector{kind: kind, pattern: compiled})
+	}
+	return detectors, nil
+}
+
+// Redact returns the text with its personal data replaced by the placeholders
+// of the thread. A value already redacted in the thread keeps its placeholder.
+// The new placeholders are numbered and stored one message of the thread at a
+// time.
+func (s *PIIRedactionService) Redact(threadID string, slackUserID string, text string) (string, error) {
+	if !s.mayHoldPII(text) {
+		return text, nil
+	}
+	var redacted string
+	var found map[string]int
+	err := s.piiPlaceholderRepo.CreateThreadPlaceholders(threadID, func(existing []models.PIIPlaceholder) []models.PIIPlaceholder {
+		vault := newPIIVault(threadID, existing)
+		redacted, found = vault.redact(text, s.detectors)
+		return vault.created
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(found) == 0 {
+		return text, nil
+	}
+	s.logger.Info().
+		Str("thread_id", threadID).
+		Str("slack_user_id", slackUserID).
+		Interface("redacted", found).
+		Msg("Personal data redacted from a message sent to the LLM")
+	return redacted, nil
+}
+
+// mayHoldPII tells whether a detector matches the text, the text holds no
+// personal data otherwise.
+func (s *PIIRedactionService) mayHoldPII(text string) bool {
+	for _, detector := range s.detectors {
+		if detector.pattern.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// Restorer returns the restorer of the placeholders of the thread.
+func (s *PIIRedactionService) Restorer(threadID string) (*PIIRestorer, error) {
+	placeholders, err := s.piiPlaceholderRepo.ListThreadPlaceholders(threadID)
+	if err != nil {
+		return nil, err
+	}
+	return newPIIRestorer(placeholders), nil
+}
+
+// piiVault numbers the placeholders of a thread, per kind of data.
+type piiVault struct {
+	threadID     string
+	placeholders map[string]string
+	counts       map[string]int
+	created      []models.PIIPlaceholder
+}
+
+func newPIIVault(threadID string, existing []models.PIIPlaceholder) *piiVault {
+	vault := &piiVault{
+		threadID:     threadID,
+		placeholders: map[string]string{},
+		counts:       map[string]int{},
+	}
+	for _, placeholder := range existing {
+		vault.placeholders[placeholder.Value] = placeholder.Placeholder
+		vault.counts[placeholder.Kind]++
+	}
+	return vault
+}
+
+// redact replaces the values found by the detectors, one detector after the
+// other. It returns the number of values found per kind.
+func (v *piiVault) redact(text string, detectors []piiDetector) (string, map[string]int) {
+	found := map[string]int{}
+	for _, detector := range detectors {
+		var redacted strings.Builder
+		last := 0
+		for _, match := range detector.pattern.FindAllStringSubmatchIndex(text, -1) {
+			start, end := match[0], match[1]
+			if len(match) >= 4 && match[2] >= 0 {
+				start, end = match[2], match[3]
+			}
+			if start == end {
+				continue
+			}
+			redacted.WriteString(text[last:start])
+			redacted.WriteString(v.placeholder(detector.kind, text[start:end]))
+			last = end
+			found[detector.kind]++
+		}
+		redacted.WriteString(text[last:])
+		text = redacted.String()
+	}
+	return text, found
+}
+
+func (v *piiVault) placeholder(kind string, value string) string {
+	if placeholder, ok := v.placeholders[value]; ok {
+		return placeholder
+	}
+	v.counts[kind]++
+	placeholder := fmt.Sprintf("[%s_%d]", strings.ToUpper(kind), v.counts[kind])
+	v.placeholders[value] = placeholder
+	v.created = append(v.created, models.PIIPlaceholder{
+		ThreadID:    v.threadID,
+		Placeholder: placeholder,
+		Kind:        kind,
+		Value:       value,
+	})
+	return placeholder
+}
+
+// PIIRestorer puts the redacted values of a thread back in place of their
+// placeholders. A nil restorer leaves everything unchanged.
+type PIIRestorer struct {
+	text *strings.Replacer
+	json *strings.Replacer
+}
+
+func newPIIRestorer(placeholders []models.PIIPlaceholder) *PIIRestorer {
+	if len(placeholders) == 0 {
+		return nil
+	}
+	text := []string{}
+	escaped := []string{}
+	for _, placeholder := range placeholders {
+		text = append(text, placeholder.Placeholder, placeholder.Value)
+		value, _ := json.Marshal(placeholder.Value)
+		escaped = append(escaped, placeholder.Placeholder, string(value[1:len(value)-1]))
+	}
+	return &PIIRestorer{
+		text: strings.NewReplacer(text...),
+		json: strings.NewReplacer(escaped...),
+	}
+}
+
+func (r *PIIRestorer) Restore(text string) string {
+	if r == nil {
+		return text
+	}
+	return r.text.Replace(text)
+}
+
+// RestoreToolCalls restores the values in the JSON arguments of the tool
+// calls, escaped as JSON strings.
+func (r *PIIRestorer) RestoreToolCalls(toolCalls []dto.AIToolCall) []dto.AIToolCall {
+	if r == nil {
+		return toolCalls
+	}
+	restored := make([]dto.AIToolCall, 0, len(toolCalls))
+	for _, toolCall := range toolCalls {
+		toolCall.Arguments = json.RawMessage(r.json.Replace(string(toolCall.Arguments)))
+		restored = append(restored, toolCall)
+	}
+	return restored
+}
+
+// restoringReplyPoster posts the assistant replies to Slack with the redacted
+// values restored.
+type restoringReplyPoster struct {
+	slackReplyPoster
+	restorer *PIIRestorer
+}
+
+func (p restoringReplyPoster) PostAttachmentMessage(ctx context.Context, channelID *string, threadTs string, message string) (string, error) {
+	return p.slackReplyPoster.PostAttachmentMessage(ctx, channelID, threadTs, p.restorer.Restore(message))
+}
+
+func (p restoringReplyPoster) UpdateAttachmentMessage(ctx context.Context, channelID *string, ts string, message string) error {
+	return p.slackReplyPoster.UpdateAttachmentMessage(ctx, channelID, ts, p.restorer.Restore(message))
+}
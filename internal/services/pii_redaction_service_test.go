@@ -0,0 +1,177 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/config"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakePIIPlaceholderRepository struct {
+	mu           sync.Mutex
+	placeholders []models.PIIPlaceholder
+}
+
+func (f *fakePIIPlaceholderRepository) ListThreadPlaceholders(threadID string) ([]models.PIIPlaceholder, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.threadPlaceholders(threadID), nil
+}
+
+func (f *fakePIIPlaceholderRepository) CreateThreadPlaceholders(threadID string, create func(existing []models.PIIPlaceholder) []models.PIIPlaceholder) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, created := range create(f.threadPlaceholders(threadID)) {
+		for _, placeholder := range f.placeholders {
+			if placeholder.ThreadID == created.ThreadID && placeholder.Placeholder == created.Placeholder {
+				return fmt.Errorf("duplicate placeholder %s", created.Placeholder)
+			}
+		}
+		f.placeholders = append(f.placeholders, created)
+	}
+	return nil
+}
+
+func (f *fakePIIPlaceholderRepository) threadPlaceholders(threadID string) []models.PIIPlaceholder {
+	placeholders := []models.PIIPlaceholder{}
+	for _, placeholder := range f.placeholders {
+		if placeholder.ThreadID == threadID {
+			placeholders = append(placeholders, placeholder)
+		}
+	}
+	return placeholders
+}
+
+func TestPIIRedactionServiceRedact(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    config.RedactionConfig
+		existing  []models.PIIPlaceholder
+		text      string
+		expected  string
+		restored  []string
+		expectErr bool
+	}{
+		{
+			name:     "email and phone",
+			text:     "Onboard an.nguyen@gmail.com, phone 0912 345 678 or +84912345679",
+			expected: "Onboard [EMAIL_1], phone [PHONE_1] or [PHONE_2]",
+			restored: []string{"an.nguyen@gmail.com", "0912 345 678", "+84912345679"},
+		},
+		{
+			name:     "citizen ids and bank account",
+			text:     "CCCD 001099012345, CMND 123456789, số tài khoản: 0071 0012 34567",
+			expected: "CCCD [CITIZEN_ID_1], CMND [CITIZEN_ID_2], số tài khoản: [BANK_ACCOUNT_1]",
+			restored: []string{"0071 0012 34567", "001099012345", "123456789"},
+		},
+		{
+			name: "values already redacted in the thread keep their placeholder",
+			existing: []models.PIIPlaceholder{
+				{ThreadID: "thread", Placeholder: "[EMAIL_1]", Kind: PIIKindEmail, Value: "an@gmail.com"},
+			},
+			text:     "Copy an@gmail.com to binh@gmail.com",
+			expected: "Copy [EMAIL_1] to [EMAIL_2]",
+			restored: []string{"binh@gmail.com"},
+		},
+		{
+			name:     "selected detectors and custom pattern",
+			config:   config.RedactionConfig{Detectors: "email", Patterns: `employee_id=EMP-\d{6}`},
+			text:     "EMP-001234 has email an@gmail.com and phone 0912345678",
+			expected: "[EMPLOYEE_ID_1] has email [EMAIL_1] and phone 0912345678",
+			restored: []string{"an@gmail.com", "EMP-001234"},
+		},
+		{
+			name:     "off",
+			config:   config.RedactionConfig{Detectors: "off"},
+			text:     "an@gmail.com",
+			expected: "an@gmail.com",
+			restored: []string{},
+		},
+		{
+			name:      "unknown detector",
+			config:    config.RedactionConfig{Detectors: "email,passport"},
+			expectErr: true,
+		},
+		{
+			name:      "invalid custom pattern",
+			config:    config.RedactionConfig{Patterns: "Employee=EMP"},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakePIIPlaceholderRepository{placeholders: tt.existing}
+			logger := zerolog.Nop()
+			service, err := NewPIIRedactionService(repo, tt.config, &logger)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			redacted, err := service.Redact("thread", "U1", tt.text)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, redacted)
+			restored := []string{}
+			for _, placeholder := range repo.placeholders[len(tt.existing):] {
+				restored = append(restored, placeholder.Value)
+			}
+			assert.Equal(t, tt.restored, restored)
+		})
+	}
+}
+
+func TestPIIRedactionServiceRedactConcurrentMessages(t *testing.T) {
+	logger := zerolog.Nop()
+	repo := &fakePIIPlaceholderRepository{}
+	service, err := NewPIIRedactionService(repo, config.RedactionConfig{}, &logger)
+	assert.NoError(t, err)
+
+	redacted := make([]string, 10)
+	var wg sync.WaitGroup
+	for i := range redacted {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			text, err := service.Redact("thread_1", "U1", fmt.Sprintf("Send it to user%d@example.com", i))
+			assert.NoError(t, err)
+			redacted[i] = text
+		}()
+	}
+	wg.Wait()
+
+	// Each message got its own placeholder.
+	assert.Len(t, repo.placeholders, len(redacted))
+	seen := map[string]bool{}
+	for _, text := range redacted {
+		assert.False(t, seen[text], text)
+		seen[text] = true
+	}
+}
+
+func TestPIIRestorer(t *testing.T) {
+	restorer := newPIIRestorer([]models.PIIPlaceholder{
+		{Placeholder: "[EMAIL_1]", Value: "an@gmail.com"},
+		{Placeholder: "[EMPLOYEE_ID_1]", Value: `EMP "1"`},
+	})
+
+	assert.Equal(t, "Welcome email sent to an@gmail.com", restorer.Restore("Welcome email sent to [EMAIL_1]"))
+
+	toolCalls := restorer.RestoreToolCalls([]dto.AIToolCall{
+		{Name: "onboard", Arguments: json.RawMessage(`{"email":"[EMAIL_1]","id":"[EMPLOYEE_ID_1]"}`)},
+	})
+	arguments := map[string]string{}
+	assert.NoError(t, json.Unmarshal(toolCalls[0].Arguments, &arguments))
+	assert.Equal(t, map[string]string{"email": "an@gmail.com", "id": `EMP "1"`}, arguments)
+
+	var none *PIIRestorer
+	assert.Equal(t, "[EMAIL_1]", none.Restore("[EMAIL_1]"))
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"time"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/models"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/repository"
+)
+
+// SlackEventRetention is how long the delivered events are remembered. Slack
+// retries an event 3 times, the last one 5 minutes after the first delivery.
+const SlackEventRetention = 10 * time.Minute
+
+// SlackEventService remembers the events delivered over HTTP in the database,
+// shared by the instances behind the load balancer.
+type SlackEventService struct {
+	slackEventRepo repository.SlackEventRepositoryInterface
+}
+
+func NewSlackEventService(slackEventRepo repository.SlackEventRepositoryInterface) *SlackEventService {
+	return &SlackEventService{slackEventRepo}
+}
+
+// FirstDelivery remembers the event and tells whether it is delivered for the
+// first time. The events older than SlackEventRetention are forgotten on each
+// call. Events without ID are always delivered for the first time.
+func (s *SlackEventService) FirstDelivery(eventID string) (bool, error) {
+	if eventID == "" {
+		return true, nil
+	}
+	now := time.Now()
+	if _, err := s.slackEventRepo.DeleteSlackEventsBefore(now.Add(-SlackEventRetention)); err != nil {
+		return false, err
+	}
+	return s.slackEventRepo.CreateSlackEvent(&models.SlackEvent{EventID: eventID, CreatedAt: now})
+}
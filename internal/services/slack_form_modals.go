@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/slack-go/slack"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/forms"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/i18n"
+)
+
+// maxButtonValueLength is the longest value Slack accepts for a button.
+const maxButtonValueLength = 2000
+
+// SendFormLauncher posts the prompt of the form with a button opening it in a
+// modal, Slack only opens modals in answer to an interaction. The button
+// carries the prefill, dropped when it does not fit in a button value.
+func (s *SlackService) SendFormLauncher(ctx context.Context, channelID string, threadTs string, locale i18n.Locale, form *forms.Form, prefill map[string]string) error {
+	value, err := json.Marshal(dto.FormLauncher{Form: form.ID, Prefill: prefill})
+	if err != nil {
+		return fmt.Errorf("failed to encode form launcher: %w", err)
+	}
+	if len(value) > maxButtonValueLength {
+		value, _ = json.Marshal(dto.FormLauncher{Form: form.ID})
+	}
+	blocks := []slack.Block{
+		slack.NewSectionBlock(
+			slack.NewTextBlockObject("mrkdwn", i18n.T(locale, form.Prompt), false, false),
+			nil,
+			nil,
+		),
+		slack.NewActionBlock(
+			"open_form",
+			slack.NewButtonBlockElement(
+				"open_form",
+				string(value),
+				slack.NewTextBlockObject("plain_text", i18n.T(locale, "form.open"), false, false),
+			).WithStyle(slack.StylePrimary),
+		),
+	}
+	_, _, err = s.slackClient.PostMessage(channelID, slack.MsgOptionBlocks(blocks...), ThreadOption(threadTs))
+	if err != nil {
+		s.slackClient.PostMessage(channelID, slack.MsgOptionText(i18n.T(locale, "error.form_not_sent", err.Error()), false), ThreadOption(threadTs))
+		return fmt.Errorf("failed to post message: %w", err)
+	}
+	return nil
+}
+
+// OpenFormModal opens the form in a modal. Its private_metadata is the
+// metadata, to answer the submission in the conversation the form was opened
+// from.
+func (s *SlackService) OpenFormModal(ctx context.Context, triggerID string, locale i18n.Locale, form *forms.Form, prefill map[string]string, metadata dto.FormMetadata) error {
+	privateMetadata, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to encode form metadata: %w", err)
+	}
+	_, err = s.slackClient.OpenViewContext(ctx, triggerID, form.Modal(locale, prefill, string(privateMetadata)))
+	if err != nil {
+		return fmt.Errorf("failed to open form %s: %w", form.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/slack-go/slack"
+)
+
+// UserGroup is the handle or the ID of the Slack user group the group name
+// maps to in SLACK_USER_GROUPS, the name itself when it is not mapped.
+func (s *SlackService) UserGroup(name string) string {
+	if group, ok := s.slackConfig.UserGroups[name]; ok {
+		return group
+	}
+	return name
+}
+
+// IsUserInGroups tells whether the user is a member of one of the groups,
+// named like for UserGroup. It needs the usergroups:read scope.
+func (s *SlackService) IsUserInGroups(ctx context.Context, userID string, groups []string) (bool, error) {
+	userGroups, err := s.slackClient.GetUserGroupsContext(ctx, slack.GetUserGroupsOptionIncludeUsers(true))
+	if err != nil {
+		return false, fmt.Errorf("failed to list user groups: %w", err)
+	}
+	for _, group := range groups {
+		group = s.UserGroup(group)
+		for _, userGroup := range userGroups {
+			if userGroup.ID != group && userGroup.Handle != group {
+				continue
+			}
+			for _, member := range userGroup.Users {
+				if member == userID {
+					return true, nil
+				}
+			}
+		}
+	}
+	return false, nil
+}
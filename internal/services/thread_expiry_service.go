@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/config"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/i18n"
+)
+
+// ThreadExpiryService closes conversations nobody talked in for a while. The
+// deadlines live on the threads table so they survive restarts, and every
+// state change is a conditional update so several instances can run it. The
+// backend threads of closed conversations are deleted when the backend keeps
+// them in the database.
+type ThreadExpiryService struct {
+	threadService *ThreadService
+	slackService  *SlackService
+	llmBackend    LLMBackend
+	threadConfig  config.ThreadConfig
+	logger        *zerolog.Logger
+}
+
+func NewThreadExpiryService(threadService *ThreadService, slackService *SlackService, llmBackend LLMBackend, threadConfig config.ThreadConfig, logger *zerolog.Logger) *ThreadExpiryService {
+	return &ThreadExpiryService{
+		threadService: threadService,
+		slackService:  slackService,
+		llmBackend:    llmBackend,
+		threadConfig:  threadConfig,
+		logger:        logger,
+	}
+}
+
+func (s *ThreadExpiryService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.threadConfig.ExpiryCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.CheckIdleThreads(ctx); err != nil {
+				s.logger.Error().Err(err).Msg("Cannot check idle threads")
+			}
+		}
+	}
+}
+
+// CheckIdleThreads asks the owners of threads about to expire whether they
+// want to continue, then closes the threads whose deadline has passed.
+func (s *ThreadExpiryService) CheckIdleThreads(ctx context.Context) error {
+	threads, err := s.threadService.ListThreadsToConfirmClose(time.Now().Add(s.threadConfig.CloseConfirmWindow))
+	if err != nil {
+		return err
+	}
+	for _, thread := range threads {
+		// Threads already past their deadline are closed below without asking.
+		if !thread.ExpiresAt.After(time.Now()) {
+			continue
+		}
+		claimed, err := s.threadService.MarkCloseConfirmSent(thread.ID)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+		closeAfter := time.Until(thread.ExpiresAt).Round(time.Second)
+		err = s.slackService.SendConfirmCloseThread(ctx, thread.ChannelId, thread.SlackThreadTs, i18n.Locale(thread.Locale), thread.ID, closeAfter)
+		if err != nil {
+			s.logger.Error().Err(err).Str("thread_id", thread.ID).Msg("Cannot send close thread confirmation")
+		}
+	}
+
+	threads, err = s.threadService.ListExpiredThreads()
+	if err != nil {
+		return err
+	}
+	for _, thread := range threads {
+		closed, err := s.threadService.CloseExpiredThread(thread.ID)
+		if err != nil {
+			return err
+		}
+		if !closed {
+			continue
+		}
+		if backend, ok := s.llmBackend.(ThreadDeletingLLMBackend); ok {
+			if err := backend.DeleteThread(ctx, thread.LLMThreadID()); err != nil {
+				s.logger.Error().Err(err).Str("thread_id", thread.ID).Msg("Cannot delete backend thread")
+			}
+		}
+		message := i18n.T(i18n.Locale(thread.Locale), "thread.expired", s.threadConfig.IdleTimeout)
+		err = s.slackService.SendMessage(ctx, &thread.ChannelId, thread.SlackThreadTs, message)
+		if err != nil {
+			s.logger.Error().Err(err).Str("thread_id", thread.ID).Msg("Cannot send thread closed message")
+		}
+	}
+	return nil
+}
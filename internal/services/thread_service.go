@@ -1,26 +1,49 @@
 package services
 
 import (
+	"time"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/config"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/i18n"
 	"github.com/sotatek-dev/hyper-automation-chatbot/internal/models"
 	"github.com/sotatek-dev/hyper-automation-chatbot/internal/repository"
 )
 
 type ThreadService struct {
-	threadRepo repository.ThreadRepositoryInterface
+	threadRepo     repository.ThreadRepositoryInterface
+	idleTimeout    time.Duration
+	rolloverTokens int64
 }
 
 type ThreadServiceInterface interface {
 	CreateThread(thread *models.Thread) error
 	GetThreadByID(threadID string) (*models.Thread, error)
 	CloseThreadStatus(threadID string) error
+	UpdateThreadAssistant(threadID string, assistant config.AssistantDefinition) error
+	UpdateThreadContextTokens(threadID string, contextTokens int64) error
+	UpdateThreadLocale(threadID string, locale i18n.Locale) error
+	NeedsRollover(thread *models.Thread) bool
+	RolloverThread(rollover *models.ThreadRollover) (int64, bool, error)
 	GetLatestOpenThreadByChannelAndUserID(channelID string, userID string) (*models.Thread, error)
+	GetLatestOpenThreadByUserID(userID string) (*models.Thread, error)
+	GetThreadBySlackThread(channelID string, slackThreadTs string) (*models.Thread, error)
+	ExtendThreadDeadline(threadID string) (bool, error)
+	ListThreadsToConfirmClose(before time.Time) ([]models.Thread, error)
+	MarkCloseConfirmSent(threadID string) (bool, error)
+	ListExpiredThreads() ([]models.Thread, error)
+	CloseExpiredThread(threadID string) (bool, error)
 }
 
-func NewThreadService(threadRepo repository.ThreadRepositoryInterface) *ThreadService {
-	return &ThreadService{threadRepo}
+func NewThreadService(threadRepo repository.ThreadRepositoryInterface, threadConfig config.ThreadConfig) *ThreadService {
+	return &ThreadService{
+		threadRepo:     threadRepo,
+		idleTimeout:    threadConfig.IdleTimeout,
+		rolloverTokens: threadConfig.RolloverTokens,
+	}
 }
 
 func (t *ThreadService) CreateThread(thread *models.Thread) error {
+	thread.ExpiresAt = time.Now().Add(t.idleTimeout)
 	return t.threadRepo.CreateThread(thread)
 }
 
@@ -32,6 +55,77 @@ func (t *ThreadService) GetLatestOpenThreadByChannelAndUserID(channelID string,
 	return t.threadRepo.GetLatestOpenThreadByChannelAndUserID(channelID, userID)
 }
 
+// GetLatestOpenThreadByUserID returns the latest open conversation of the
+// user, in any channel.
+func (t *ThreadService) GetLatestOpenThreadByUserID(userID string) (*models.Thread, error) {
+	return t.threadRepo.GetLatestOpenThreadByUserID(userID)
+}
+
+// GetThreadBySlackThread returns the conversation held in a Slack thread,
+// whatever its status: a Slack thread maps to exactly one thread.
+func (t *ThreadService) GetThreadBySlackThread(channelID string, slackThreadTs string) (*models.Thread, error) {
+	return t.threadRepo.GetThreadBySlackThread(channelID, slackThreadTs)
+}
+
 func (t *ThreadService) CloseThreadStatus(threadID string) error {
 	return t.threadRepo.UpdateThreadStatus(threadID, models.ThreadStatusClosed)
 }
+
+// UpdateThreadAssistant hands the thread over to another assistant, or
+// records the new version of its assistant.
+func (t *ThreadService) UpdateThreadAssistant(threadID string, assistant config.AssistantDefinition) error {
+	return t.threadRepo.UpdateThreadAssistant(threadID, assistant.Name, assistant.Version)
+}
+
+// UpdateThreadContextTokens records the size of the backend thread after a
+// run.
+func (t *ThreadService) UpdateThreadContextTokens(threadID string, contextTokens int64) error {
+	return t.threadRepo.UpdateThreadContextTokens(threadID, contextTokens)
+}
+
+// UpdateThreadLocale switches the language the bot answers the thread in.
+func (t *ThreadService) UpdateThreadLocale(threadID string, locale i18n.Locale) error {
+	return t.threadRepo.UpdateThreadLocale(threadID, string(locale))
+}
+
+// NeedsRollover reports whether the backend thread grew past
+// THREAD_ROLLOVER_TOKENS.
+func (t *ThreadService) NeedsRollover(thread *models.Thread) bool {
+	return t.rolloverTokens > 0 && thread.ContextTokens >= t.rolloverTokens
+}
+
+// RolloverThread points the thread to the backend thread of the rollover. The
+// new backend thread only holds the summary, it returns its estimated size. It
+// reports false when another rollover moved the thread first.
+func (t *ThreadService) RolloverThread(rollover *models.ThreadRollover) (int64, bool, error) {
+	contextTokens := estimateTokens(rollover.Summary)
+	rolledOver, err := t.threadRepo.RolloverThread(rollover, contextTokens)
+	return contextTokens, rolledOver, err
+}
+
+// estimateTokens approximates the tokens of a text, about four characters
+// each.
+func estimateTokens(text string) int64 {
+	return int64(len([]rune(text))+3) / 4
+}
+
+// ExtendThreadDeadline restarts the inactivity timer of an open thread.
+func (t *ThreadService) ExtendThreadDeadline(threadID string) (bool, error) {
+	return t.threadRepo.ExtendThreadDeadline(threadID, time.Now().Add(t.idleTimeout))
+}
+
+func (t *ThreadService) ListThreadsToConfirmClose(before time.Time) ([]models.Thread, error) {
+	return t.threadRepo.ListThreadsToConfirmClose(before)
+}
+
+func (t *ThreadService) MarkCloseConfirmSent(threadID string) (bool, error) {
+	return t.threadRepo.MarkCloseConfirmSent(threadID, time.Now())
+}
+
+func (t *ThreadService) ListExpiredThreads() ([]models.Thread, error) {
+	return t.threadRepo.ListExpiredThreads(time.Now())
+}
+
+func (t *ThreadService) CloseExpiredThread(threadID string) (bool, error) {
+	return t.threadRepo.CloseExpiredThread(threadID, time.Now())
+}
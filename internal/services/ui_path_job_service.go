@@ -3,23 +3,26 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/i18n"
 	"github.com/sotatek-dev/hyper-automation-chatbot/internal/models"
 	"github.com/sotatek-dev/hyper-automation-chatbot/internal/repository"
 	"github.com/sotatek-dev/hyper-automation-chatbot/pkg/rabbitmq"
 )
 
 type UIPathJobService struct {
-	uiPathJobRepository   *repository.UIPathJobRepository
+	uiPathJobRepository   repository.UIPathJobRepositoryInterface
 	UIPathService         *UIPathService
 	SlackService          *SlackService
 	pollingCheckPublisher rabbitmq.IPublisher
+	stateListeners        []func(job *models.UIPathJob)
 }
 
-func NewUIPathJobService(uiPathJobRepository *repository.UIPathJobRepository, pollingCheckPublisher rabbitmq.IPublisher, uiPathService *UIPathService, slackService *SlackService) *UIPathJobService {
+func NewUIPathJobService(uiPathJobRepository repository.UIPathJobRepositoryInterface, pollingCheckPublisher rabbitmq.IPublisher, uiPathService *UIPathService, slackService *SlackService) *UIPathJobService {
 	return &UIPathJobService{
 		uiPathJobRepository:   uiPathJobRepository,
 		pollingCheckPublisher: pollingCheckPublisher,
@@ -32,20 +35,39 @@ func (s *UIPathJobService) CreateJob(job *models.UIPathJob) error {
 	return s.uiPathJobRepository.CreateJob(job)
 }
 
+// OnJobStateChange registers a listener called when a job is started and
+// each time its state changes afterwards. Register the listeners before the
+// jobs are polled.
+func (s *UIPathJobService) OnJobStateChange(listener func(job *models.UIPathJob)) {
+	s.stateListeners = append(s.stateListeners, listener)
+}
+
+func (s *UIPathJobService) notifyStateChange(job *models.UIPathJob) {
+	for _, listener := range s.stateListeners {
+		listener(job)
+	}
+}
+
+// ListJobsBySlackUser lists the latest jobs requested by the Slack user, of
+// any type when jobType is empty.
+func (s *UIPathJobService) ListJobsBySlackUser(slackUserID string, jobType string, limit int) ([]models.UIPathJob, error) {
+	return s.uiPathJobRepository.ListJobsBySlackUser(slackUserID, jobType, limit)
+}
+
 func (s *UIPathJobService) PollingCheck(jobID int) (bool, error) {
 	ticker := time.NewTicker(3 * time.Second)
 	defer ticker.Stop()
 	for range ticker.C {
 		job, err := s.GetJob(jobID)
 		if err != nil {
-			s.SlackService.SendMessage(context.Background(), &job.SlackChannel, err.Error())
+			s.SlackService.SendMessage(context.Background(), &job.SlackChannel, job.SlackThreadTs, err.Error())
 			return false, err
 		}
 		switch job.JobType {
 		case models.JobTypeGreeting:
 			completed, err := s.HandleCheckGreetingJobPolling(job)
 			if err != nil {
-				s.SlackService.SendMessage(context.Background(), &job.SlackChannel, err.Error())
+				s.SlackService.SendMessage(context.Background(), &job.SlackChannel, job.SlackThreadTs, err.Error())
 				return false, err
 			}
 			if completed {
@@ -54,7 +76,7 @@ func (s *UIPathJobService) PollingCheck(jobID int) (bool, error) {
 		case models.JobTypeFillBuddyForm:
 			completed, err := s.HandleCheckFillBuddyFormJobPolling(job)
 			if err != nil {
-				s.SlackService.SendMessage(context.Background(), &job.SlackChannel, err.Error())
+				s.SlackService.SendMessage(context.Background(), &job.SlackChannel, job.SlackThreadTs, err.Error())
 				return false, err
 			}
 			if completed {
@@ -63,7 +85,7 @@ func (s *UIPathJobService) PollingCheck(jobID int) (bool, error) {
 		case models.JobTypeCreateLeaveRequest:
 			completed, err := s.HandleCheckCreateLeaveRequestJobPolling(job)
 			if err != nil {
-				s.SlackService.SendMessage(context.Background(), &job.SlackChannel, err.Error())
+				s.SlackService.SendMessage(context.Background(), &job.SlackChannel, job.SlackThreadTs, err.Error())
 				return false, err
 			}
 			if completed {
@@ -72,7 +94,7 @@ func (s *UIPathJobService) PollingCheck(jobID int) (bool, error) {
 		case models.JobTypeIntegrateTrainingForm:
 			completed, err := s.HandleCheckCreateIntegrateTrainingJobPolling(job)
 			if err != nil {
-				s.SlackService.SendMessage(context.Background(), &job.SlackChannel, err.Error())
+				s.SlackService.SendMessage(context.Background(), &job.SlackChannel, job.SlackThreadTs, err.Error())
 				return false, err
 			}
 			if completed {
@@ -81,7 +103,7 @@ func (s *UIPathJobService) PollingCheck(jobID int) (bool, error) {
 		case models.JobTypePreOnboardEmail:
 			completed, err := s.HandleCheckCreatePreOnboardEmailJobPolling(job)
 			if err != nil {
-				s.SlackService.SendMessage(context.Background(), &job.SlackChannel, err.Error())
+				s.SlackService.SendMessage(context.Background(), &job.SlackChannel, job.SlackThreadTs, err.Error())
 				return false, err
 			}
 			if completed {
@@ -95,23 +117,23 @@ func (s *UIPathJobService) PollingCheck(jobID int) (bool, error) {
 func (s *UIPathJobService) HandleCheckGreetingJobPolling(job *models.UIPathJob) (bool, error) {
 	status, output, err := s.CheckAndUpdateJobStatus(job.JobID)
 	if err != nil {
-		str := "Sorry, something went wrong. Please try again later."
-		s.SlackService.SendMessage(context.Background(), &job.SlackChannel, str)
+		str := i18n.T(i18n.Locale(job.Locale), "error.generic")
+		s.SlackService.SendMessage(context.Background(), &job.SlackChannel, job.SlackThreadTs, str)
 		return true, err
 	}
 	if status == JobStatusCompleted {
 		uiPathGreetingOutput := dto.UIPathGreetingOutput{}
 		err := json.Unmarshal([]byte(output), &uiPathGreetingOutput)
 		if err != nil {
-			str := "Sorry, something went wrong. Please try again later."
-			s.SlackService.SendMessage(context.Background(), &job.SlackChannel, str)
+			str := i18n.T(i18n.Locale(job.Locale), "error.generic")
+			s.SlackService.SendMessage(context.Background(), &job.SlackChannel, job.SlackThreadTs, str)
 			return true, err
 		}
-		s.SlackService.SendMessage(context.Background(), &job.SlackChannel, uiPathGreetingOutput.Greeting)
+		s.SlackService.SendMessage(context.Background(), &job.SlackChannel, job.SlackThreadTs, uiPathGreetingOutput.Greeting)
 		return true, nil
 	} else if status == JobStatusFailed {
-		str := "Sorry, something went wrong. Please try again later."
-		s.SlackService.SendMessage(context.Background(), &job.SlackChannel, str)
+		str := i18n.T(i18n.Locale(job.Locale), "error.generic")
+		s.SlackService.SendMessage(context.Background(), &job.SlackChannel, job.SlackThreadTs, str)
 		return true, nil
 	}
 	return false, nil
@@ -120,24 +142,24 @@ func (s *UIPathJobService) HandleCheckGreetingJobPolling(job *models.UIPathJob)
 func (s *UIPathJobService) HandleCheckFillBuddyFormJobPolling(job *models.UIPathJob) (bool, error) {
 	status, output, err := s.CheckAndUpdateJobStatus(job.JobID)
 	if err != nil {
-		str := "Sorry, something went wrong. Please try again later."
-		s.SlackService.SendMessage(context.Background(), &job.SlackChannel, str)
+		str := i18n.T(i18n.Locale(job.Locale), "error.generic")
+		s.SlackService.SendMessage(context.Background(), &job.SlackChannel, job.SlackThreadTs, str)
 		return true, err
 	}
 	if status == JobStatusCompleted {
 		uiPathFillBuddyOutput := dto.UIPathFillBuddyOutput{}
 		err := json.Unmarshal([]byte(output), &uiPathFillBuddyOutput)
 		if err != nil {
-			str := "Sorry, something went wrong. Please try again later."
-			s.SlackService.SendMessage(context.Background(), &job.SlackChannel, str)
+			str := i18n.T(i18n.Locale(job.Locale), "error.generic")
+			s.SlackService.SendMessage(context.Background(), &job.SlackChannel, job.SlackThreadTs, str)
 			return true, err
 		}
-		response := fmt.Sprintf("Buddy form created successfully. Please check file %s", uiPathFillBuddyOutput.BuddyFormName)
-		s.SlackService.SendMessage(context.Background(), &job.SlackChannel, response)
+		response := i18n.T(i18n.Locale(job.Locale), "uipath.buddy_form_created", uiPathFillBuddyOutput.BuddyFormName)
+		s.SlackService.SendMessage(context.Background(), &job.SlackChannel, job.SlackThreadTs, response)
 		return true, nil
 	} else if status == JobStatusFailed {
-		str := "Sorry, something went wrong. Please try again later."
-		s.SlackService.SendMessage(context.Background(), &job.SlackChannel, str)
+		str := i18n.T(i18n.Locale(job.Locale), "error.generic")
+		s.SlackService.SendMessage(context.Background(), &job.SlackChannel, job.SlackThreadTs, str)
 		return true, nil
 	}
 	return false, nil
@@ -146,8 +168,8 @@ func (s *UIPathJobService) HandleCheckFillBuddyFormJobPolling(job *models.UIPath
 func (s *UIPathJobService) HandleCheckCreateLeaveRequestJobPolling(job *models.UIPathJob) (bool, error) {
 	status, output, err := s.CheckAndUpdateJobStatus(job.JobID)
 	if err != nil {
-		str := "Sorry, something went wrong. Please try again later."
-		s.SlackService.SendMessage(context.Background(), &job.SlackChannel, str)
+		str := i18n.T(i18n.Locale(job.Locale), "error.generic")
+		s.SlackService.SendMessage(context.Background(), &job.SlackChannel, job.SlackThreadTs, str)
 		return true, err
 	}
 	if status == JobStatusCompleted {
@@ -163,8 +185,8 @@ func (s *UIPathJobService) HandleCheckCreateLeaveRequestJobPolling(job *models.U
 		}
 		if uiPathCreateLeaveRequestOutputResponse.Result != nil {
 			if uiPathCreateLeaveRequestOutputResponse.Result.Code == 200 {
-				str := "Leave request created successfully. Please check your calendar."
-				s.SlackService.SendMessage(context.Background(), &job.SlackChannel, str)
+				str := i18n.T(i18n.Locale(job.Locale), "uipath.leave_request_created")
+				s.SlackService.SendMessage(context.Background(), &job.SlackChannel, job.SlackThreadTs, str)
 				return true, nil
 			}
 		}
@@ -172,11 +194,11 @@ func (s *UIPathJobService) HandleCheckCreateLeaveRequestJobPolling(job *models.U
 			err = fmt.Errorf(uiPathCreateLeaveRequestOutputResponse.Error.Data.Message)
 			return true, err
 		}
-		err = fmt.Errorf("sorry, something went wrong - please try again later")
+		err = errors.New(i18n.T(i18n.Locale(job.Locale), "error.generic"))
 		return true, err
 	} else if status == JobStatusFailed {
-		str := "Sorry, something went wrong. Please try again later."
-		s.SlackService.SendMessage(context.Background(), &job.SlackChannel, str)
+		str := i18n.T(i18n.Locale(job.Locale), "error.generic")
+		s.SlackService.SendMessage(context.Background(), &job.SlackChannel, job.SlackThreadTs, str)
 		return true, nil
 	}
 	return false, nil
@@ -185,8 +207,8 @@ func (s *UIPathJobService) HandleCheckCreateLeaveRequestJobPolling(job *models.U
 func (s *UIPathJobService) HandleCheckCreateIntegrateTrainingJobPolling(job *models.UIPathJob) (bool, error) {
 	status, output, err := s.CheckAndUpdateJobStatus(job.JobID)
 	if err != nil {
-		str := "Sorry, something went wrong. Please try again later."
-		s.SlackService.SendMessage(context.Background(), &job.SlackChannel, str)
+		str := i18n.T(i18n.Locale(job.Locale), "error.generic")
+		s.SlackService.SendMessage(context.Background(), &job.SlackChannel, job.SlackThreadTs, str)
 		return true, err
 	}
 	if status == JobStatusCompleted {
@@ -196,14 +218,14 @@ func (s *UIPathJobService) HandleCheckCreateIntegrateTrainingJobPolling(job *mod
 			return true, err
 		}
 		if uiPathCreateIntegrateTrainingOutput.CalendarId != "" {
-			s.SlackService.SendMessage(context.Background(), &job.SlackChannel, uiPathCreateIntegrateTrainingOutput.CalendarId)
+			s.SlackService.SendMessage(context.Background(), &job.SlackChannel, job.SlackThreadTs, uiPathCreateIntegrateTrainingOutput.CalendarId)
 			return true, nil
 		}
 		err = fmt.Errorf(uiPathCreateIntegrateTrainingOutput.ErrMessage)
 		return true, err
 	} else if status == JobStatusFailed {
-		str := "Sorry, something went wrong. Please try again later."
-		s.SlackService.SendMessage(context.Background(), &job.SlackChannel, str)
+		str := i18n.T(i18n.Locale(job.Locale), "error.generic")
+		s.SlackService.SendMessage(context.Background(), &job.SlackChannel, job.SlackThreadTs, str)
 		return true, nil
 	}
 	return false, nil
@@ -211,8 +233,8 @@ func (s *UIPathJobService) HandleCheckCreateIntegrateTrainingJobPolling(job *mod
 func (s *UIPathJobService) HandleCheckCreatePreOnboardEmailJobPolling(job *models.UIPathJob) (bool, error) {
 	status, output, err := s.CheckAndUpdateJobStatus(job.JobID)
 	if err != nil {
-		str := "Sorry, something went wrong. Please try again later."
-		s.SlackService.SendMessage(context.Background(), &job.SlackChannel, str)
+		str := i18n.T(i18n.Locale(job.Locale), "error.generic")
+		s.SlackService.SendMessage(context.Background(), &job.SlackChannel, job.SlackThreadTs, str)
 		return true, err
 	}
 	if status == JobStatusCompleted {
@@ -222,16 +244,16 @@ func (s *UIPathJobService) HandleCheckCreatePreOnboardEmailJobPolling(job *model
 			return true, err
 		}
 		if uiPathCreatePreOnboardEmailOutput.JobInfoMessage != "" {
-			s.SlackService.SendMessage(context.Background(), &job.SlackChannel, uiPathCreatePreOnboardEmailOutput.JobInfoMessage)
+			s.SlackService.SendMessage(context.Background(), &job.SlackChannel, job.SlackThreadTs, uiPathCreatePreOnboardEmailOutput.JobInfoMessage)
 			for _, message := range uiPathCreatePreOnboardEmailOutput.ErrMessage {
-				s.SlackService.SendMessage(context.Background(), &job.SlackChannel, message)
+				s.SlackService.SendMessage(context.Background(), &job.SlackChannel, job.SlackThreadTs, message)
 			}
 			return true, nil
 		}
 		return true, err
 	} else if status == JobStatusFailed {
-		str := "Sorry, something went wrong. Please try again later."
-		s.SlackService.SendMessage(context.Background(), &job.SlackChannel, str)
+		str := i18n.T(i18n.Locale(job.Locale), "error.generic")
+		s.SlackService.SendMessage(context.Background(), &job.SlackChannel, job.SlackThreadTs, str)
 		return true, nil
 	}
 	return false, nil
@@ -246,22 +268,33 @@ func (s *UIPathJobService) CheckAndUpdateJobStatus(jobID int) (string, string, e
 	if err != nil {
 		job.State = JobStatusFailed
 		job.Error = err.Error()
-		s.UpdateJob(job)
+		s.updateJobState(job)
 		return JobStatusFailed, "", err
 	}
 	if jobDetails.State == JobStatusCompleted {
 		job.State = JobStatusCompleted
 		job.Output = jobDetails.OutputArguments
-		s.UpdateJob(job)
+		s.updateJobState(job)
 		return JobStatusCompleted, jobDetails.OutputArguments, nil
 	} else if jobDetails.State == JobStatusFailed {
 		job.State = JobStatusFailed
-		s.UpdateJob(job)
+		s.updateJobState(job)
 		return JobStatusFailed, "", nil
 	}
+	if jobDetails.State == JobStatusRunning && job.State != JobStatusRunning {
+		job.State = JobStatusRunning
+		s.updateJobState(job)
+	}
 	return JobStatusPending, "", nil
 }
 
+func (s *UIPathJobService) updateJobState(job *models.UIPathJob) {
+	if err := s.UpdateJob(job); err != nil {
+		return
+	}
+	s.notifyStateChange(job)
+}
+
 func (s *UIPathJobService) GetJob(jobID int) (*models.UIPathJob, error) {
 	return s.uiPathJobRepository.GetJob(jobID)
 }
@@ -270,92 +303,69 @@ func (s *UIPathJobService) UpdateJob(job *models.UIPathJob) error {
 	return s.uiPathJobRepository.UpdateJob(job)
 }
 
-func (s *UIPathJobService) CreateGreetingJob(input dto.UIPathGreetingNewEmployee, slackChannel string) error {
+func (s *UIPathJobService) CreateGreetingJob(input dto.UIPathGreetingNewEmployee, slackUserID string, slackChannel string, slackThreadTs string, locale i18n.Locale) error {
 	uiJob, err := s.UIPathService.GreetingNewEmployee(input)
 	if err != nil {
 		return err
 	}
-	job := &models.UIPathJob{
-		JobID:        uiJob.ID,
-		JobType:      models.JobTypeGreeting,
-		SlackChannel: slackChannel,
-	}
-	err = s.uiPathJobRepository.CreateJob(job)
-	if err != nil {
-		return err
-	}
-	s.pollingCheckPublisher.PublishMessage(dto.UIPathCheckingJobInput{JobID: job.JobID})
-	return nil
+	return s.startJob(models.JobTypeGreeting, uiJob.ID, input, slackUserID, slackChannel, slackThreadTs, locale)
 }
 
-func (s *UIPathJobService) CreateFillBuddyJob(input dto.UIPathFillBuddyInput, slackChannel string) error {
+func (s *UIPathJobService) CreateFillBuddyJob(input dto.UIPathFillBuddyInput, slackUserID string, slackChannel string, slackThreadTs string, locale i18n.Locale) error {
 	uiJob, err := s.UIPathService.FillBuddyForm(input)
 	if err != nil {
 		return err
 	}
-	job := &models.UIPathJob{
-		JobID:        uiJob.ID,
-		JobType:      models.JobTypeFillBuddyForm,
-		SlackChannel: slackChannel,
-	}
-	err = s.uiPathJobRepository.CreateJob(job)
-	if err != nil {
-		return err
-	}
-	s.pollingCheckPublisher.PublishMessage(dto.UIPathCheckingJobInput{JobID: job.JobID})
-	return nil
+	return s.startJob(models.JobTypeFillBuddyForm, uiJob.ID, input, slackUserID, slackChannel, slackThreadTs, locale)
 }
 
-func (s *UIPathJobService) CreateIntegrateTrainingJob(input dto.UIPathCreateIntegrateTrainingInput, slackChannel string) error {
+func (s *UIPathJobService) CreateIntegrateTrainingJob(input dto.UIPathCreateIntegrateTrainingInput, slackUserID string, slackChannel string, slackThreadTs string, locale i18n.Locale) error {
 	uiJob, err := s.UIPathService.CreateIntegrateTraining(input)
 	if err != nil {
 		return err
 	}
-	job := &models.UIPathJob{
-		JobID:        uiJob.ID,
-		JobType:      models.JobTypeIntegrateTrainingForm,
-		SlackChannel: slackChannel,
-	}
-	err = s.uiPathJobRepository.CreateJob(job)
-	if err != nil {
-		return err
-	}
-	s.pollingCheckPublisher.PublishMessage(dto.UIPathCheckingJobInput{JobID: job.JobID})
-	return nil
+	return s.startJob(models.JobTypeIntegrateTrainingForm, uiJob.ID, input, slackUserID, slackChannel, slackThreadTs, locale)
 }
 
-func (s *UIPathJobService) CreateLeaveRequestJob(input dto.UIPathCreateLeaveRequestInput, slackChannel string) error {
+func (s *UIPathJobService) CreateLeaveRequestJob(input dto.UIPathCreateLeaveRequestInput, slackUserID string, slackChannel string, slackThreadTs string, locale i18n.Locale) error {
 	uiJob, err := s.UIPathService.CreateLeaveRequestOnOdoo(input)
 	if err != nil {
 		return err
 	}
-	job := &models.UIPathJob{
-		JobID:        uiJob.ID,
-		JobType:      models.JobTypeCreateLeaveRequest,
-		SlackChannel: slackChannel,
-	}
-	err = s.uiPathJobRepository.CreateJob(job)
+	return s.startJob(models.JobTypeCreateLeaveRequest, uiJob.ID, input, slackUserID, slackChannel, slackThreadTs, locale)
+}
+
+func (s *UIPathJobService) CreatePreOnboardEmailJob(input dto.UIPathPreOnboardEmailInput, slackUserID string, slackChannel string, slackThreadTs string, locale i18n.Locale) error {
+	uiJob, err := s.UIPathService.PreOnboardEmail(input)
 	if err != nil {
 		return err
 	}
-	s.pollingCheckPublisher.PublishMessage(dto.UIPathCheckingJobInput{JobID: job.JobID})
-	return nil
+	return s.startJob(models.JobTypePreOnboardEmail, uiJob.ID, input, slackUserID, slackChannel, slackThreadTs, locale)
 }
 
-func (s *UIPathJobService) CreatePreOnboardEmailJob(input dto.UIPathPreOnboardEmailInput, slackChannel string) error {
-	uiJob, err := s.UIPathService.PreOnboardEmail(input)
+// startJob records the UiPath job started for the Slack user and queues its
+// polling. The input is kept with the job, e.g. for the dates of a leave
+// request.
+func (s *UIPathJobService) startJob(jobType string, jobID int, input interface{}, slackUserID string, slackChannel string, slackThreadTs string, locale i18n.Locale) error {
+	encodedInput, err := json.Marshal(input)
 	if err != nil {
 		return err
 	}
 	job := &models.UIPathJob{
-		JobID:        uiJob.ID,
-		JobType:      models.JobTypePreOnboardEmail,
-		SlackChannel: slackChannel,
+		JobID:         jobID,
+		JobType:       jobType,
+		State:         JobStatusPending,
+		SlackUserID:   slackUserID,
+		SlackChannel:  slackChannel,
+		SlackThreadTs: slackThreadTs,
+		Locale:        string(locale),
+		Input:         encodedInput,
 	}
 	err = s.uiPathJobRepository.CreateJob(job)
 	if err != nil {
 		return err
 	}
+	s.notifyStateChange(job)
 	s.pollingCheckPublisher.PublishMessage(dto.UIPathCheckingJobInput{JobID: job.JobID})
 	return nil
 }
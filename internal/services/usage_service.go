@@ -0,0 +1,84 @@
+package services
+
+import (
+	"time"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/config"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/models"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/repository"
+)
+
+// UsageService records the tokens spent by assistant runs, prices them and
+// enforces the daily token quota of the Slack users.
+type UsageService struct {
+	runUsageRepo repository.RunUsageRepositoryInterface
+	usageConfig  config.UsageConfig
+}
+
+type UsageServiceInterface interface {
+	RecordRunUsage(run *dto.AzureAIRun, threadID string, slackUserID string, channelID string) error
+	IsDailyQuotaExceeded(slackUserID string) (bool, error)
+	ListUsageSummary(query dto.UsageSummaryQuery) ([]dto.UsageSummaryResponse, error)
+	DailyTokenQuota() int64
+}
+
+func NewUsageService(runUsageRepo repository.RunUsageRepositoryInterface, usageConfig config.UsageConfig) *UsageService {
+	return &UsageService{runUsageRepo: runUsageRepo, usageConfig: usageConfig}
+}
+
+// RecordRunUsage stores the usage of a finished run. Runs without usage, such
+// as runs that failed before reaching the model, are skipped.
+func (s *UsageService) RecordRunUsage(run *dto.AzureAIRun, threadID string, slackUserID string, channelID string) error {
+	if run.Usage == nil {
+		return nil
+	}
+	return s.runUsageRepo.CreateRunUsage(&models.RunUsage{
+		RunID:            run.ID,
+		ThreadID:         threadID,
+		SlackUserID:      slackUserID,
+		ChannelID:        channelID,
+		Model:            run.Model,
+		PromptTokens:     run.Usage.PromptTokens,
+		CompletionTokens: run.Usage.CompletionTokens,
+		TotalTokens:      run.Usage.TotalTokens,
+		Cost:             s.Cost(run.Model, run.Usage),
+	})
+}
+
+// Cost prices the usage of a run in USD. Models without a configured price
+// cost nothing, their tokens are still counted against the quota.
+func (s *UsageService) Cost(model string, usage *dto.AzureAIRunUsage) float64 {
+	price, ok := s.usageConfig.Prices[model]
+	if !ok {
+		return 0
+	}
+	return (float64(usage.PromptTokens)*price.Prompt + float64(usage.CompletionTokens)*price.Completion) / 1_000_000
+}
+
+// IsDailyQuotaExceeded reports whether the Slack user already spent their
+// tokens of the current UTC day.
+func (s *UsageService) IsDailyQuotaExceeded(slackUserID string) (bool, error) {
+	if s.usageConfig.DailyTokenQuota <= 0 {
+		return false, nil
+	}
+	startOfDay := time.Now().UTC().Truncate(24 * time.Hour)
+	used, err := s.runUsageRepo.SumTotalTokensByUserSince(slackUserID, startOfDay)
+	if err != nil {
+		return false, err
+	}
+	return used >= s.usageConfig.DailyTokenQuota, nil
+}
+
+// DailyTokenQuota is the number of tokens a Slack user may spend per day, zero
+// when there is no quota.
+func (s *UsageService) DailyTokenQuota() int64 {
+	return s.usageConfig.DailyTokenQuota
+}
+
+// ListUsageSummary returns the usage per day, Slack user and channel between
+// query.From and query.To, both days included.
+func (s *UsageService) ListUsageSummary(query dto.UsageSummaryQuery) ([]dto.UsageSummaryResponse, error) {
+	query.To = query.To.AddDate(0, 0, 1)
+	return s.runUsageRepo.ListUsageSummary(query)
+}
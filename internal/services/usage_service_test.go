@@ -0,0 +1,100 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/config"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeRunUsageRepository struct {
+	usages    []models.RunUsage
+	usedToday int64
+}
+
+func (f *fakeRunUsageRepository) CreateRunUsage(usage *models.RunUsage) error {
+	f.usages = append(f.usages, *usage)
+	return nil
+}
+
+func (f *fakeRunUsageRepository) SumTotalTokensByUserSince(slackUserID string, since time.Time) (int64, error) {
+	return f.usedToday, nil
+}
+
+func (f *fakeRunUsageRepository) ListUsageSummary(query dto.UsageSummaryQuery) ([]dto.UsageSummaryResponse, error) {
+	return nil, nil
+}
+
+func TestUsageServiceRecordRunUsage(t *testing.T) {
+	prices, err := config.ParseModelPricing("gpt-4o=2.5:10, gpt-4o-mini=0.15:0.6")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		run       *dto.AzureAIRun
+		wantUsage []models.RunUsage
+	}{
+		{
+			name: "priced model",
+			run:  &dto.AzureAIRun{ID: "run_1", Model: "gpt-4o", Usage: &dto.AzureAIRunUsage{PromptTokens: 1000, CompletionTokens: 200, TotalTokens: 1200}},
+			wantUsage: []models.RunUsage{{
+				RunID: "run_1", ThreadID: "thread_1", SlackUserID: "U1", ChannelID: "C1", Model: "gpt-4o",
+				PromptTokens: 1000, CompletionTokens: 200, TotalTokens: 1200, Cost: 0.0045,
+			}},
+		},
+		{
+			name: "unpriced model is free",
+			run:  &dto.AzureAIRun{ID: "run_1", Model: "llama3", Usage: &dto.AzureAIRunUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}},
+			wantUsage: []models.RunUsage{{
+				RunID: "run_1", ThreadID: "thread_1", SlackUserID: "U1", ChannelID: "C1", Model: "llama3",
+				PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15,
+			}},
+		},
+		{
+			name: "run without usage is skipped",
+			run:  &dto.AzureAIRun{ID: "run_1", Model: "gpt-4o"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRunUsageRepository{}
+			service := NewUsageService(repo, config.UsageConfig{Prices: prices})
+
+			err := service.RecordRunUsage(tt.run, "thread_1", "U1", "C1")
+
+			assert.NoError(t, err)
+			assert.Len(t, repo.usages, len(tt.wantUsage))
+			for i, want := range tt.wantUsage {
+				assert.InDelta(t, want.Cost, repo.usages[i].Cost, 1e-9)
+				repo.usages[i].Cost = want.Cost
+				assert.Equal(t, want, repo.usages[i])
+			}
+		})
+	}
+}
+
+func TestUsageServiceIsDailyQuotaExceeded(t *testing.T) {
+	tests := []struct {
+		name      string
+		quota     int64
+		usedToday int64
+		want      bool
+	}{
+		{name: "no quota", quota: 0, usedToday: 1_000_000, want: false},
+		{name: "under quota", quota: 1000, usedToday: 999, want: false},
+		{name: "quota reached", quota: 1000, usedToday: 1000, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewUsageService(&fakeRunUsageRepository{usedToday: tt.usedToday}, config.UsageConfig{DailyTokenQuota: tt.quota})
+
+			exceeded, err := service.IsDailyQuotaExceeded("U1")
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, exceeded)
+		})
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/sotatek-dev/hyper-automation-chatbot/internal/google_internal"
 	"github.com/sotatek-dev/hyper-automation-chatbot/internal/repository"
 	"github.com/sotatek-dev/hyper-automation-chatbot/internal/services"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/slack_handlers"
 	"github.com/sotatek-dev/hyper-automation-chatbot/pkg/logger"
 	"github.com/sotatek-dev/hyper-automation-chatbot/pkg/rabbitmq"
 	"github.com/streadway/amqp"
@@ -17,21 +18,28 @@ import (
 )
 
 type AppDependencies struct {
-	UIPathJobService *services.UIPathJobService
-	Logger           *zerolog.Logger
-	UIPathJobRepo    *repository.UIPathJobRepository
-	DB               *gorm.DB
-	RabbitConn       *amqp.Connection
-	AiChatbotService *services.AIChatbotService
-	SlackService     *services.SlackService
-	GgSheetService   *services.GSheetService
-	UiPathService    *services.UIPathService
-	SlackClient      *slack.Client
-	ThreadService    *services.ThreadService
-	MessageService   *services.MessageService
-	ThreadRepo       *repository.ThreadRepository
-	MessageRepo      *repository.MessageRepository
-	Config           *config.Config
+	UIPathJobService     *services.UIPathJobService
+	Logger               *zerolog.Logger
+	UIPathJobRepo        *repository.UIPathJobRepository
+	DB                   *gorm.DB
+	RabbitConn           *amqp.Connection
+	AiChatbotService     *services.AIChatbotService
+	SlackService         *services.SlackService
+	GgSheetService       *services.GSheetService
+	UiPathService        *services.UIPathService
+	SlackClient          *slack.Client
+	ThreadService        *services.ThreadService
+	ThreadExpiryService  *services.ThreadExpiryService
+	MessageService       *services.MessageService
+	UsageService         *services.UsageService
+	ColumnMappingService *services.ColumnMappingService
+	// SlackHandler answers the Slack events, interactions and slash
+	// commands, whatever the transport they come from.
+	SlackHandler  *slack_handlers.SlackHandler
+	LocaleService *services.LocaleService
+	ThreadRepo    *repository.ThreadRepository
+	MessageRepo   *repository.MessageRepository
+	Config        *config.Config
 }
 
 func InitDependencies(db *gorm.DB, rabbitConn *amqp.Connection, cfg *config.Config) AppDependencies {
@@ -45,16 +53,33 @@ func InitDependencies(db *gorm.DB, rabbitConn *amqp.Connection, cfg *config.Conf
 	slackService := services.NewSlackService(&cfg.SlackConfig, slackClient)
 	threadRepo := repository.NewThreadRepository(db)
 	messageRepo := repository.NewMessageRepository(db)
-	threadService := services.NewThreadService(threadRepo)
+	threadService := services.NewThreadService(threadRepo, cfg.Thread)
 	messageService := services.NewMessageService(messageRepo)
-	aiChatbotService := services.NewAIChatbotService(cfg.AzureOpenAI, slackService, threadService, messageService)
+	usageService := services.NewUsageService(repository.NewRunUsageRepository(db), cfg.Usage)
+	llmBackend := services.NewLLMBackend(cfg.LLM, cfg.AzureOpenAI, repository.NewChatCompletionRepository(db))
+	assistantRouter, err := services.NewAssistantRouter(cfg.Assistants)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Cannot load assistants")
+	}
+	knowledgeService := services.NewKnowledgeService(
+		repository.NewDocumentChunkRepository(db),
+		services.NewEmbedder(cfg.RAG, cfg.AzureOpenAI),
+		cfg.RAG,
+	)
+	redactionService, err := services.NewPIIRedactionService(repository.NewPIIPlaceholderRepository(db), cfg.Redaction, &logger)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Cannot load PII redaction")
+	}
+	answerCache := services.NewAnswerCacheService(repository.NewCachedAnswerRepository(db), cfg.AnswerCache)
+	aiChatbotService := services.NewAIChatbotService(llmBackend, cfg.AzureOpenAI, slackService, threadService, messageService, usageService, assistantRouter, knowledgeService, redactionService, answerCache)
 	ggSheetService := services.NewGSheetService(
 		google_internal.GetSheetService(&cfg.Google),
 		google_internal.GetDriveService(&cfg.Google),
 	)
 	uiPathService := services.NewUIPathService(http.DefaultClient, cfg.UIPath)
+	columnMappingService := services.NewColumnMappingService(repository.NewColumnMappingRepository(db), ggSheetService, aiChatbotService)
 
-	return AppDependencies{
+	dependencies := AppDependencies{
 		UIPathJobService: services.NewUIPathJobService(uiPathJobRepo,
 			rabbitmq.NewPublisher(context.Background(),
 				&rabbitmq.RabbitMQConfig{
@@ -72,19 +97,36 @@ func InitDependencies(db *gorm.DB, rabbitConn *amqp.Connection, cfg *config.Conf
 			uiPathService,
 			slackService,
 		),
-		Logger:           &logger,
-		UIPathJobRepo:    uiPathJobRepo,
-		DB:               db,
-		RabbitConn:       rabbitConn,
-		AiChatbotService: aiChatbotService,
-		SlackService:     slackService,
-		GgSheetService:   ggSheetService,
-		UiPathService:    uiPathService,
-		ThreadService:    threadService,
-		MessageService:   messageService,
-		ThreadRepo:       threadRepo,
-		MessageRepo:      messageRepo,
-		Config:           cfg,
-		SlackClient:      slackClient,
+		Logger:               &logger,
+		UIPathJobRepo:        uiPathJobRepo,
+		DB:                   db,
+		RabbitConn:           rabbitConn,
+		AiChatbotService:     aiChatbotService,
+		SlackService:         slackService,
+		GgSheetService:       ggSheetService,
+		UiPathService:        uiPathService,
+		ThreadService:        threadService,
+		ThreadExpiryService:  services.NewThreadExpiryService(threadService, slackService, llmBackend, cfg.Thread, &logger),
+		MessageService:       messageService,
+		UsageService:         usageService,
+		ColumnMappingService: columnMappingService,
+		LocaleService:        services.NewLocaleService(slackClient, threadService),
+		ThreadRepo:           threadRepo,
+		MessageRepo:          messageRepo,
+		Config:               cfg,
+		SlackClient:          slackClient,
 	}
+	dependencies.SlackHandler = slack_handlers.NewSlackHandler(
+		slackClient,
+		slackService,
+		aiChatbotService,
+		ggSheetService,
+		dependencies.UIPathJobService,
+		threadService,
+		usageService,
+		columnMappingService,
+		dependencies.LocaleService,
+		&logger,
+	)
+	return dependencies
 }
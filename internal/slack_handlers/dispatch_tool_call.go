@@ -0,0 +1,46 @@
+package slack_handlers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/i18n"
+)
+
+type toolCallHandler func(channelID string, threadTs string, locale i18n.Locale, arguments json.RawMessage) error
+
+// bindToolArguments decodes the raw tool call arguments into the handler's
+// argument struct before calling it.
+func bindToolArguments[T any](handle func(channelID string, threadTs string, locale i18n.Locale, args T) error) toolCallHandler {
+	return func(channelID string, threadTs string, locale i18n.Locale, arguments json.RawMessage) error {
+		var args T
+		if len(arguments) > 0 {
+			if err := json.Unmarshal(arguments, &args); err != nil {
+				return fmt.Errorf("invalid arguments: %w", err)
+			}
+		}
+		return handle(channelID, threadTs, locale, args)
+	}
+}
+
+func (s *SlackHandler) newToolCallHandlers() map[string]toolCallHandler {
+	return map[string]toolCallHandler{
+		dto.ToolOnboardNhanVien:     bindToolArguments(s.handleCandidateSheetEvent),
+		dto.ToolWelcomeNewEmployee:  bindToolArguments(s.handleGreetingNewEmployeeEvent),
+		dto.ToolCreateBuddyFormFile: bindToolArguments(s.handleCreateBuddyFormEvent),
+		dto.ToolTakeLeave:           bindToolArguments(s.handleLeaveRequestEvent),
+		dto.ToolTrainingRequest:     bindToolArguments(s.handleIntegrateTrainingEvent),
+	}
+}
+
+func (s *SlackHandler) dispatchToolCall(channelID string, threadTs string, locale i18n.Locale, toolCall dto.AIToolCall) error {
+	handle, ok := s.toolCallHandlers[toolCall.Name]
+	if !ok {
+		return fmt.Errorf("unsupported tool call: %s", toolCall.Name)
+	}
+	if err := handle(channelID, threadTs, locale, toolCall.Arguments); err != nil {
+		return fmt.Errorf("failed to handle tool call %s: %w", toolCall.Name, err)
+	}
+	return nil
+}
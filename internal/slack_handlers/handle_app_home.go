@@ -0,0 +1,221 @@
+package slack_handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/i18n"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/models"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/services"
+	"gorm.io/gorm"
+)
+
+const (
+	// appHomeJobs is the number of recent jobs the Home tab lists.
+	appHomeJobs = 5
+	// appHomeLeaveRequests is the number of latest leave requests looked
+	// through for the pending ones.
+	appHomeLeaveRequests = 20
+	// leaveRequestDateLayout is the date layout of the leave request jobs.
+	leaveRequestDateLayout = "02/01/2006"
+)
+
+var jobStateEmojis = map[string]string{
+	services.JobStatusPending:   ":hourglass_flowing_sand:",
+	services.JobStatusRunning:   ":arrows_counterclockwise:",
+	services.JobStatusCompleted: ":white_check_mark:",
+	services.JobStatusFailed:    ":x:",
+}
+
+func (s *SlackHandler) handleAppHomeOpenedEvent(event *slackevents.AppHomeOpenedEvent) error {
+	if event.Tab != "home" {
+		return nil
+	}
+	return s.PublishAppHome(context.Background(), event.User)
+}
+
+// HandleJobStateChange renders the Home tab of the user who requested the
+// job again, with its new state.
+func (s *SlackHandler) HandleJobStateChange(job *models.UIPathJob) error {
+	if job.SlackUserID == "" {
+		return nil
+	}
+	return s.PublishAppHome(context.Background(), job.SlackUserID)
+}
+
+// PublishAppHome renders the Home tab of the user: their open conversation,
+// their recent jobs, their pending leave requests and the buttons opening
+// the workflow forms they can fill.
+func (s *SlackHandler) PublishAppHome(ctx context.Context, userID string) error {
+	locale := s.localeService.Locale(ctx, "", "", userID, "")
+	blocks := []slack.Block{slack.NewHeaderBlock(plainText(i18n.T(locale, "home.title")))}
+
+	thread, err := s.threadService.GetLatestOpenThreadByUserID(userID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	text := i18n.T(locale, "home.thread.none")
+	if err == nil {
+		text = i18n.T(locale, "home.thread.open", thread.ChannelId, slackDate(thread.CreatedAt))
+	}
+	blocks = append(blocks, homeSection(i18n.T(locale, "home.thread.title"), text))
+
+	jobs, err := s.uiPathJobService.ListJobsBySlackUser(userID, "", appHomeJobs)
+	if err != nil {
+		return err
+	}
+	lines := []string{}
+	for _, job := range jobs {
+		lines = append(lines, formatHomeJob(job, locale))
+	}
+	if len(lines) == 0 {
+		lines = append(lines, i18n.T(locale, "home.jobs.none"))
+	}
+	blocks = append(blocks, homeSection(i18n.T(locale, "home.jobs.title"), strings.Join(lines, "\n")))
+
+	leaveRequests, err := s.uiPathJobService.ListJobsBySlackUser(userID, models.JobTypeCreateLeaveRequest, appHomeLeaveRequests)
+	if err != nil {
+		return err
+	}
+	lines = []string{}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	for _, job := range leaveRequests {
+		if line, ok := formatPendingLeaveRequest(job, today, locale); ok {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		lines = append(lines, i18n.T(locale, "home.leaves.none"))
+	}
+	blocks = append(blocks, homeSection(i18n.T(locale, "home.leaves.title"), strings.Join(lines, "\n")))
+
+	actions, err := s.homeActions(ctx, userID, locale)
+	if err != nil {
+		return err
+	}
+	if len(actions) > 0 {
+		blocks = append(blocks,
+			slack.NewDividerBlock(),
+			slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, "*"+i18n.T(locale, "home.actions.title")+"*", false, false), nil, nil),
+			slack.NewActionBlock("home_actions", actions...),
+		)
+	}
+
+	_, err = s.slackClient.PublishViewContext(ctx, userID, slack.HomeTabViewRequest{
+		Type:   slack.VTHomeTab,
+		Blocks: slack.Blocks{BlockSet: blocks},
+	}, "")
+	if err != nil {
+		return fmt.Errorf("failed to publish home tab: %w", err)
+	}
+	return nil
+}
+
+// homeActions are the buttons opening the forms of the commands the user can
+// run. The commands restricted to user groups are left out when the groups
+// cannot be read, the forms check the groups again when opened and submitted.
+func (s *SlackHandler) homeActions(ctx context.Context, userID string, locale i18n.Locale) ([]slack.BlockElement, error) {
+	actions := []slack.BlockElement{}
+	allowedGroups := map[string]bool{}
+	for _, command := range s.slashCommands {
+		if command.form == "" {
+			continue
+		}
+		groups := strings.Join(command.groups, ",")
+		allowed, ok := allowedGroups[groups]
+		if !ok {
+			var err error
+			allowed, err = s.canRun(ctx, userID, command)
+			if err != nil {
+				s.logger.Warn().Err(err).Str("user_id", userID).Str("groups", groups).Msg("Cannot check the user groups of the Home tab actions")
+			} else {
+				allowedGroups[groups] = allowed
+			}
+		}
+		registered, ok := s.forms[command.form]
+		if !allowed || !ok {
+			continue
+		}
+		value, err := json.Marshal(dto.FormLauncher{Form: command.form})
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, slack.NewButtonBlockElement("open_form", string(value), plainText(i18n.T(locale, registered.form.Title))))
+	}
+	return actions, nil
+}
+
+func formatHomeJob(job models.UIPathJob, locale i18n.Locale) string {
+	state := job.State
+	if state == "" {
+		state = services.JobStatusPending
+	}
+	line := fmt.Sprintf("%s *%s* · %s · %s", jobStateEmojis[state], i18n.T(locale, "home.job_type."+job.JobType), i18n.T(locale, "home.job_state."+strings.ToLower(state)), slackDate(job.CreatedAt))
+	if result := jobResult(job, locale); result != "" {
+		line += "\n      " + result
+	}
+	return line
+}
+
+// jobResult is the outcome of a finished job, empty when there is nothing to
+// tell more than its state.
+func jobResult(job models.UIPathJob, locale i18n.Locale) string {
+	switch job.State {
+	case services.JobStatusFailed:
+		if job.Error == "" {
+			return i18n.T(locale, "error.generic")
+		}
+		return job.Error
+	case services.JobStatusCompleted:
+		if job.JobType != models.JobTypeFillBuddyForm {
+			return ""
+		}
+		output := dto.UIPathFillBuddyOutput{}
+		if err := json.Unmarshal([]byte(job.Output), &output); err != nil || output.BuddyFormName == "" {
+			return ""
+		}
+		return i18n.T(locale, "uipath.buddy_form_created", output.BuddyFormName)
+	}
+	return ""
+}
+
+// formatPendingLeaveRequest describes a leave request that did not fail and
+// does not end before today, it is waiting to be created or approved.
+func formatPendingLeaveRequest(job models.UIPathJob, today time.Time, locale i18n.Locale) (string, bool) {
+	if job.State == services.JobStatusFailed {
+		return "", false
+	}
+	var input dto.UIPathCreateLeaveRequestInput
+	if err := json.Unmarshal(job.Input, &input); err != nil {
+		return "", false
+	}
+	dateTo, err := time.Parse(leaveRequestDateLayout, input.RequestDateTo)
+	if err != nil || dateTo.Before(today) {
+		return "", false
+	}
+	status := i18n.T(locale, "home.leave.submitting")
+	if job.State == services.JobStatusCompleted {
+		status = i18n.T(locale, "home.leave.awaiting_approval")
+	}
+	return i18n.T(locale, "home.leave.row", input.RequestDateFrom, input.RequestDateTo, status), true
+}
+
+func homeSection(title string, text string) slack.Block {
+	return slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, "*"+title+"*\n"+text, false, false), nil, nil)
+}
+
+// slackDate formats the time in the timezone of the reader.
+func slackDate(t time.Time) string {
+	return fmt.Sprintf("<!date^%d^{date_short_pretty} {time}|%s>", t.Unix(), t.UTC().Format("2006-01-02 15:04 UTC"))
+}
+
+func plainText(text string) *slack.TextBlockObject {
+	return slack.NewTextBlockObject(slack.PlainTextType, text, false, false)
+}
@@ -0,0 +1,125 @@
+package slack_handlers
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/mocks"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/models"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublishAppHome(t *testing.T) {
+	now := time.Now()
+	leaveRequest := func(jobID int, state string, to time.Time) models.UIPathJob {
+		input, _ := json.Marshal(map[string]string{"request_date_from": "01/05/2024", "request_date_to": to.Format("02/01/2006")})
+		return models.UIPathJob{JobID: jobID, JobType: models.JobTypeCreateLeaveRequest, State: state, SlackUserID: "U1", Input: input, CreatedAt: now.Add(-time.Duration(jobID) * time.Minute)}
+	}
+	jobs := []models.UIPathJob{
+		{JobID: 1, JobType: models.JobTypeFillBuddyForm, State: services.JobStatusCompleted, Output: `{"buddyFormName":"Buddy - An"}`, SlackUserID: "U1", CreatedAt: now.Add(-time.Minute)},
+		{JobID: 2, JobType: models.JobTypeGreeting, State: services.JobStatusFailed, Error: "robot offline", SlackUserID: "U1", CreatedAt: now.Add(-2 * time.Minute)},
+		leaveRequest(3, services.JobStatusPending, now.AddDate(0, 0, 3)),
+		leaveRequest(4, services.JobStatusCompleted, now.AddDate(0, 0, 10)),
+		leaveRequest(5, services.JobStatusCompleted, now.AddDate(0, 0, -3)),
+		leaveRequest(6, services.JobStatusFailed, now.AddDate(0, 0, 3)),
+		{JobID: 7, JobType: models.JobTypeFillBuddyForm, State: services.JobStatusRunning, SlackUserID: "U2", CreatedAt: now},
+	}
+
+	tests := []struct {
+		name        string
+		userID      string
+		wantText    []string
+		wantMissing []string
+		wantActions int
+	}{
+		{
+			name:   "dashboard of a member of the HR group",
+			userID: "U1",
+			wantText: []string{
+				"You have an open conversation in <#C1>",
+				"*Buddy form* · Done",
+				"Buddy form created successfully. Please check file Buddy - An",
+				"*Welcome new employee* · Failed",
+				"robot offline",
+				"Awaiting approval",
+				"Being submitted",
+			},
+			wantMissing: []string{"Running", now.AddDate(0, 0, -3).Format("02/01/2006")},
+			wantActions: 5,
+		},
+		{
+			name:        "dashboard of another user",
+			userID:      "U2",
+			wantText:    []string{"You have no open conversation", "*Buddy form* · Running", "You have no pending leave request."},
+			wantActions: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			azure := mocks.NewFakeAzureServer()
+			defer azure.Close()
+			slackServer := mocks.NewFakeSlackServer()
+			defer slackServer.Close()
+			slackServer.UserGroups = []slack.UserGroup{{ID: "S1", Handle: "hr", Users: []string{"U1"}}}
+			handler := newTestSlackHandler(t, azure, slackServer)
+			handler.uiPathJobService = services.NewUIPathJobService(&mocks.FakeUIPathJobRepository{Jobs: jobs}, nil, nil, nil)
+			err := handler.threadService.CreateThread(&models.Thread{ID: "thread_1", ChannelId: "C1", SlackUserId: "U1", SlackThreadTs: "1700000000.000100"})
+			assert.NoError(t, err)
+
+			err = handler.HandleEventMessage(slackevents.EventsAPIEvent{
+				Type:       slackevents.CallbackEvent,
+				InnerEvent: slackevents.EventsAPIInnerEvent{Data: &slackevents.AppHomeOpenedEvent{User: tt.userID, Tab: "home"}},
+			})
+
+			assert.NoError(t, err)
+			published := slackServer.Calls("views.publish")
+			assert.Len(t, published, 1)
+			assert.Equal(t, tt.userID, published[0].UserID)
+			var home struct {
+				Blocks []struct {
+					Text struct {
+						Text string `json:"text"`
+					} `json:"text"`
+				} `json:"blocks"`
+			}
+			assert.NoError(t, json.Unmarshal(published[0].View, &home))
+			texts := []string{}
+			for _, block := range home.Blocks {
+				texts = append(texts, block.Text.Text)
+			}
+			view := strings.Join(texts, "\n")
+			for _, text := range tt.wantText {
+				assert.Contains(t, view, text)
+			}
+			for _, text := range tt.wantMissing {
+				assert.NotContains(t, view, text)
+			}
+			assert.Equal(t, tt.wantActions, strings.Count(string(published[0].View), `"action_id":"open_form"`))
+		})
+	}
+}
+
+func TestHandleAppHomeOpenedEventIgnoresOtherTabs(t *testing.T) {
+	azure := mocks.NewFakeAzureServer()
+	defer azure.Close()
+	slackServer := mocks.NewFakeSlackServer()
+	defer slackServer.Close()
+	handler := newTestSlackHandler(t, azure, slackServer)
+
+	err := handler.HandleEventMessage(slackevents.EventsAPIEvent{
+		Type:       slackevents.CallbackEvent,
+		InnerEvent: slackevents.EventsAPIInnerEvent{Data: &slackevents.AppHomeOpenedEvent{User: "U1", Tab: "messages"}},
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, slackServer.Calls("views.publish"))
+
+	err = handler.HandleJobStateChange(&models.UIPathJob{JobID: 1})
+	assert.NoError(t, err)
+	assert.Empty(t, slackServer.Calls("views.publish"))
+}
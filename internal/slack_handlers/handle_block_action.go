@@ -1,24 +1,31 @@
 package slack_handlers
 
 import (
+	"context"
+
 	"github.com/slack-go/slack"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/i18n"
 )
 
 func (s *SlackHandler) HandleBlockAction(payload slack.InteractionCallback) (string, error) {
 	for _, action := range payload.ActionCallback.BlockActions {
 		switch action.ActionID {
-		case "submit_candidate_file":
-			return "", s.handleCandidateSheetSubmission(payload)
-		case "submit_welcome_new_employee":
-			return "", s.handleGreetingNewEmployeeSubmission(payload)
-		case "submit_create_buddy":
-			return "", s.handleCreateBuddyFormFileEvent(payload)
-		case "submit_create_leave_request":
-			return "", s.handleCreateLeaveRequestSubmission(payload)
-		case "submit_integrate_training":
-			return "", s.handleCreateIntegrateTrainingSubmission(payload)
+		case "open_form":
+			return "", s.handleOpenForm(payload, action.Value)
+		case "confirm_column_mapping":
+			return "", s.handleConfirmColumnMapping(payload, action.Value)
+		case "cancel_column_mapping":
+			return "", s.handleCancelColumnMapping(payload, action.Value)
+		case "continue_thread":
+			return "", s.handleContinueThread(payload, action.Value)
 			// ... handle other action IDs as needed ...
 		}
 	}
 	return "", nil
 }
+
+// payloadLocale is the language to answer an interaction in, the one of the
+// conversation it happens in.
+func (s *SlackHandler) payloadLocale(payload slack.InteractionCallback) i18n.Locale {
+	return s.localeService.Locale(context.Background(), payload.Channel.ID, payload.Container.ThreadTs, payload.User.ID, "")
+}
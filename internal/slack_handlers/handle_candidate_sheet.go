@@ -5,48 +5,70 @@ import (
 	"fmt"
 
 	"github.com/slack-go/slack"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/forms"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/i18n"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/services"
 )
 
-func (s *SlackHandler) handleCandidateSheetEvent(channelID string) error {
-	return s.slackService.SendCandidateFileForm(context.Background(), channelID)
+var candidateFileForm = &forms.Form{
+	ID:     dto.FormCandidateFile,
+	Title:  "form.candidate_file.title",
+	Prompt: "form.candidate_file.prompt",
+	Fields: []forms.Field{
+		{ID: "candidate_file", Type: forms.FieldSheetURL, Invalid: "validation.invalid_candidate_file"},
+		{ID: "skill_file", Type: forms.FieldSheetURL, Optional: true, Hint: "form.skill_file.optional_hint", Invalid: "validation.invalid_skill_file"},
+	},
 }
 
-func (s *SlackHandler) handleCandidateSheetSubmission(payload slack.InteractionCallback) error {
-	// Extract the submitted link
-	submittedLink := payload.BlockActionState.Values["candidate_file"]["candidate_file_input"].Value
+type candidateFileValues struct {
+	CandidateFile string `form:"candidate_file"`
+	SkillFile     string `form:"skill_file"`
+}
 
-	// Process the submitted link (e.g., validate, store, etc.)
-	// For now, we'll just send a confirmation message
-	responseMsg := fmt.Sprintf("Received candidate sheet link: %s", submittedLink)
+func (s *SlackHandler) handleCandidateSheetEvent(channelID string, threadTs string, locale i18n.Locale, args dto.OnboardEmployeeArguments) error {
+	return s.sendFormLauncher(channelID, threadTs, locale, dto.FormCandidateFile, candidateFileValues{
+		CandidateFile: args.CandidateSheetURL,
+		SkillFile:     args.SkillSheetURL,
+	})
+}
 
-	responseBlocks := []slack.Block{
-		slack.NewSectionBlock(
-			slack.NewTextBlockObject("mrkdwn", responseMsg, false, false),
-			nil,
-			nil,
-		),
-	}
+func (s *SlackHandler) handleCandidateSheetSubmission(submission formSubmission, values candidateFileValues) (map[string]string, func() error) {
+	submittedLink := values.CandidateFile
+	skillLink := values.SkillFile
+	return nil, func() error {
+		ctx := context.Background()
+		locale := submission.locale
+		responseMsg := i18n.T(locale, "column_mapping.received", submittedLink)
+		responseBlocks := []slack.Block{
+			slack.NewSectionBlock(
+				slack.NewTextBlockObject("mrkdwn", responseMsg, false, false),
+				nil,
+				nil,
+			),
+		}
+		_, _, err := s.slackClient.PostMessage(submission.channelID, slack.MsgOptionBlocks(responseBlocks...), services.ThreadOption(submission.threadTs))
+		if err != nil {
+			return fmt.Errorf("failed to send confirmation message: %w", err)
+		}
 
-	_, _, err := s.slackClient.PostMessage(payload.Channel.ID, slack.MsgOptionBlocks(responseBlocks...))
-	if err != nil {
-		return fmt.Errorf("failed to send confirmation message: %w", err)
-	}
-	newEmployeeSkillFile, err := s.ggSheetService.HandleFileCandidateOffer(submittedLink)
-	if err != nil {
-		return fmt.Errorf("failed to handle file candidate offer: %w", err)
-	}
-	responseMsg2 := fmt.Sprintf("File skill: %s", newEmployeeSkillFile.SpreadsheetUrl)
-
-	responseBlocks2 := []slack.Block{
-		slack.NewSectionBlock(
-			slack.NewTextBlockObject("mrkdwn", responseMsg2, false, false),
-			nil,
-			nil,
-		),
-	}
-	_, _, err = s.slackClient.PostMessage(payload.Channel.ID, slack.MsgOptionBlocks(responseBlocks2...))
-	if err != nil {
-		return fmt.Errorf("failed to send confirmation message: %w", err)
+		proposal, cached, err := s.columnMappingService.ProposeColumnMapping(ctx, dto.ColumnMappingRequest{
+			SourceSheetURL: submittedLink,
+			TargetSheetURL: skillLink,
+			SlackUserID:    submission.userID,
+			ChannelID:      submission.channelID,
+			SlackThreadTs:  submission.threadTs,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to map candidate sheet columns: %w", err)
+		}
+		if !cached {
+			return s.slackService.SendColumnMappingConfirmation(ctx, submission.channelID, submission.threadTs, locale, proposal)
+		}
+		err = s.slackService.SendMessage(ctx, &submission.channelID, submission.threadTs, i18n.T(locale, "column_mapping.reused"))
+		if err != nil {
+			return err
+		}
+		return s.applyColumnMapping(ctx, submission.channelID, submission.threadTs, submission.userID, locale, proposal.ID)
 	}
-	return nil
 }
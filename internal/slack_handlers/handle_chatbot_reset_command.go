@@ -0,0 +1,53 @@
+package slack_handlers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/slack-go/slack"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/i18n"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/models"
+	"gorm.io/gorm"
+)
+
+// handleChatbotResetCommand answers /chatbot-reset by rolling the open
+// conversation of the user in the channel over to a fresh context. The
+// summary takes longer than Slack waits for the command answer, the outcome
+// is sent to the response URL of the command.
+func (s *SlackHandler) handleChatbotResetCommand(call commandCall) (interface{}, error) {
+	command, locale := call.command, call.locale
+	thread, err := s.threadService.GetLatestOpenThreadByChannelAndUserID(command.ChannelID, command.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ephemeralMessage(i18n.T(locale, "command.reset.no_thread")), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		text := i18n.T(locale, "command.reset.done")
+		err := s.aiChatbotService.RolloverThread(context.Background(), thread, models.ThreadRolloverReasonManual)
+		if err != nil {
+			s.logger.Error().Err(err).Str("thread_id", thread.ID).Msg("Cannot reset conversation")
+			text = i18n.T(locale, "command.reset.failed")
+		}
+		err = slack.PostWebhook(command.ResponseURL, &slack.WebhookMessage{
+			ResponseType: slack.ResponseTypeEphemeral,
+			Text:         text,
+		})
+		if err != nil {
+			s.logger.Error().Err(err).Str("thread_id", thread.ID).Msg("Cannot send reset outcome")
+		}
+	}()
+	return ephemeralMessage(i18n.T(locale, "command.reset.summarizing")), nil
+}
+
+func ephemeralMessage(text string) *slack.Msg {
+	return &slack.Msg{ResponseType: slack.ResponseTypeEphemeral, Text: text}
+}
+
+// commandLocale is the language to answer a slash command in, the command
+// text is too short to tell it most of the time.
+func (s *SlackHandler) commandLocale(command slack.SlashCommand) i18n.Locale {
+	return s.localeService.Locale(context.Background(), command.ChannelID, "", command.UserID, command.Text)
+}
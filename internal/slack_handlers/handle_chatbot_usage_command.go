@@ -0,0 +1,59 @@
+package slack_handlers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/i18n"
+)
+
+const (
+	chatbotUsageDays    = 7
+	maxChatbotUsageDays = 90
+)
+
+// handleChatbotUsageCommand answers /chatbot-usage with the assistant spend of
+// the calling user per day and channel over the last days, a week unless the
+// number of days is given.
+func (s *SlackHandler) handleChatbotUsageCommand(call commandCall) (interface{}, error) {
+	days := chatbotUsageDays
+	if call.args[0] != "" {
+		days, _ = strconv.Atoi(call.args[0])
+	}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	summary, err := s.usageService.ListUsageSummary(dto.UsageSummaryQuery{
+		From:        today.AddDate(0, 0, -(days - 1)),
+		To:          today,
+		SlackUserID: call.command.UserID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &slack.Msg{
+		ResponseType: slack.ResponseTypeEphemeral,
+		Text:         formatUsageSummary(summary, days, s.usageService.DailyTokenQuota(), call.locale),
+	}, nil
+}
+
+func formatUsageSummary(summary []dto.UsageSummaryResponse, days int, dailyTokenQuota int64, locale i18n.Locale) string {
+	if len(summary) == 0 {
+		return i18n.T(locale, "command.usage.none", days)
+	}
+	lines := []string{i18n.T(locale, "command.usage.title", days)}
+	var totalTokens int64
+	var totalCost float64
+	for _, row := range summary {
+		lines = append(lines, i18n.T(locale, "command.usage.row", row.Day.Format("2006-01-02"), row.ChannelID, row.TotalTokens, row.Cost))
+		totalTokens += row.TotalTokens
+		totalCost += row.Cost
+	}
+	lines = append(lines, i18n.T(locale, "command.usage.total", totalTokens, totalCost))
+	if dailyTokenQuota > 0 {
+		lines = append(lines, i18n.T(locale, "command.usage.quota", dailyTokenQuota))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,52 @@
+package slack_handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/slack-go/slack"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/i18n"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/services"
+)
+
+func (s *SlackHandler) handleConfirmColumnMapping(payload slack.InteractionCallback, proposalID string) error {
+	return s.applyColumnMapping(context.Background(), payload.Channel.ID, payload.Container.ThreadTs, payload.User.ID, s.payloadLocale(payload), proposalID)
+}
+
+func (s *SlackHandler) handleCancelColumnMapping(payload slack.InteractionCallback, proposalID string) error {
+	locale := s.payloadLocale(payload)
+	err := s.columnMappingService.CancelColumnMapping(proposalID, payload.User.ID)
+	if err != nil {
+		return s.replyColumnMappingError(payload.Channel.ID, payload.Container.ThreadTs, payload.User.ID, locale, err)
+	}
+	return s.slackService.SendMessage(context.Background(), &payload.Channel.ID, payload.Container.ThreadTs, i18n.T(locale, "column_mapping.cancelled"))
+}
+
+// applyColumnMapping fills the skill sheet with the confirmed mapping and
+// posts its link in the thread, userID is the user confirming it.
+func (s *SlackHandler) applyColumnMapping(ctx context.Context, channelID string, threadTs string, userID string, locale i18n.Locale, proposalID string) error {
+	skillFile, err := s.columnMappingService.ConfirmColumnMapping(ctx, proposalID, userID)
+	if err != nil {
+		return s.replyColumnMappingError(channelID, threadTs, userID, locale, err)
+	}
+	return s.slackService.SendMessage(ctx, &channelID, threadTs, i18n.T(locale, "column_mapping.applied", skillFile.SpreadsheetUrl))
+}
+
+// replyColumnMappingError tells the user why the button did nothing when it
+// is their mistake, other errors are returned for the logs.
+func (s *SlackHandler) replyColumnMappingError(channelID string, threadTs string, userID string, locale i18n.Locale, err error) error {
+	var message string
+	switch {
+	case errors.Is(err, services.ErrColumnMappingNotOwner):
+		message = i18n.T(locale, "column_mapping.not_owner")
+	case errors.Is(err, services.ErrColumnMappingAnswered):
+		message = i18n.T(locale, "column_mapping.answered")
+	case errors.Is(err, services.ErrColumnMappingHeadersChanged):
+		message = i18n.T(locale, "column_mapping.headers_changed")
+	default:
+		return fmt.Errorf("failed to answer column mapping: %w", err)
+	}
+	_, err = s.slackClient.PostEphemeral(channelID, userID, slack.MsgOptionText(message, false), services.ThreadOption(threadTs))
+	return err
+}
@@ -0,0 +1,29 @@
+package slack_handlers
+
+import (
+	"context"
+
+	"github.com/slack-go/slack"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/i18n"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/services"
+)
+
+func (s *SlackHandler) handleContinueThread(payload slack.InteractionCallback, threadID string) error {
+	thread, err := s.threadService.GetThreadByID(threadID)
+	if err != nil {
+		return err
+	}
+	locale := s.payloadLocale(payload)
+	if thread.SlackUserId != payload.User.ID {
+		_, err = s.slackClient.PostEphemeral(payload.Channel.ID, payload.User.ID, slack.MsgOptionText(i18n.T(locale, "thread.not_owner"), false), services.ThreadOption(payload.Container.ThreadTs))
+		return err
+	}
+	extended, err := s.threadService.ExtendThreadDeadline(threadID)
+	if err != nil {
+		return err
+	}
+	if !extended {
+		return s.slackService.SendMessage(context.Background(), &payload.Channel.ID, payload.Container.ThreadTs, i18n.T(locale, "thread.already_closed"))
+	}
+	return s.slackService.SendMessage(context.Background(), &payload.Channel.ID, payload.Container.ThreadTs, i18n.T(locale, "thread.continued"))
+}
@@ -1,31 +1,38 @@
 package slack_handlers
 
 import (
-	"context"
-
-	"github.com/slack-go/slack"
 	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
-	"github.com/sotatek-dev/hyper-automation-chatbot/pkg/util"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/forms"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/i18n"
 )
 
-func (s *SlackHandler) handleCreateBuddyFormFileEvent(payload slack.InteractionCallback) error {
-	submittedTransformationInputFile := payload.BlockActionState.Values["transformation_input_file"]["transformation_input_file_input"].Value
-	submittedTransformationOutputFile := payload.BlockActionState.Values["transformation_output_file"]["transformation_output_file_input"].Value
-	if !util.IsValidGoogleSheetLink(submittedTransformationInputFile) {
-		err := s.slackService.SendMessage(context.Background(), &payload.Channel.ID, "Invalid transformation input file link")
-		return err
-	}
-	if !util.IsValidGoogleSheetLink(submittedTransformationOutputFile) {
-		err := s.slackService.SendMessage(context.Background(), &payload.Channel.ID, "Invalid transformation output file link")
-		return err
+var createBuddyForm = &forms.Form{
+	ID:     dto.FormCreateBuddy,
+	Title:  "form.create_buddy.title",
+	Prompt: "form.transformation.prompt",
+	Fields: []forms.Field{
+		{ID: "transformation_input_file", Type: forms.FieldSheetURL, Invalid: "validation.invalid_transformation_input_file"},
+		{ID: "transformation_output_file", Type: forms.FieldSheetURL, Invalid: "validation.invalid_transformation_output_file"},
+	},
+}
+
+type createBuddyValues struct {
+	InputSheet  string `form:"transformation_input_file"`
+	OutputSheet string `form:"transformation_output_file"`
+}
+
+func (s *SlackHandler) handleCreateBuddyFormFileEvent(submission formSubmission, values createBuddyValues) (map[string]string, func() error) {
+	return nil, func() error {
+		return s.uiPathJobService.CreateFillBuddyJob(dto.UIPathFillBuddyInput{
+			InputSheet:  values.InputSheet,
+			OutputSheet: values.OutputSheet,
+		}, submission.userID, submission.channelID, submission.threadTs, submission.locale)
 	}
-	err := s.uiPathJobService.CreateFillBuddyJob(dto.UIPathFillBuddyInput{
-		InputSheet:  submittedTransformationInputFile,
-		OutputSheet: submittedTransformationOutputFile,
-	}, payload.Channel.ID)
-	return err
 }
 
-func (s *SlackHandler) handleCreateBuddyFormEvent(channelID string) error {
-	return s.slackService.SendCreateBuddyForm(context.Background(), channelID)
+func (s *SlackHandler) handleCreateBuddyFormEvent(channelID string, threadTs string, locale i18n.Locale, args dto.CreateBuddyFormArguments) error {
+	return s.sendFormLauncher(channelID, threadTs, locale, dto.FormCreateBuddy, createBuddyValues{
+		InputSheet:  args.InputSheetURL,
+		OutputSheet: args.OutputSheetURL,
+	})
 }
@@ -1,31 +1,38 @@
 package slack_handlers
 
 import (
-	"context"
-
-	"github.com/slack-go/slack"
 	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
-	"github.com/sotatek-dev/hyper-automation-chatbot/pkg/util"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/forms"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/i18n"
 )
 
-func (s *SlackHandler) handleCreateIntegrateTrainingSubmission(payload slack.InteractionCallback) error {
-	submittedSheetURL := payload.BlockActionState.Values["sheet_url"]["sheet_url_input"].Value
-	submittedSheetName := payload.BlockActionState.Values["sheet_name"]["sheet_name_input"].Value
-	if !util.IsValidGoogleSheetLink(submittedSheetURL) {
-		err := s.slackService.SendMessage(context.Background(), &payload.Channel.ID, "Invalid skill file link")
-		return err
-	}
-	if submittedSheetName == "" {
-		err := s.slackService.SendMessage(context.Background(), &payload.Channel.ID, "Sheet name is required")
-		return err
+var integrateTrainingForm = &forms.Form{
+	ID:     dto.FormIntegrateTraining,
+	Title:  "form.integrate_training.title",
+	Prompt: "form.sheet.prompt",
+	Fields: []forms.Field{
+		{ID: "sheet_url", Type: forms.FieldSheetURL, Invalid: "validation.invalid_sheet_url"},
+		{ID: "sheet_name", Type: forms.FieldText, MaxLength: 254},
+	},
+}
+
+type integrateTrainingValues struct {
+	SheetURL  string `form:"sheet_url"`
+	SheetName string `form:"sheet_name"`
+}
+
+func (s *SlackHandler) handleCreateIntegrateTrainingSubmission(submission formSubmission, values integrateTrainingValues) (map[string]string, func() error) {
+	return nil, func() error {
+		return s.uiPathJobService.CreateIntegrateTrainingJob(dto.UIPathCreateIntegrateTrainingInput{
+			SheetURL:  values.SheetURL,
+			SheetName: values.SheetName,
+		}, submission.userID, submission.channelID, submission.threadTs, submission.locale)
 	}
-	err := s.uiPathJobService.CreateIntegrateTrainingJob(dto.UIPathCreateIntegrateTrainingInput{
-		SheetURL:  submittedSheetURL,
-		SheetName: submittedSheetName,
-	}, payload.Channel.ID)
-	return err
 }
 
-func (s *SlackHandler) handleIntegrateTrainingEvent(channelID string) error {
-	return s.slackService.SendIntegrateTrainingForm(context.Background(), channelID)
+func (s *SlackHandler) handleIntegrateTrainingEvent(channelID string, threadTs string, locale i18n.Locale, args dto.TrainingRequestArguments) error {
+	return s.sendFormLauncher(channelID, threadTs, locale, dto.FormIntegrateTraining, integrateTrainingValues{
+		SheetURL:  args.SheetURL,
+		SheetName: args.SheetName,
+	})
 }
@@ -5,8 +5,9 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/slack-go/slack"
 	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/forms"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/i18n"
 )
 
 const (
@@ -15,60 +16,86 @@ const (
 	WorkingTime9001830 = "9:00-18:30"
 )
 
-func (s *SlackHandler) handleCreateLeaveRequestSubmission(payload slack.InteractionCallback) error {
-	startDate := payload.BlockActionState.Values["date_pickers"]["request_date_from_input"].SelectedDate
-	endDate := payload.BlockActionState.Values["date_pickers"]["request_date_to_input"].SelectedDate
-	hourFrom := payload.BlockActionState.Values["time_pickers"]["hour_from_input"].SelectedTime
-	hourTo := payload.BlockActionState.Values["time_pickers"]["hour_to_input"].SelectedTime
-	description := payload.BlockActionState.Values["description"]["description_input"].Value
-	workingTime := payload.BlockActionState.Values["leave_type"]["working_time_input"].SelectedOption.Value
-	leaveType := payload.BlockActionState.Values["leave_type"]["leave_type_input"].SelectedOption.Value
-	userInfo, err := s.slackClient.GetUserInfo(payload.User.ID)
-	if err != nil {
-		return s.slackService.SendMessage(context.Background(), &payload.Channel.ID, "Failed to get user information")
-	}
-	workerEmail := userInfo.Profile.Email
+var createLeaveRequestForm = &forms.Form{
+	ID:     dto.FormCreateLeaveRequest,
+	Title:  "form.leave_request.title",
+	Prompt: "form.leave_request.prompt",
+	Fields: []forms.Field{
+		{ID: "leave_type", Type: forms.FieldSelect, Options: leaveTypeOptions, Invalid: "validation.invalid_leave_type"},
+		{ID: "working_time", Type: forms.FieldSelect, Options: workingTimeOptions, Invalid: "validation.invalid_working_time"},
+		{ID: "request_date_from", Type: forms.FieldDate, Invalid: "validation.invalid_start_date"},
+		{ID: "request_date_to", Type: forms.FieldDate, Invalid: "validation.invalid_end_date"},
+		{ID: "hour_from", Type: forms.FieldTime, Validate: isWorkingHour, Invalid: "validation.invalid_hour"},
+		{ID: "hour_to", Type: forms.FieldTime, Validate: isWorkingHour, Invalid: "validation.invalid_hour"},
+		{ID: "description", Type: forms.FieldText, Multiline: true, MaxLength: 254},
+	},
+}
 
-	if startDate == "" || endDate == "" || hourFrom == "" || hourTo == "" || description == "" || workingTime == "" || leaveType == "" || workerEmail == "" {
-		return s.slackService.SendMessage(context.Background(), &payload.Channel.ID, "All fields are required")
-	}
+type leaveRequestValues struct {
+	LeaveType   int       `form:"leave_type"`
+	WorkingTime int       `form:"working_time"`
+	DateFrom    time.Time `form:"request_date_from"`
+	DateTo      time.Time `form:"request_date_to"`
+	HourFrom    string    `form:"hour_from"`
+	HourTo      string    `form:"hour_to"`
+	Description string    `form:"description"`
+}
 
-	// Parse the dates
-	start, err := time.Parse("2006-01-02", startDate)
-	if err != nil {
-		return s.slackService.SendMessage(context.Background(), &payload.Channel.ID, "Invalid start date format")
+func leaveTypeOptions(locale i18n.Locale) []forms.Option {
+	options := make([]forms.Option, 0, len(dto.AppMappingCodeLeave))
+	for _, leave := range dto.AppMappingCodeLeave {
+		options = append(options, forms.Option{Label: leave.Name, Value: strconv.Itoa(leave.Code)})
 	}
+	return options
+}
 
-	end, err := time.Parse("2006-01-02", endDate)
-	if err != nil {
-		return s.slackService.SendMessage(context.Background(), &payload.Channel.ID, "Invalid end date format")
+func workingTimeOptions(locale i18n.Locale) []forms.Option {
+	options := make([]forms.Option, 0, len(dto.AppMappingCodeWorkingTime))
+	for _, workingTime := range dto.AppMappingCodeWorkingTime {
+		options = append(options, forms.Option{Label: workingTime.Name, Value: strconv.Itoa(workingTime.Code)})
 	}
+	return options
+}
+
+func isWorkingHour(hour string) bool {
+	return getHourFromCode(hour) != 0
+}
 
-	hourFromCode := getHourFromCode(hourFrom)
-	hourToCode := getHourFromCode(hourTo)
-	calendarId, err := strconv.Atoi(workingTime)
-	if err != nil {
-		return s.slackService.SendMessage(context.Background(), &payload.Channel.ID, "Invalid working time")
+func (s *SlackHandler) handleCreateLeaveRequestSubmission(submission formSubmission, values leaveRequestValues) (map[string]string, func() error) {
+	locale := submission.locale
+	if values.DateTo.Before(values.DateFrom) {
+		return map[string]string{"request_date_to": i18n.T(locale, "validation.end_before_start")}, nil
 	}
-	holidayStatusId, err := strconv.Atoi(leaveType)
-	if err != nil {
-		return s.slackService.SendMessage(context.Background(), &payload.Channel.ID, "Invalid leave type")
+
+	return nil, func() error {
+		userInfo, err := s.slackClient.GetUserInfo(submission.userID)
+		if err != nil || userInfo.Profile.Email == "" {
+			return s.slackService.SendMessage(context.Background(), &submission.channelID, submission.threadTs, i18n.T(locale, "error.user_info"))
+		}
+		return s.uiPathJobService.CreateLeaveRequestJob(dto.UIPathCreateLeaveRequestInput{
+			RequestDateFrom: values.DateFrom.Format("02/01/2006"),
+			RequestDateTo:   values.DateTo.Format("02/01/2006"),
+			HourFrom:        getHourFromCode(values.HourFrom),
+			HourTo:          getHourFromCode(values.HourTo),
+			Description:     values.Description,
+			CalendarId:      values.WorkingTime,
+			WorkEmail:       userInfo.Profile.Email,
+			HolidayStatusId: values.LeaveType,
+		}, submission.userID, submission.channelID, submission.threadTs, locale)
 	}
-	err = s.uiPathJobService.CreateLeaveRequestJob(dto.UIPathCreateLeaveRequestInput{
-		RequestDateFrom: start.Format("02/01/2006"),
-		RequestDateTo:   end.Format("02/01/2006"),
-		HourFrom:        hourFromCode,
-		HourTo:          hourToCode,
-		Description:     description,
-		CalendarId:      calendarId,
-		WorkEmail:       workerEmail,
-		HolidayStatusId: holidayStatusId,
-	}, payload.Channel.ID)
-	return err
 }
 
-func (s *SlackHandler) handleLeaveRequestEvent(channelID string) error {
-	return s.slackService.SendCreateLeaveRequestForm(context.Background(), channelID)
+func (s *SlackHandler) handleLeaveRequestEvent(channelID string, threadTs string, locale i18n.Locale, args dto.TakeLeaveArguments) error {
+	// Dates the assistant got wrong are left for the user to pick.
+	dateFrom, _ := time.Parse("2006-01-02", args.RequestDateFrom)
+	dateTo, _ := time.Parse("2006-01-02", args.RequestDateTo)
+	return s.sendFormLauncher(channelID, threadTs, locale, dto.FormCreateLeaveRequest, leaveRequestValues{
+		DateFrom:    dateFrom,
+		DateTo:      dateTo,
+		HourFrom:    args.HourFrom,
+		HourTo:      args.HourTo,
+		Description: args.Description,
+	})
 }
 
 func getHourFromCode(hourFrom string) int {
@@ -9,6 +9,8 @@ import (
 
 	"github.com/slack-go/slack"
 	"github.com/slack-go/slack/slackevents"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/i18n"
 )
 
 // HandleEventMessage will take an event and handle it properly based on the type of event
@@ -25,6 +27,10 @@ func (s *SlackHandler) HandleEventMessage(event slackevents.EventsAPIEvent) erro
 			return s.handleAppMentionEvent(ev)
 		case *slackevents.MessageEvent:
 			return s.handleMessageEvent(ev)
+		case *slackevents.ReactionAddedEvent:
+			return s.handleReactionAddedEvent(ev)
+		case *slackevents.AppHomeOpenedEvent:
+			return s.handleAppHomeOpenedEvent(ev)
 		}
 	default:
 		return errors.New("unsupported event type")
@@ -49,15 +55,16 @@ func (s *SlackHandler) handleAppMentionEvent(event *slackevents.AppMentionEvent)
 			Value: user.Name,
 		},
 	}
-	if strings.Contains(text, "hello") {
+	locale := s.localeService.Locale(context.Background(), event.Channel, event.ThreadTimeStamp, event.User, event.Text)
+	if strings.Contains(text, "hello") || strings.Contains(text, "xin chào") {
 		// Greet the user
-		attachment.Text = fmt.Sprintf("Hello %s", user.Name)
-		attachment.Pretext = "Greetings"
+		attachment.Text = i18n.T(locale, "chatbot.greeting", user.Name)
+		attachment.Pretext = i18n.T(locale, "chatbot.greeting_title")
 		attachment.Color = "#4af030"
 	} else {
 		// Send a message to the user
-		attachment.Text = fmt.Sprintf("How can I help you %s?", user.Name)
-		attachment.Pretext = "How can I be of service"
+		attachment.Text = i18n.T(locale, "chatbot.offer_help", user.Name)
+		attachment.Pretext = i18n.T(locale, "chatbot.offer_help_title")
 		attachment.Color = "#3d3d3d"
 	}
 	_, _, err = s.slackClient.PostMessage(event.Channel, slack.MsgOptionAttachments(attachment))
@@ -75,23 +82,36 @@ func (s *SlackHandler) handleMessageEvent(event *slackevents.MessageEvent) error
 	if event.BotID != "" || event.SubType == "bot_message" {
 		return nil
 	}
-	_, action, err := s.aiChatbotService.AddAndRunMessage(context.Background(), &event.Channel, event.Text, event.User)
+	files := make([]dto.MessageFile, 0, len(event.Files))
+	for _, file := range event.Files {
+		files = append(files, dto.MessageFile{
+			Name:        file.Name,
+			MimeType:    file.Mimetype,
+			DownloadURL: file.URLPrivateDownload,
+		})
+	}
+	// Top level messages start a new Slack thread, the bot answers in it.
+	threadTs := event.ThreadTimeStamp
+	if threadTs == "" {
+		threadTs = event.TimeStamp
+	}
+	locale := s.localeService.Locale(context.Background(), event.Channel, threadTs, event.User, event.Text)
+	_, toolCalls, err := s.aiChatbotService.AddAndRunMessage(context.Background(), dto.AddMessageRequest{
+		ChannelID:     &event.Channel,
+		Message:       event.Text,
+		UserID:        event.User,
+		SlackTs:       event.TimeStamp,
+		SlackThreadTs: threadTs,
+		Files:         files,
+		Locale:        locale,
+	})
 	if err != nil {
 		return err
 	}
-	switch action {
-	case "onboard_nhan_vien":
-		s.handleCandidateSheetEvent(event.Channel)
-	case "welcome_new_employee":
-		s.handleGreetingNewEmployeeEvent(
-			event.Channel,
-		)
-	case "create_buddy_form_file":
-		s.handleCreateBuddyFormEvent(event.Channel)
-	case "take_leave":
-		s.handleLeaveRequestEvent(event.Channel)
-	case "training_request":
-		s.handleIntegrateTrainingEvent(event.Channel)
+	for _, toolCall := range toolCalls {
+		if err := s.dispatchToolCall(event.Channel, threadTs, locale, toolCall); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -0,0 +1,202 @@
+package slack_handlers
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/config"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/mocks"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/services"
+	"github.com/stretchr/testify/assert"
+)
+
+var testAssistantsConfig = config.AssistantsConfig{
+	Default: config.AssistantIntentDetection,
+	Assistants: []config.AssistantDefinition{
+		{Name: config.AssistantIntentDetection, AssistantID: "asst_intent", Tools: []string{"*"}},
+		{Name: config.AssistantHeaderMapping, AssistantID: "asst_header"},
+	},
+}
+
+// newTestSlackHandler wires a SlackHandler to the fake Azure and Slack
+// servers and in-memory repositories.
+func newTestSlackHandler(t *testing.T, azure *mocks.FakeAzureServer, slackServer *mocks.FakeSlackServer) *SlackHandler {
+	router, err := services.NewAssistantRouter(testAssistantsConfig)
+	assert.NoError(t, err)
+	azureOpenAIConfig := config.AzureOpenAIConfig{Endpoint: azure.URL, Key: "key", ApiVersion: "2024-02-15-preview"}
+	slackClient := slack.New("xoxb-test", slack.OptionAPIURL(slackServer.APIURL()))
+	slackService := services.NewSlackService(&config.SlackConfig{}, slackClient)
+	threadService := services.NewThreadService(mocks.NewFakeThreadRepository(), config.ThreadConfig{IdleTimeout: time.Hour})
+	usageService := services.NewUsageService(&mocks.FakeRunUsageRepository{}, config.UsageConfig{})
+	aiChatbotService := services.NewAIChatbotService(
+		services.NewAzureAssistantsBackend(azure.Client(), azureOpenAIConfig),
+		azureOpenAIConfig,
+		slackService,
+		threadService,
+		services.NewMessageService(&mocks.FakeMessageRepository{}),
+		usageService,
+		router,
+		nil,
+		nil,
+		services.NewAnswerCacheService(mocks.NewFakeCachedAnswerRepository(), config.AnswerCacheConfig{TTL: time.Hour}),
+	)
+	aiChatbotService.SetPollInterval(time.Millisecond)
+	logger := zerolog.Nop()
+	return NewSlackHandler(slackClient, slackService, aiChatbotService, nil, nil, threadService, usageService, nil, services.NewLocaleService(slackClient, threadService), &logger)
+}
+
+func TestHandleMessageEvent(t *testing.T) {
+	createBuddyForm := dto.AzureAIToolCall{ID: "call_1", Type: "function"}
+	createBuddyForm.Function.Name = dto.ToolCreateBuddyFormFile
+	createBuddyForm.Function.Arguments = `{"input_sheet_url":"https://docs.google.com/spreadsheets/d/input"}`
+	unknownTool := dto.AzureAIToolCall{ID: "call_2", Type: "function"}
+	unknownTool.Function.Name = "book_meeting_room"
+
+	tests := []struct {
+		name         string
+		event        slackevents.MessageEvent
+		runs         []mocks.FakeLLMRun
+		wantErr      string
+		wantRuns     int
+		wantThreadTs string
+		wantPosts    int
+		wantBlocks   bool
+		wantButton   string
+	}{
+		{
+			name:  "bot messages are ignored",
+			event: slackevents.MessageEvent{Channel: "C1", User: "U1", Text: "Hi", TimeStamp: "1700000000.000100", BotID: "B1"},
+		},
+		{
+			name:         "top level message is answered in a new slack thread",
+			event:        slackevents.MessageEvent{Channel: "C1", User: "U1", Text: "Hi", TimeStamp: "1700000000.000100"},
+			runs:         []mocks.FakeLLMRun{{Replies: []string{"Hello!"}}},
+			wantRuns:     1,
+			wantThreadTs: "1700000000.000100",
+			wantPosts:    1,
+		},
+		{
+			name:         "reply is answered in its slack thread",
+			event:        slackevents.MessageEvent{Channel: "C1", User: "U1", Text: "Hi", TimeStamp: "1700000000.000200", ThreadTimeStamp: "1700000000.000100"},
+			runs:         []mocks.FakeLLMRun{{Replies: []string{"Hello!"}}},
+			wantRuns:     1,
+			wantThreadTs: "1700000000.000100",
+			wantPosts:    1,
+		},
+		{
+			name:  "tool call is dispatched to its handler",
+			event: slackevents.MessageEvent{Channel: "C1", User: "U1", Text: "Create the buddy form", TimeStamp: "1700000000.000100"},
+			runs: []mocks.FakeLLMRun{{
+				Statuses:  []string{services.RunStatusRequiresAction, services.RunStatusCompleted},
+				ToolCalls: []dto.AzureAIToolCall{createBuddyForm},
+			}},
+			wantRuns:     1,
+			wantThreadTs: "1700000000.000100",
+			wantPosts:    1,
+			wantBlocks:   true,
+			wantButton:   "Open form",
+		},
+		{
+			name:  "form is in the language of the message",
+			event: slackevents.MessageEvent{Channel: "C1", User: "U1", Text: "Tạo buddy form giúp mình", TimeStamp: "1700000000.000100"},
+			runs: []mocks.FakeLLMRun{{
+				Statuses:  []string{services.RunStatusRequiresAction, services.RunStatusCompleted},
+				ToolCalls: []dto.AzureAIToolCall{createBuddyForm},
+			}},
+			wantRuns:     1,
+			wantThreadTs: "1700000000.000100",
+			wantPosts:    1,
+			wantBlocks:   true,
+			wantButton:   "Mở biểu mẫu",
+		},
+		{
+			name:  "unsupported tool call",
+			event: slackevents.MessageEvent{Channel: "C1", User: "U1", Text: "Book a room", TimeStamp: "1700000000.000100"},
+			runs: []mocks.FakeLLMRun{{
+				Statuses:  []string{services.RunStatusRequiresAction, services.RunStatusCompleted},
+				ToolCalls: []dto.AzureAIToolCall{unknownTool},
+			}},
+			wantErr:  "unsupported tool call: book_meeting_room",
+			wantRuns: 1,
+		},
+		{
+			name:     "failed run",
+			event:    slackevents.MessageEvent{Channel: "C1", User: "U1", Text: "Hi", TimeStamp: "1700000000.000100"},
+			runs:     []mocks.FakeLLMRun{{Statuses: []string{services.RunStatusFailed}, LastError: &dto.AzureAIRunError{Message: "Rate limit exceeded."}}},
+			wantErr:  "Rate limit exceeded.",
+			wantRuns: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			azure := mocks.NewFakeAzureServer(tt.runs...)
+			defer azure.Close()
+			slackServer := mocks.NewFakeSlackServer()
+			defer slackServer.Close()
+			handler := newTestSlackHandler(t, azure, slackServer)
+
+			err := handler.handleMessageEvent(&tt.event)
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Len(t, azure.Backend.RunRequests, tt.wantRuns)
+			posts := slackServer.Calls("chat.postMessage")
+			assert.Len(t, posts, tt.wantPosts)
+			for _, post := range posts {
+				assert.Equal(t, "C1", post.Channel)
+				assert.Equal(t, tt.wantThreadTs, post.ThreadTs)
+				assert.Equal(t, tt.wantBlocks, json.Valid(post.Blocks))
+				if tt.wantButton != "" {
+					assert.Contains(t, string(post.Blocks), `"text":"`+tt.wantButton+`"`)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleReactionAddedEvent(t *testing.T) {
+	azure := mocks.NewFakeAzureServer(
+		mocks.FakeLLMRun{Replies: []string{"You have 12 leave days."}},
+		mocks.FakeLLMRun{Replies: []string{"You have 12 leave days left."}},
+	)
+	defer azure.Close()
+	slackServer := mocks.NewFakeSlackServer()
+	defer slackServer.Close()
+	handler := newTestSlackHandler(t, azure, slackServer)
+	ask := func(userID string, ts string) {
+		err := handler.handleMessageEvent(&slackevents.MessageEvent{Channel: "C1", User: userID, Text: "How many leave days do I have?", TimeStamp: ts})
+		assert.NoError(t, err)
+	}
+	react := func(reaction string, ts string) {
+		err := handler.HandleEventMessage(slackevents.EventsAPIEvent{
+			Type: slackevents.CallbackEvent,
+			InnerEvent: slackevents.EventsAPIInnerEvent{Data: &slackevents.ReactionAddedEvent{
+				Reaction: reaction,
+				Item:     slackevents.Item{Type: "message", Channel: "C1", Timestamp: ts},
+			}},
+		})
+		assert.NoError(t, err)
+	}
+
+	ask("U1", "1700000000.000100")
+	ask("U2", "1700000000.000200")
+	assert.Len(t, azure.Backend.RunRequests, 1, "the second question is answered from the cache")
+
+	// The replayed answer was posted as 1700000000.000002.
+	react("+1", "1700000000.000002")
+	ask("U3", "1700000000.000300")
+	assert.Len(t, azure.Backend.RunRequests, 1)
+
+	react("-1::skin-tone-2", "1700000000.000002")
+	ask("U4", "1700000000.000400")
+	assert.Len(t, azure.Backend.RunRequests, 2, "the answer given a thumbs down is not replayed")
+}
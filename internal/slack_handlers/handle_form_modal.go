@@ -0,0 +1,173 @@
+package slack_handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/slack-go/slack"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/forms"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/i18n"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/services"
+	"gorm.io/gorm"
+)
+
+// formSubmission is a form submitted from its modal, answered in the
+// conversation the form was opened from.
+type formSubmission struct {
+	userID    string
+	channelID string
+	threadTs  string
+	locale    i18n.Locale
+	values    map[string]map[string]slack.BlockAction
+}
+
+// formHandler validates a submitted form. It returns the errors to show under
+// the invalid inputs, by field ID, or the workflow to run.
+type formHandler func(submission formSubmission) (map[string]string, func() error, error)
+
+// registeredForm is a workflow form with the handler of its submissions.
+type registeredForm struct {
+	form   *forms.Form
+	handle formHandler
+}
+
+// bindForm validates the submissions of the form and binds them into a T
+// before calling the handler, which checks what the fields cannot alone. It
+// panics when T does not match the form.
+func bindForm[T any](form *forms.Form, handle func(submission formSubmission, values T) (map[string]string, func() error)) registeredForm {
+	var zero T
+	if _, err := form.Prefill(zero); err != nil {
+		panic(err)
+	}
+	return registeredForm{
+		form: form,
+		handle: func(submission formSubmission) (map[string]string, func() error, error) {
+			values, fieldErrors, err := forms.Bind[T](form, submission.locale, submission.values)
+			if err != nil || len(fieldErrors) > 0 {
+				return fieldErrors, nil, err
+			}
+			fieldErrors, run := handle(submission, values)
+			return fieldErrors, run, nil
+		},
+	}
+}
+
+func (s *SlackHandler) newForms() map[string]registeredForm {
+	registered := map[string]registeredForm{}
+	for _, form := range []registeredForm{
+		bindForm(candidateFileForm, s.handleCandidateSheetSubmission),
+		bindForm(welcomeNewEmployeeForm, s.handleGreetingNewEmployeeSubmission),
+		bindForm(createBuddyForm, s.handleCreateBuddyFormFileEvent),
+		bindForm(createLeaveRequestForm, s.handleCreateLeaveRequestSubmission),
+		bindForm(integrateTrainingForm, s.handleCreateIntegrateTrainingSubmission),
+	} {
+		registered[form.form.ID] = form
+	}
+	return registered
+}
+
+// sendFormLauncher posts the button opening the form in the conversation,
+// prefill is a value of the struct the form is bound into.
+func (s *SlackHandler) sendFormLauncher(channelID string, threadTs string, locale i18n.Locale, formID string, prefill interface{}) error {
+	registered, ok := s.forms[formID]
+	if !ok {
+		return fmt.Errorf("unknown form: %s", formID)
+	}
+	values, err := registered.form.Prefill(prefill)
+	if err != nil {
+		return err
+	}
+	return s.slackService.SendFormLauncher(context.Background(), channelID, threadTs, locale, registered.form, values)
+}
+
+// handleOpenForm opens the form of the launcher button in a modal. Only the
+// owner of the conversation the button was posted in can open it, if they are
+// allowed to fill the form. The forms opened from the Home tab are answered in
+// the app DM of the user.
+func (s *SlackHandler) handleOpenForm(payload slack.InteractionCallback, value string) error {
+	var launcher dto.FormLauncher
+	if err := json.Unmarshal([]byte(value), &launcher); err != nil {
+		return fmt.Errorf("invalid form launcher: %w", err)
+	}
+	registered, ok := s.forms[launcher.Form]
+	if !ok {
+		return fmt.Errorf("unknown form: %s", launcher.Form)
+	}
+	locale := s.payloadLocale(payload)
+	channelID := payload.Channel.ID
+	if channelID == "" {
+		channelID = payload.User.ID
+	}
+	allowed, groups, err := s.canFillForm(context.Background(), payload.User.ID, launcher.Form)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		_, err = s.slackClient.PostEphemeral(channelID, payload.User.ID, slack.MsgOptionText(i18n.T(locale, "form.forbidden", strings.Join(groups, ", ")), false), services.ThreadOption(payload.Container.ThreadTs))
+		return err
+	}
+	thread, err := s.threadService.GetThreadBySlackThread(channelID, payload.Container.ThreadTs)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if err == nil && thread.SlackUserId != payload.User.ID {
+		_, err = s.slackClient.PostEphemeral(channelID, payload.User.ID, slack.MsgOptionText(i18n.T(locale, "form.not_owner"), false), services.ThreadOption(payload.Container.ThreadTs))
+		return err
+	}
+	return s.slackService.OpenFormModal(context.Background(), payload.TriggerID, locale, registered.form, launcher.Prefill, dto.FormMetadata{
+		ChannelID: channelID,
+		ThreadTs:  payload.Container.ThreadTs,
+	})
+}
+
+// HandleViewSubmission validates a form submitted from its modal. It returns
+// the payload to acknowledge the submission with, the errors to show in the
+// modal or nil to close it, and the workflow to run once the submission is
+// acknowledged: Slack only waits 3 seconds for the acknowledgement. The groups
+// allowed to fill the form are checked again, the modal may come from anyone.
+func (s *SlackHandler) HandleViewSubmission(payload slack.InteractionCallback) (interface{}, func() error, error) {
+	form := payload.View.CallbackID
+	registered, ok := s.forms[form]
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported form: %s", form)
+	}
+	var metadata dto.FormMetadata
+	if err := json.Unmarshal([]byte(payload.View.PrivateMetadata), &metadata); err != nil {
+		return nil, nil, fmt.Errorf("invalid metadata of form %s: %w", form, err)
+	}
+	submission := formSubmission{
+		userID:    payload.User.ID,
+		channelID: metadata.ChannelID,
+		threadTs:  metadata.ThreadTs,
+		locale:    s.localeService.Locale(context.Background(), metadata.ChannelID, metadata.ThreadTs, payload.User.ID, ""),
+	}
+	if payload.View.State != nil {
+		submission.values = payload.View.State.Values
+	}
+	allowed, groups, err := s.canFillForm(context.Background(), submission.userID, form)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to check the groups of form %s: %w", form, err)
+	}
+	if !allowed {
+		return slack.NewErrorsViewSubmissionResponse(map[string]string{
+			registered.form.Fields[0].ID: i18n.T(submission.locale, "form.forbidden", strings.Join(groups, ", ")),
+		}), nil, nil
+	}
+	fieldErrors, run, err := registered.handle(submission)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to bind form %s: %w", form, err)
+	}
+	if len(fieldErrors) > 0 {
+		return slack.NewErrorsViewSubmissionResponse(fieldErrors), nil, nil
+	}
+	return nil, func() error {
+		if err := run(); err != nil {
+			return fmt.Errorf("failed to submit form %s: %w", form, err)
+		}
+		return nil
+	}, nil
+}
@@ -0,0 +1,203 @@
+package slack_handlers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/slack-go/slack"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/mocks"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleOpenForm(t *testing.T) {
+	launcher := `{"form":"create_buddy","prefill":{"transformation_input_file":"https://docs.google.com/spreadsheets/d/input/edit"}}`
+
+	tests := []struct {
+		name           string
+		userID         string
+		value          string
+		fromHome       bool
+		wantModal      bool
+		wantMetadata   string
+		wantEphemerals int
+		wantErr        string
+	}{
+		{name: "owner opens the form in a modal", userID: "U1", value: launcher, wantModal: true, wantMetadata: `{"channel_id":"C1","thread_ts":"1700000000.000100"}`},
+		{name: "form opened from the Home tab is answered in the app DM", userID: "U2", value: launcher, fromHome: true, wantModal: true, wantMetadata: `{"channel_id":"U2","thread_ts":""}`},
+		{name: "other users cannot open the form", userID: "U2", value: launcher, wantEphemerals: 1},
+		{name: "users outside the groups of the command cannot open the form", userID: "U3", value: launcher, fromHome: true, wantEphemerals: 1},
+		{name: "unknown form", userID: "U1", value: `{"form":"book_meeting_room"}`, wantErr: "unknown form: book_meeting_room"},
+		{name: "invalid launcher", userID: "U1", value: "submit_create_buddy", wantErr: "invalid form launcher"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			azure := mocks.NewFakeAzureServer()
+			defer azure.Close()
+			slackServer := mocks.NewFakeSlackServer()
+			defer slackServer.Close()
+			slackServer.UserGroups = []slack.UserGroup{{ID: "S1", Handle: "hr", Users: []string{"U1", "U2"}}}
+			handler := newTestSlackHandler(t, azure, slackServer)
+			err := handler.threadService.CreateThread(&models.Thread{ID: "thread_1", ChannelId: "C1", SlackUserId: "U1", SlackThreadTs: "1700000000.000100"})
+			assert.NoError(t, err)
+
+			payload := slack.InteractionCallback{
+				Type:      slack.InteractionTypeBlockActions,
+				TriggerID: "trigger_1",
+				User:      slack.User{ID: tt.userID},
+				Channel:   slack.Channel{GroupConversation: slack.GroupConversation{Conversation: slack.Conversation{ID: "C1"}}},
+				Container: slack.Container{ThreadTs: "1700000000.000100"},
+				ActionCallback: slack.ActionCallbacks{BlockActions: []*slack.BlockAction{
+					{ActionID: "open_form", Value: tt.value},
+				}},
+			}
+			if tt.fromHome {
+				payload.Channel = slack.Channel{}
+				payload.Container = slack.Container{Type: "view"}
+			}
+			_, err = handler.HandleBlockAction(payload)
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			opened := slackServer.Calls("views.open")
+			assert.Equal(t, tt.wantModal, len(opened) == 1)
+			assert.Len(t, slackServer.Calls("chat.postEphemeral"), tt.wantEphemerals)
+			if !tt.wantModal {
+				return
+			}
+			var view slack.View
+			assert.NoError(t, json.Unmarshal(opened[0].View, &view))
+			assert.Equal(t, "trigger_1", opened[0].TriggerID)
+			assert.Equal(t, dto.FormCreateBuddy, view.CallbackID)
+			assert.JSONEq(t, tt.wantMetadata, view.PrivateMetadata)
+			assert.Contains(t, string(opened[0].View), `"initial_value":"https://docs.google.com/spreadsheets/d/input/edit"`)
+		})
+	}
+}
+
+func TestHandleViewSubmission(t *testing.T) {
+	sheet := "https://docs.google.com/spreadsheets/d/sheet/edit"
+	text := func(value string) slack.BlockAction { return slack.BlockAction{Value: value} }
+	option := func(value string) slack.BlockAction {
+		return slack.BlockAction{SelectedOption: slack.OptionBlockObject{Value: value}}
+	}
+	leaveRequest := func(from string, to string, hourFrom string) map[string]map[string]slack.BlockAction {
+		return map[string]map[string]slack.BlockAction{
+			"leave_type":        {"leave_type_input": option("35")},
+			"working_time":      {"working_time_input": option("36")},
+			"request_date_from": {"request_date_from_input": {SelectedDate: from}},
+			"request_date_to":   {"request_date_to_input": {SelectedDate: to}},
+			"hour_from":         {"hour_from_input": {SelectedTime: hourFrom}},
+			"hour_to":           {"hour_to_input": {SelectedTime: "17:30"}},
+			"description":       {"description_input": text("Family trip")},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		userID     string
+		form       string
+		metadata   string
+		values     map[string]map[string]slack.BlockAction
+		wantErrors map[string]string
+		wantSubmit bool
+		wantErr    string
+	}{
+		{
+			name:     "invalid fields are shown in the modal",
+			form:     dto.FormCreateBuddy,
+			metadata: `{"channel_id":"C1","thread_ts":"1700000000.000100"}`,
+			values: map[string]map[string]slack.BlockAction{
+				"transformation_input_file":  {"transformation_input_file_input": text("not a link")},
+				"transformation_output_file": {"transformation_output_file_input": text(sheet)},
+			},
+			wantErrors: map[string]string{"transformation_input_file": "Invalid transformation input file link"},
+		},
+		{
+			name:     "valid form is submitted",
+			form:     dto.FormWelcomeNewEmployee,
+			metadata: `{"channel_id":"C1","thread_ts":"1700000000.000100"}`,
+			values: map[string]map[string]slack.BlockAction{
+				"skill_file":     {"skill_file_input": text(sheet)},
+				"personal_email": {"personal_email_input": text("an@example.com")},
+			},
+			wantSubmit: true,
+		},
+		{
+			name:       "leave ending before it starts",
+			form:       dto.FormCreateLeaveRequest,
+			metadata:   `{"channel_id":"C1","thread_ts":"1700000000.000100"}`,
+			values:     leaveRequest("2024-05-10", "2024-05-09", "08:00"),
+			wantErrors: map[string]string{"request_date_to": "The end date is before the start date"},
+		},
+		{
+			name:       "leave starting outside working hours",
+			form:       dto.FormCreateLeaveRequest,
+			metadata:   `{"channel_id":"C1","thread_ts":"1700000000.000100"}`,
+			values:     leaveRequest("2024-05-09", "2024-05-10", "12:30"),
+			wantErrors: map[string]string{"hour_from": "Pick a working hour, on the hour or half past"},
+		},
+		{
+			name:       "valid leave request",
+			form:       dto.FormCreateLeaveRequest,
+			metadata:   `{"channel_id":"C1","thread_ts":"1700000000.000100"}`,
+			values:     leaveRequest("2024-05-09", "2024-05-10", "08:00"),
+			wantSubmit: true,
+		},
+		{
+			name:     "users outside the groups of the command cannot submit the form",
+			userID:   "U3",
+			form:     dto.FormCreateBuddy,
+			metadata: `{"channel_id":"C1","thread_ts":"1700000000.000100"}`,
+			values: map[string]map[string]slack.BlockAction{
+				"transformation_input_file":  {"transformation_input_file_input": text(sheet)},
+				"transformation_output_file": {"transformation_output_file_input": text(sheet)},
+			},
+			wantErrors: map[string]string{"transformation_input_file": "Only members of hr can fill this form"},
+		},
+		{name: "unknown form", form: "book_meeting_room", wantErr: "unsupported form: book_meeting_room"},
+		{name: "invalid metadata", form: dto.FormCreateBuddy, metadata: "C1", wantErr: "invalid metadata of form create_buddy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			azure := mocks.NewFakeAzureServer()
+			defer azure.Close()
+			slackServer := mocks.NewFakeSlackServer()
+			defer slackServer.Close()
+			slackServer.UserGroups = []slack.UserGroup{{ID: "S1", Handle: "hr", Users: []string{"U1"}}}
+			handler := newTestSlackHandler(t, azure, slackServer)
+			userID := tt.userID
+			if userID == "" {
+				userID = "U1"
+			}
+
+			response, submit, err := handler.HandleViewSubmission(slack.InteractionCallback{
+				Type: slack.InteractionTypeViewSubmission,
+				User: slack.User{ID: userID},
+				View: slack.View{
+					CallbackID:      tt.form,
+					PrivateMetadata: tt.metadata,
+					State:           &slack.ViewState{Values: tt.values},
+				},
+			})
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantSubmit, submit != nil)
+			if tt.wantErrors == nil {
+				assert.Nil(t, response)
+				return
+			}
+			assert.Equal(t, slack.NewErrorsViewSubmissionResponse(tt.wantErrors), response)
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package slack_handlers
+
+import "github.com/slack-go/slack"
+
+// HandleInteraction answers an interaction whatever its type. It returns the
+// payload to acknowledge the interaction with and the work to run once it is
+// acknowledged, if any: Slack shows an error when the acknowledgement takes
+// more than 3 seconds.
+func (s *SlackHandler) HandleInteraction(payload slack.InteractionCallback) (interface{}, func() error, error) {
+	if payload.Type == slack.InteractionTypeViewSubmission {
+		return s.HandleViewSubmission(payload)
+	}
+	_, err := s.HandleBlockAction(payload)
+	return nil, nil, err
+}
@@ -0,0 +1,19 @@
+package slack_handlers
+
+import (
+	"strings"
+
+	"github.com/slack-go/slack/slackevents"
+)
+
+// handleReactionAddedEvent evicts the cached answer given a thumbs down, the
+// next user asking the same question gets a fresh answer.
+func (s *SlackHandler) handleReactionAddedEvent(event *slackevents.ReactionAddedEvent) error {
+	// Skin tones are suffixed to the reaction name, e.g. -1::skin-tone-2.
+	reaction, _, _ := strings.Cut(event.Reaction, "::")
+	if event.Item.Type != "message" || (reaction != "-1" && reaction != "thumbsdown") {
+		return nil
+	}
+	_, err := s.aiChatbotService.EvictCachedAnswer(event.Item.Channel, event.Item.Timestamp)
+	return err
+}
@@ -1,170 +1,360 @@
 package slack_handlers
 
 import (
+	"context"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/slack-go/slack"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/i18n"
+	"github.com/sotatek-dev/hyper-automation-chatbot/pkg/util"
 )
 
-func (s *SlackHandler) HandleSlashCommand(command slack.SlashCommand, client *slack.Client) (interface{}, error) {
-	// We need to switch depending on the command
-	switch command.Command {
-	case "/hello":
-		// This was a hello command, so pass it along to the proper function
-		return handleHelloCommand(command, client), nil
+// rootCommand is the slash command all the commands are subcommands of.
+const rootCommand = "/hyper"
 
-	case "/was-chatbot-useful":
-		return handleWasChatbotUsefulCommandWithForm(command, client)
-	}
+// groupHR is the user group of the HR team, mapped onto a Slack user group
+// in SLACK_USER_GROUPS.
+const groupHR = "hr"
+
+// directCommands are the slash commands running a command of rootCommand
+// straight away, e.g. /leave runs /hyper leave.
+var directCommands = map[string][]string{
+	"/leave":         {"leave"},
+	"/buddy":         {"buddy"},
+	"/training":      {"training"},
+	"/onboard":       {"onboard"},
+	"/chatbot-usage": {"chatbot", "usage"},
+	"/chatbot-reset": {"chatbot", "reset"},
+}
+
+// commandArg is a positional argument of a command.
+type commandArg struct {
+	name     string
+	optional bool
+	// field is the field of the form of the command the value prefills.
+	field string
+	// rest takes the remaining words of the command text, it is the last
+	// argument.
+	rest bool
+	// valid checks the value, any value is valid when nil.
+	valid func(value string) bool
+}
+
+// commandCall is a command run by a user. args are the values of the
+// arguments of the command by position, empty when left out.
+type commandCall struct {
+	command slack.SlashCommand
+	locale  i18n.Locale
+	args    []string
+}
 
-	return nil, nil
+// slashCommand is a subcommand of rootCommand. A command either groups
+// subcommands, opens a form or runs.
+type slashCommand struct {
+	name string
+	// help is the i18n key of the description of the command.
+	help        string
+	args        []commandArg
+	subcommands []*slashCommand
+	// groups are the user groups allowed to run the command, anyone when
+	// empty.
+	groups []string
+	// form is the ID of the form the command opens in a modal.
+	form string
+	// run answers the command with the message to acknowledge it with.
+	run func(call commandCall) (interface{}, error)
 }
 
-// handleHelloCommand will take care of /hello submissions
-func handleHelloCommand(command slack.SlashCommand, client *slack.Client) error {
-	// The Input is found in the text field so
-	// Create the attachment and assigned based on the message
-	attachment := slack.Attachment{}
-	// Add Some default context like user who mentioned the bot
-	attachment.Fields = []slack.AttachmentField{
+func (s *SlackHandler) newSlashCommands() []*slashCommand {
+	return []*slashCommand{
+		{
+			name: "help",
+			help: "command.help.help",
+			run:  s.handleHelpCommand,
+		},
+		{
+			name: "leave",
+			help: "command.leave.help",
+			args: []commandArg{
+				{name: "from", optional: true, field: "request_date_from", valid: isDate},
+				{name: "to", optional: true, field: "request_date_to", valid: isDate},
+			},
+			form: dto.FormCreateLeaveRequest,
+		},
+		{
+			name: "buddy",
+			help: "command.buddy.help",
+			args: []commandArg{
+				{name: "input_sheet", optional: true, field: "transformation_input_file", valid: util.IsValidGoogleSheetLink},
+				{name: "output_sheet", optional: true, field: "transformation_output_file", valid: util.IsValidGoogleSheetLink},
+			},
+			groups: []string{groupHR},
+			form:   dto.FormCreateBuddy,
+		},
+		{
+			name: "training",
+			help: "command.training.help",
+			args: []commandArg{
+				{name: "sheet", optional: true, field: "sheet_url", valid: util.IsValidGoogleSheetLink},
+				{name: "sheet_name", optional: true, field: "sheet_name", rest: true},
+			},
+			groups: []string{groupHR},
+			form:   dto.FormIntegrateTraining,
+		},
+		{
+			name: "onboard",
+			help: "command.onboard.help",
+			args: []commandArg{
+				{name: "candidate_sheet", optional: true, field: "candidate_file", valid: util.IsValidGoogleSheetLink},
+				{name: "skill_sheet", optional: true, field: "skill_file", valid: util.IsValidGoogleSheetLink},
+			},
+			groups: []string{groupHR},
+			form:   dto.FormCandidateFile,
+		},
+		{
+			name: "welcome",
+			help: "command.welcome.help",
+			args: []commandArg{
+				{name: "skill_sheet", optional: true, field: "skill_file", valid: util.IsValidGoogleSheetLink},
+				{name: "personal_email", optional: true, field: "personal_email", valid: util.IsValidEmail},
+			},
+			groups: []string{groupHR},
+			form:   dto.FormWelcomeNewEmployee,
+		},
 		{
-			Title: "Date",
-			Value: time.Now().String(),
-		}, {
-			Title: "Initializer",
-			Value: command.UserName,
+			name: "chatbot",
+			subcommands: []*slashCommand{
+				{
+					name: "usage",
+					help: "command.usage.help",
+					args: []commandArg{{name: "days", optional: true, valid: isUsageDays}},
+					run:  s.handleChatbotUsageCommand,
+				},
+				{
+					name: "reset",
+					help: "command.reset.help",
+					run:  s.handleChatbotResetCommand,
+				},
+			},
 		},
 	}
+}
+
+// HandleSlashCommand runs the command of rootCommand the slash command
+// names, or the one a direct command stands for. It returns the message to
+// acknowledge the command with: errors are answered with an ephemeral
+// message too, the error is returned to be logged.
+func (s *SlackHandler) HandleSlashCommand(command slack.SlashCommand) (interface{}, error) {
+	locale := s.commandLocale(command)
+	words := strings.Fields(command.Text)
+	if command.Command != rootCommand {
+		path, ok := directCommands[command.Command]
+		if !ok {
+			return ephemeralMessage(i18n.T(locale, "command.unknown", command.Command)), nil
+		}
+		words = append(append([]string{}, path...), words...)
+	}
+	if len(words) == 0 {
+		words = []string{"help"}
+	}
+
+	commands := s.slashCommands
+	var found *slashCommand
+	path := []string{rootCommand}
+	for len(words) > 0 {
+		found = findCommand(commands, words[0])
+		if found == nil {
+			return ephemeralMessage(i18n.T(locale, "command.unknown", strings.Join(append(path, words[0]), " "))), nil
+		}
+		path = append(path, found.name)
+		words = words[1:]
+		if len(found.subcommands) == 0 {
+			break
+		}
+		commands = found.subcommands
+	}
+	if len(found.subcommands) > 0 {
+		return ephemeralMessage(formatCommandHelp(found.subcommands, path, locale)), nil
+	}
 
-	// Greet the user
-	attachment.Text = fmt.Sprintf("Hello %s", command.Text)
-	attachment.Color = "#4af030"
+	args, ok := parseCommandArgs(found.args, words)
+	if !ok {
+		return ephemeralMessage(i18n.T(locale, "command.invalid_usage", commandUsage(found, path[:len(path)-1]))), nil
+	}
+	if len(found.groups) > 0 {
+		allowed, err := s.canRun(context.Background(), command.UserID, found)
+		if err != nil {
+			return ephemeralMessage(i18n.T(locale, "error.generic")), err
+		}
+		if !allowed {
+			return ephemeralMessage(i18n.T(locale, "command.forbidden", strings.Join(found.groups, ", "), strings.Join(path, " "))), nil
+		}
+	}
 
-	// Send the message to the channel
-	// The Channel is available in the command.ChannelID
-	_, _, err := client.PostMessage(command.ChannelID, slack.MsgOptionAttachments(attachment))
+	call := commandCall{command: command, locale: locale, args: args}
+	var payload interface{}
+	var err error
+	if found.form != "" {
+		err = s.openFormCommand(found, call)
+	} else {
+		payload, err = found.run(call)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to post message: %w", err)
+		return ephemeralMessage(i18n.T(locale, "error.generic")), fmt.Errorf("failed to run %s: %w", strings.Join(path, " "), err)
+	}
+	return payload, nil
+}
+
+func findCommand(commands []*slashCommand, name string) *slashCommand {
+	for _, command := range commands {
+		if strings.EqualFold(command.name, name) {
+			return command
+		}
 	}
 	return nil
 }
 
-func handleWasChatbotUsefulCommand(command slack.SlashCommand, client *slack.Client) (interface{}, error) {
-	attachment := slack.Attachment{}
-	checkbox := slack.NewCheckboxGroupsBlockElement("answer",
-		slack.NewOptionBlockObject(
-			"yes",
-			&slack.TextBlockObject{Text: "Yes", Type: slack.MarkdownType},
-			&slack.TextBlockObject{Text: "Did you Enjoy it?", Type: slack.MarkdownType},
-		),
-		slack.NewOptionBlockObject(
-			"no",
-			&slack.TextBlockObject{Text: "No", Type: slack.MarkdownType},
-			&slack.TextBlockObject{Text: "Did you Dislike it?", Type: slack.MarkdownType},
-		),
-	)
-	accessory := slack.NewAccessory(checkbox)
-	attachment.Blocks = slack.Blocks{
-		BlockSet: []slack.Block{
-			// Create a new section block element and add some text and the accessory to it
-			slack.NewSectionBlock(
-				&slack.TextBlockObject{
-					Type: slack.MarkdownType,
-					Text: "Did you think this chatbot was helpful?",
-				},
-				nil,
-				accessory,
-			),
-		},
+// parseCommandArgs gives the words of the command text to the arguments in
+// order. It fails when a required argument is missing, a value is invalid or
+// words are left.
+func parseCommandArgs(declared []commandArg, words []string) ([]string, bool) {
+	args := make([]string, len(declared))
+	for index, arg := range declared {
+		if len(words) == 0 {
+			if !arg.optional {
+				return nil, false
+			}
+			continue
+		}
+		value := words[0]
+		words = words[1:]
+		if arg.rest {
+			value = strings.Join(append([]string{value}, words...), " ")
+			words = nil
+		}
+		if arg.valid != nil && !arg.valid(value) {
+			return nil, false
+		}
+		args[index] = value
 	}
+	return args, len(words) == 0
+}
 
-	attachment.Text = "Rate the tutorial"
-	attachment.Color = "#4af030"
-	return attachment, nil
+// canRun tells whether the user is a member of one of the groups allowed to
+// run the command.
+func (s *SlackHandler) canRun(ctx context.Context, userID string, command *slashCommand) (bool, error) {
+	if len(command.groups) == 0 {
+		return true, nil
+	}
+	return s.slackService.IsUserInGroups(ctx, userID, command.groups)
 }
 
-func handleWasChatbotUsefulCommandWithButton(command slack.SlashCommand, client *slack.Client) (interface{}, error) {
-	attachment := slack.Attachment{}
-	yesButton := slack.NewButtonBlockElement("yes", "yes", &slack.TextBlockObject{Type: slack.PlainTextType, Text: "Yes"})
-	noButton := slack.NewButtonBlockElement("no", "no", &slack.TextBlockObject{Type: slack.PlainTextType, Text: "No"})
+// formCommand returns the command opening the form, nil when none does.
+func formCommand(commands []*slashCommand, formID string) *slashCommand {
+	for _, command := range commands {
+		if command.form == formID {
+			return command
+		}
+		if found := formCommand(command.subcommands, formID); found != nil {
+			return found
+		}
+	}
+	return nil
+}
 
-	numberOfDays := 3
-	date := time.Now().Format("2006-01-02")
-	reason := "illness"
-	text := fmt.Sprintf("Do you want to take %d days off from %s due to %s?", numberOfDays, date, reason)
+// canFillForm tells whether the user can run the command opening the form.
+// The buttons and the assistant open the forms too, the groups of the command
+// apply to them alike. It returns the groups allowed to fill the form.
+func (s *SlackHandler) canFillForm(ctx context.Context, userID string, formID string) (bool, []string, error) {
+	command := formCommand(s.slashCommands, formID)
+	if command == nil {
+		return true, nil, nil
+	}
+	allowed, err := s.canRun(ctx, userID, command)
+	return allowed, command.groups, err
+}
 
-	attachment.Blocks = slack.Blocks{
-		BlockSet: []slack.Block{
-			slack.NewSectionBlock(
-				&slack.TextBlockObject{
-					Type: slack.MarkdownType,
-					Text: text,
-				},
-				nil,
-				nil,
-			),
-			slack.NewActionBlock(
-				"chatbot_rating",
-				yesButton,
-				noButton,
-			),
-		},
+// openFormCommand opens the form of the command in a modal, prefilled with
+// the arguments. The submission is answered in the channel the command was
+// run in.
+func (s *SlackHandler) openFormCommand(command *slashCommand, call commandCall) error {
+	registered, ok := s.forms[command.form]
+	if !ok {
+		return fmt.Errorf("unknown form: %s", command.form)
+	}
+	prefill := map[string]string{}
+	for index, arg := range command.args {
+		if call.args[index] != "" {
+			prefill[arg.field] = call.args[index]
+		}
 	}
+	return s.slackService.OpenFormModal(context.Background(), call.command.TriggerID, call.locale, registered.form, prefill, dto.FormMetadata{
+		ChannelID: call.command.ChannelID,
+	})
+}
 
-	attachment.Text = text
-	attachment.Color = "#4af030"
-	return attachment, nil
+// handleHelpCommand lists the commands with their usage.
+func (s *SlackHandler) handleHelpCommand(call commandCall) (interface{}, error) {
+	help := formatCommandHelp(s.slashCommands, []string{rootCommand}, call.locale)
+	direct := make([]string, 0, len(directCommands))
+	for command := range directCommands {
+		direct = append(direct, "`"+command+"`")
+	}
+	sort.Strings(direct)
+	help += "\n" + i18n.T(call.locale, "command.help.direct", strings.Join(direct, ", "))
+	return ephemeralMessage(help), nil
 }
 
-func handleWasChatbotUsefulCommandWithForm(command slack.SlashCommand, client *slack.Client) (interface{}, error) {
-	// Create a new modal view
-	modalView := slack.ModalViewRequest{
-		Type: slack.ViewType("modal"),
-		Title: &slack.TextBlockObject{
-			Type: slack.PlainTextType,
-			Text: "Rate the Chatbot",
-		},
-		Close: &slack.TextBlockObject{
-			Type: slack.PlainTextType,
-			Text: "Cancel",
-		},
-		Submit: &slack.TextBlockObject{
-			Type: slack.PlainTextType,
-			Text: "Submit",
-		},
-		Blocks: slack.Blocks{
-			BlockSet: []slack.Block{
-				slack.NewInputBlock(
-					"rating_block",
-					&slack.TextBlockObject{
-						Type: slack.PlainTextType,
-						Text: "Enter the rating",
-					},
-					&slack.TextBlockObject{
-						Type: slack.PlainTextType,
-						Text: "Enter a rating from 1 to 5",
-					},
-					&slack.PlainTextInputBlockElement{
-						Type:        slack.METPlainTextInput,
-						ActionID:    "rating_input",
-						Placeholder: &slack.TextBlockObject{Type: slack.PlainTextType, Text: "Enter a rating from 1 to 5"},
-						MaxLength:   1,
-					},
-				),
-			},
-		},
+// formatCommandHelp lists the commands found under path, one per line.
+func formatCommandHelp(commands []*slashCommand, path []string, locale i18n.Locale) string {
+	lines := []string{i18n.T(locale, "command.help.title")}
+	var list func(commands []*slashCommand, path []string)
+	list = func(commands []*slashCommand, path []string) {
+		for _, command := range commands {
+			if len(command.subcommands) > 0 {
+				list(command.subcommands, append(append([]string{}, path...), command.name))
+				continue
+			}
+			line := fmt.Sprintf("• `%s` %s", commandUsage(command, path), i18n.T(locale, command.help))
+			if len(command.groups) > 0 {
+				line += " " + i18n.T(locale, "command.help.groups", strings.Join(command.groups, ", "))
+			}
+			lines = append(lines, line)
+		}
 	}
+	list(commands, path)
+	return strings.Join(lines, "\n")
+}
 
-	// Open the modal
-	_, err := client.OpenView(command.TriggerID, modalView)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open modal: %w", err)
+// commandUsage is the command under path with its arguments, optional ones
+// in brackets, e.g. "/hyper chatbot usage [days]".
+func commandUsage(command *slashCommand, path []string) string {
+	words := append(append([]string{}, path...), command.name)
+	for _, arg := range command.args {
+		name := arg.name
+		if arg.rest {
+			name += "..."
+		}
+		if arg.optional {
+			words = append(words, "["+name+"]")
+		} else {
+			words = append(words, "<"+name+">")
+		}
 	}
+	return strings.Join(words, " ")
+}
+
+func isDate(value string) bool {
+	_, err := time.Parse("2006-01-02", value)
+	return err == nil
+}
 
-	// Return a response to acknowledge the command
-	return &slack.Msg{
-		ResponseType: slack.ResponseTypeEphemeral,
-		Text:         "Please rate the chatbot in the modal that just opened.",
-	}, nil
+func isUsageDays(value string) bool {
+	days, err := strconv.Atoi(value)
+	return err == nil && days > 0 && days <= maxChatbotUsageDays
 }
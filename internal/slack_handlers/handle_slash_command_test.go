@@ -0,0 +1,88 @@
+package slack_handlers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/slack-go/slack"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleSlashCommand(t *testing.T) {
+	sheet := "https://docs.google.com/spreadsheets/d/input/edit"
+
+	tests := []struct {
+		name      string
+		command   string
+		text      string
+		userID    string
+		wantText  []string
+		wantForm  string
+		wantValue string
+	}{
+		{
+			name:     "help lists the commands",
+			command:  "/hyper",
+			text:     "help",
+			wantText: []string{"`/hyper leave [from] [to]`", "`/hyper chatbot usage [days]`", "`/hyper buddy [input_sheet] [output_sheet]` Create the buddy form from the transformation sheets _(hr only)_", "`/leave`"},
+		},
+		{name: "root command alone shows the help", command: "/hyper", wantText: []string{"`/hyper chatbot reset`"}},
+		{name: "group of commands shows its subcommands", command: "/hyper", text: "chatbot", wantText: []string{"`/hyper chatbot usage [days]`"}},
+		{name: "unknown command", command: "/hyper", text: "book room", wantText: []string{"Unknown command `/hyper book`"}},
+		{name: "unknown slash command", command: "/hello", wantText: []string{"Unknown command `/hello`"}},
+		{name: "invalid argument", command: "/leave", text: "09/05/2024", wantText: []string{"Usage: `/hyper leave [from] [to]`"}},
+		{name: "too many arguments", command: "/hyper", text: "chatbot reset now", wantText: []string{"Usage: `/hyper chatbot reset`"}},
+		{name: "direct command opens the form", command: "/leave", text: "2024-05-09", wantForm: dto.FormCreateLeaveRequest, wantValue: `"initial_date":"2024-05-09"`},
+		{name: "member of the group runs the command", command: "/buddy", text: sheet, userID: "U1", wantForm: dto.FormCreateBuddy, wantValue: `"initial_value":"` + sheet + `"`},
+		{name: "other users cannot run the command", command: "/hyper", text: "buddy", userID: "U2", wantText: []string{"Only the members of hr can run `/hyper buddy`."}},
+		{name: "rest argument takes the remaining words", command: "/training", text: sheet + " Sales onboarding", userID: "U1", wantForm: dto.FormIntegrateTraining, wantValue: `"initial_value":"Sales onboarding"`},
+		{name: "subcommand with argument", command: "/chatbot-usage", text: "14", wantText: []string{"You did not use the assistant in the last 14 days."}},
+		{name: "argument out of range", command: "/chatbot-usage", text: "365", wantText: []string{"Usage: `/hyper chatbot usage [days]`"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			azure := mocks.NewFakeAzureServer()
+			defer azure.Close()
+			slackServer := mocks.NewFakeSlackServer()
+			defer slackServer.Close()
+			slackServer.UserGroups = []slack.UserGroup{{ID: "S1", Handle: "hr", Users: []string{"U1"}}}
+			handler := newTestSlackHandler(t, azure, slackServer)
+			userID := tt.userID
+			if userID == "" {
+				userID = "U3"
+			}
+
+			payload, err := handler.HandleSlashCommand(slack.SlashCommand{
+				Command:   tt.command,
+				Text:      tt.text,
+				UserID:    userID,
+				ChannelID: "C1",
+				TriggerID: "trigger_1",
+			})
+
+			assert.NoError(t, err)
+			opened := slackServer.Calls("views.open")
+			if tt.wantForm == "" {
+				assert.Empty(t, opened)
+				msg, ok := payload.(*slack.Msg)
+				assert.True(t, ok)
+				assert.Equal(t, slack.ResponseTypeEphemeral, msg.ResponseType)
+				for _, text := range tt.wantText {
+					assert.Contains(t, msg.Text, text)
+				}
+				return
+			}
+			assert.Nil(t, payload)
+			assert.Len(t, opened, 1)
+			var view slack.View
+			assert.NoError(t, json.Unmarshal(opened[0].View, &view))
+			assert.Equal(t, "trigger_1", opened[0].TriggerID)
+			assert.Equal(t, tt.wantForm, view.CallbackID)
+			assert.JSONEq(t, `{"channel_id":"C1","thread_ts":""}`, view.PrivateMetadata)
+			assert.Contains(t, string(opened[0].View), tt.wantValue)
+		})
+	}
+}
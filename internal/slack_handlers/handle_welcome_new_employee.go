@@ -1,31 +1,38 @@
 package slack_handlers
 
 import (
-	"context"
-
-	"github.com/slack-go/slack"
 	"github.com/sotatek-dev/hyper-automation-chatbot/internal/dto"
-	"github.com/sotatek-dev/hyper-automation-chatbot/pkg/util"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/forms"
+	"github.com/sotatek-dev/hyper-automation-chatbot/internal/i18n"
 )
 
-func (s *SlackHandler) handleGreetingNewEmployeeSubmission(payload slack.InteractionCallback) error {
-	submittedSkillFile := payload.BlockActionState.Values["skill_file"]["skill_file_input"].Value
-	submittedPersonalEmail := payload.BlockActionState.Values["personal_email"]["personal_email_input"].Value
-	if !util.IsValidGoogleSheetLink(submittedSkillFile) {
-		err := s.slackService.SendMessage(context.Background(), &payload.Channel.ID, "Invalid skill file link")
-		return err
-	}
-	if !util.IsValidEmail(submittedPersonalEmail) {
-		err := s.slackService.SendMessage(context.Background(), &payload.Channel.ID, "Invalid personal email")
-		return err
+var welcomeNewEmployeeForm = &forms.Form{
+	ID:     dto.FormWelcomeNewEmployee,
+	Title:  "form.welcome_new_employee.title",
+	Prompt: "form.skill_file.prompt",
+	Fields: []forms.Field{
+		{ID: "skill_file", Type: forms.FieldSheetURL, Invalid: "validation.invalid_skill_file"},
+		{ID: "personal_email", Type: forms.FieldEmail, Invalid: "validation.invalid_personal_email"},
+	},
+}
+
+type welcomeNewEmployeeValues struct {
+	SkillFile     string `form:"skill_file"`
+	PersonalEmail string `form:"personal_email"`
+}
+
+func (s *SlackHandler) handleGreetingNewEmployeeSubmission(submission formSubmission, values welcomeNewEmployeeValues) (map[string]string, func() error) {
+	return nil, func() error {
+		return s.uiPathJobService.CreateGreetingJob(dto.UIPathGreetingNewEmployee{
+			SkillFile:     values.SkillFile,
+			PersonalEmail: values.PersonalEmail,
+		}, submission.userID, submission.channelID, submission.threadTs, submission.locale)
 	}
-	err := s.uiPathJobService.CreateGreetingJob(dto.UIPathGreetingNewEmployee{
-		SkillFile:     submittedSkillFile,
-		PersonalEmail: submittedPersonalEmail,
-	}, payload.Channel.ID)
-	return err
 }
 
-func (s *SlackHandler) handleGreetingNewEmployeeEvent(channelID string) error {
-	return s.slackService.SendWelcomeNewEmployeeForm(context.Background(), channelID)
+func (s *SlackHandler) handleGreetingNewEmployeeEvent(channelID string, threadTs string, locale i18n.Locale, args dto.WelcomeNewEmployeeArguments) error {
+	return s.sendFormLauncher(channelID, threadTs, locale, dto.FormWelcomeNewEmployee, welcomeNewEmployeeValues{
+		SkillFile:     args.SkillFileURL,
+		PersonalEmail: args.PersonalEmail,
+	})
 }
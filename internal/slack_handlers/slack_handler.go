@@ -1,18 +1,31 @@
 package slack_handlers
 
 import (
+	"github.com/rs/zerolog"
 	"github.com/slack-go/slack"
 	"github.com/sotatek-dev/hyper-automation-chatbot/internal/services"
 )
 
 type SlackHandler struct {
-	slackClient      *slack.Client
-	slackService     *services.SlackService
-	aiChatbotService *services.AIChatbotService
-	ggSheetService   *services.GSheetService
-	uiPathJobService *services.UIPathJobService
+	slackClient          *slack.Client
+	slackService         *services.SlackService
+	aiChatbotService     *services.AIChatbotService
+	ggSheetService       *services.GSheetService
+	uiPathJobService     *services.UIPathJobService
+	threadService        *services.ThreadService
+	usageService         *services.UsageService
+	columnMappingService *services.ColumnMappingService
+	localeService        *services.LocaleService
+	toolCallHandlers     map[string]toolCallHandler
+	forms                map[string]registeredForm
+	slashCommands        []*slashCommand
+	logger               *zerolog.Logger
 }
 
-func NewSlackHandler(slackClient *slack.Client, slackService *services.SlackService, aiChatbotService *services.AIChatbotService, ggSheetService *services.GSheetService, uiPathJobService *services.UIPathJobService) *SlackHandler {
-	return &SlackHandler{slackClient: slackClient, slackService: slackService, aiChatbotService: aiChatbotService, ggSheetService: ggSheetService, uiPathJobService: uiPathJobService}
+func NewSlackHandler(slackClient *slack.Client, slackService *services.SlackService, aiChatbotService *services.AIChatbotService, ggSheetService *services.GSheetService, uiPathJobService *services.UIPathJobService, threadService *services.ThreadService, usageService *services.UsageService, columnMappingService *services.ColumnMappingService, localeService *services.LocaleService, logger *zerolog.Logger) *SlackHandler {
+	s := &SlackHandler{slackClient: slackClient, slackService: slackService, aiChatbotService: aiChatbotService, ggSheetService: ggSheetService, uiPathJobService: uiPathJobService, threadService: threadService, usageService: usageService, columnMappingService: columnMappingService, localeService: localeService, logger: logger}
+	s.toolCallHandlers = s.newToolCallHandlers()
+	s.forms = s.newForms()
+	s.slashCommands = s.newSlashCommands()
+	return s
 }
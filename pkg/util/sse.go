@@ -0,0 +1,58 @@
+package util
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// ReadServerSentEvents reads a text/event-stream body and calls handle for
+// every event it contains. Multi-line data fields are joined with a newline,
+// comments and unknown fields are skipped. Reading stops at the end of the
+// stream or at the first error returned by handle.
+func ReadServerSentEvents(r io.Reader, handle func(event string, data []byte) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	event := ""
+	data := []string{}
+	dispatch := func() error {
+		if len(data) == 0 {
+			event = ""
+			return nil
+		}
+		name := event
+		if name == "" {
+			name = "message"
+		}
+		payload := strings.Join(data, "\n")
+		event = ""
+		data = data[:0]
+		return handle(name, []byte(payload))
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if err := dispatch(); err != nil {
+				return err
+			}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return dispatch()
+}
@@ -0,0 +1,67 @@
+package util
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadServerSentEvents(t *testing.T) {
+	type event struct {
+		name string
+		data string
+	}
+	tests := []struct {
+		name   string
+		stream string
+		want   []event
+	}{
+		{
+			name:   "named events",
+			stream: "event: thread.run.created\ndata: {\"id\":\"run_1\"}\n\nevent: done\ndata: [DONE]\n\n",
+			want: []event{
+				{name: "thread.run.created", data: `{"id":"run_1"}`},
+				{name: "done", data: "[DONE]"},
+			},
+		},
+		{
+			name:   "multi line data and comments",
+			stream: ": keep-alive\ndata: first\ndata: second\n\n",
+			want:   []event{{name: "message", data: "first\nsecond"}},
+		},
+		{
+			name:   "last event without trailing blank line",
+			stream: "event: thread.message.delta\ndata: {}",
+			want:   []event{{name: "thread.message.delta", data: "{}"}},
+		},
+		{
+			name:   "event without data is ignored",
+			stream: "event: ping\n\n",
+			want:   []event{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []event{}
+			err := ReadServerSentEvents(strings.NewReader(tt.stream), func(name string, data []byte) error {
+				got = append(got, event{name: name, data: string(data)})
+				return nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestReadServerSentEventsStopsOnHandlerError(t *testing.T) {
+	stop := errors.New("stop")
+	calls := 0
+	err := ReadServerSentEvents(strings.NewReader("data: a\n\ndata: b\n\n"), func(name string, data []byte) error {
+		calls++
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, calls)
+}